@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"net/http"
+	"strings"
+)
+
+// adminAuth returns middleware that requires the bearer token on every /admin route.
+// An empty token denies every admin request, so a server started without one never exposes them. Elsewhere, a request
+// carrying the token is marked as an admin's, which lets it correct receipts submitted by other principals.
+func adminAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/admin/") {
+				if token != "" && validToken(r, token) {
+					r = r.WithContext(receipts.NewAdminContext(r.Context()))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if token == "" {
+				writeError(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "The admin routes are disabled because no admin token is configured"))
+				return
+			}
+			if !validToken(r, token) {
+				writeError(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "Missing or invalid admin token"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validToken reports whether the request carries the admin token as its bearer token.
+func validToken(r *http.Request, token string) bool {
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fetch-app/receipts"
+	"fetch-app/review"
+	"fetch-app/server"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// storePendingReceipt stores a receipt awaiting review and returns its ID.
+func storePendingReceipt(storage *receipts.Storage, submittedAt time.Time) string {
+	receiptID := uuid.New().String()
+	storage.Put(&receipts.Record{
+		ID:            receiptID,
+		Receipt:       duplicateTestReceipt(),
+		SubmittedAt:   submittedAt,
+		Status:        review.StatusPendingReview,
+		ReviewReasons: []string{"points 1200 exceed the limit of 1000"},
+	})
+	return receiptID
+}
+
+// testAdminToken is the admin token the tests serve the admin routes with.
+const testAdminToken = "secret"
+
+// serveAdmin registers all routes with admin authentication and serves the request, sending the token as the bearer
+// token unless the request has an Authorization header.
+func serveAdmin(service *receipts.Service, token string, req *http.Request) *httptest.ResponseRecorder {
+	if req.Header.Get("Authorization") == "" && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	api, err := NewAPI(service, APIOptions{AdminToken: token})
+	if err != nil {
+		panic(err)
+	}
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	return rec
+}
+
+// decisionRequest builds an approve or reject request with the given reason.
+func decisionRequest(id, action, reason string) *http.Request {
+	body, _ := json.Marshal(server.ReviewDecision{Reason: reason})
+	req := httptest.NewRequest(http.MethodPost, "/admin/receipts/"+id+"/"+action, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// TestGetAdminReviewQueue tests that only pending receipts are listed, oldest first.
+func TestGetAdminReviewQueue(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	now := time.Now().UTC()
+	newer := storePendingReceipt(service.Storage, now)
+	older := storePendingReceipt(service.Storage, now.Add(-time.Hour))
+	service.Storage.Put(&receipts.Record{ID: uuid.New().String(), Status: review.StatusApproved})
+
+	rec := serveAdmin(service, testAdminToken, httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response, 2)
+	assert.Equal(t, older, response[0]["id"])
+	assert.Equal(t, newer, response[1]["id"])
+}
+
+// TestApproveMakesPointsVisible tests the pending, approved flow through the points endpoint.
+func TestApproveMakesPointsVisible(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	receiptID := storePendingReceipt(service.Storage, time.Now().UTC())
+
+	// Points are withheld while the receipt is pending
+	rec := serveAdmin(service, testAdminToken, httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID+"/points", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"status": "pending_review"}`, rec.Body.String())
+
+	rec = serveAdmin(service, testAdminToken, decisionRequest(receiptID, "approve", "Checked with the retailer"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	record, _ := service.Storage.Get(receiptID)
+	assert.Equal(t, review.StatusApproved, record.Status)
+	assert.Equal(t, "Checked with the retailer", record.Decision.Reason)
+
+	rec = serveAdmin(service, testAdminToken, httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID+"/points", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "points")
+
+	// A decided receipt cannot be decided again
+	rec = serveAdmin(service, testAdminToken, decisionRequest(receiptID, "reject", "Changed my mind"))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+// TestRejectHidesPoints tests the pending, rejected flow through the points endpoint.
+func TestRejectHidesPoints(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	receiptID := storePendingReceipt(service.Storage, time.Now().UTC())
+
+	// A rejection needs a reason
+	rec := serveAdmin(service, testAdminToken, decisionRequest(receiptID, "reject", ""))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAdmin(service, testAdminToken, decisionRequest(receiptID, "reject", "Duplicate photo"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveAdmin(service, testAdminToken, httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID+"/points", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Duplicate photo")
+}
+
+// TestDecisionNotFound tests deciding on a receipt that does not exist.
+func TestDecisionNotFound(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	rec := serveAdmin(service, testAdminToken, decisionRequest(uuid.New().String(), "approve", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestAdminAuth tests that the admin routes require the configured token.
+func TestAdminAuth(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	req := httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(service, "secret", req).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil)
+	assert.Equal(t, http.StatusOK, serveAdmin(service, "secret", req).Code)
+
+	// Without a token configured the admin routes are closed
+	req = httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil)
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(service, "", req).Code)
+	req = httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	assert.Equal(t, http.StatusUnauthorized, serveAdmin(service, "", req).Code)
+
+	// Non-admin routes stay open
+	req = httptest.NewRequest(http.MethodGet, "/receipts/"+uuid.New().String()+"/points", nil)
+	assert.Equal(t, http.StatusNotFound, serveAdmin(service, "secret", req).Code)
+}
@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Action is the kind of mutation an entry records.
+type Action string
+
+const (
+	// ActionReceiptCreated records a stored receipt. The content hash covers the receipt as submitted.
+	ActionReceiptCreated Action = "receipt_created"
+	// ActionReceiptCorrected records a receipt replaced with a corrected version. The content hash covers the
+	// corrected receipt, and the detail names the version.
+	ActionReceiptCorrected Action = "receipt_corrected"
+	// ActionPointsAwarded records the points a receipt earned when it was approved, automatically or on review.
+	ActionPointsAwarded Action = "points_awarded"
+	// ActionPointsRecomputed records a receipt whose points were computed again, such as after its items were
+	// purged and its score frozen or after it was corrected.
+	ActionPointsRecomputed Action = "points_recomputed"
+	// ActionReceiptDeleted records a receipt removed from the store. The detail says why.
+	ActionReceiptDeleted Action = "receipt_deleted"
+	// ActionReceiptApproved records an admin approving a receipt held for review.
+	ActionReceiptApproved Action = "receipt_approved"
+	// ActionReceiptRejected records an admin rejecting a receipt held for review.
+	ActionReceiptRejected Action = "receipt_rejected"
+	// ActionImageAttached records an image attached to a receipt. The content hash covers the image's metadata,
+	// which includes the hash of the image itself.
+	ActionImageAttached Action = "image_attached"
+	// ActionImageDeleted records the image of a receipt removed without the receipt. The detail says why.
+	ActionImageDeleted Action = "image_deleted"
+)
+
+const (
+	// PrincipalAdmin is the principal of the changes made through the admin API, which is not told who holds the token.
+	PrincipalAdmin = "admin"
+	// PrincipalSystem is the principal of the changes the service makes on its own, such as retention purges.
+	PrincipalSystem = "system"
+)
+
+// Entry is one record of the audit log. Each entry includes the hash of the one before it, so changing, removing
+// or reordering any entry breaks every hash after it.
+type Entry struct {
+	// Seq numbers the entries from 1.
+	Seq    int64     `json:"seq"`
+	At     time.Time `json:"at"`
+	Action Action    `json:"action"`
+	// Principal is who made the change: the hash of the submitter's principal, PrincipalAdmin or PrincipalSystem.
+	Principal string `json:"principal"`
+	ReceiptID string `json:"receiptId"`
+	// ContentHash is the SHA-256 hash of the content the change concerns, such as the receipt or the decision.
+	ContentHash string `json:"contentHash,omitempty"`
+	Points      *int64 `json:"points,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	// PrevHash is the hash of the previous entry, or empty for the first.
+	PrevHash string `json:"prevHash"`
+	// Hash is the SHA-256 hash of this entry with an empty Hash, which covers PrevHash and so the whole chain.
+	Hash string `json:"hash"`
+}
+
+// computeHash returns the hash the entry should have.
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentHash returns the SHA-256 hash of a value's JSON encoding, for Entry.ContentHash.
+func ContentHash(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Log appends entries to an audit log of JSON lines, chaining each to the one before. It is safe for concurrent use.
+type Log struct {
+	mu   sync.Mutex
+	w    io.Writer
+	seq  int64
+	last string
+	now  func() time.Time
+}
+
+// NewLog creates an empty Log writing to w.
+func NewLog(w io.Writer) *Log {
+	return &Log{w: w, now: time.Now}
+}
+
+// Open opens the audit log file at path for appending, creating it if needed. The existing entries are verified
+// first, since appending to a broken chain would hide where it was broken. A last line cut short, as a crash while
+// appending leaves it, is dropped first: the entry it held was never acknowledged, so the chain before it is intact.
+//
+// Returns:
+//
+//	The Log and the file it writes to, or an error if the file cannot be opened or its chain is broken.
+func Open(path string) (*Log, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	if err := dropTornLine(file); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("recovering audit log: %w", err)
+	}
+	last, err := Verify(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	l := NewLog(file)
+	l.seq, l.last = last.Seq, last.Hash
+	return l, file, nil
+}
+
+// dropTornLine truncates the log after its last complete line if it does not end with a newline, and leaves the file
+// positioned at its start. Every entry is written with its newline in one write, so a last line without one was cut
+// short.
+func dropTornLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	// Look back from the end for the newline closing the last complete line
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for offset := end; offset > 0; {
+		n := int64(len(buf))
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		if _, err := file.ReadAt(buf[:n], offset); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = offset + int64(i) + 1
+			break
+		}
+		if offset == 0 {
+			end = 0
+		}
+	}
+	if end < info.Size() {
+		log.Printf("Dropping the last %d bytes of the audit log, a line cut short by an interrupted append", info.Size()-end)
+		if err := file.Truncate(end); err != nil {
+			return err
+		}
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+// Append completes the entry with its sequence number, time and hashes and writes it to the log.
+// The principal, action, receipt ID, content hash, points and detail are taken from the entry given.
+//
+// Returns:
+//
+//	The entry as written, or an error if it could not be written, in which case the chain is unchanged.
+func (l *Log) Append(entry Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.seq + 1
+	entry.At = l.now().UTC()
+	entry.PrevHash = l.last
+	entry.Hash = entry.computeHash()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		return Entry{}, fmt.Errorf("writing audit entry: %w", err)
+	}
+	l.seq, l.last = entry.Seq, entry.Hash
+	return entry, nil
+}
+
+// ChainError reports where an audit log's chain is broken.
+type ChainError struct {
+	// Line is the line of the first entry that does not verify.
+	Line int
+	// Reason says what does not match.
+	Reason string
+}
+
+// Error returns the line and the reason.
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit log is broken at line %d: %s", e.Line, e.Reason)
+}
+
+// Verify reads an audit log and checks that every entry follows the one before: sequence numbers count up from 1,
+// each entry names the previous entry's hash, and each entry's hash matches its contents.
+//
+// Returns:
+//
+//	The last entry, which is empty for an empty log, or a *ChainError locating the first entry that does not
+//	verify. Any change to an entry, or a removed or reordered one, is found.
+func Verify(r io.Reader) (Entry, error) {
+	var last Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return last, &ChainError{Line: line, Reason: "not a valid entry"}
+		}
+		switch {
+		case entry.Seq != last.Seq+1:
+			return last, &ChainError{Line: line, Reason: fmt.Sprintf("sequence %d follows %d", entry.Seq, last.Seq)}
+		case entry.PrevHash != last.Hash:
+			return last, &ChainError{Line: line, Reason: "previous hash does not match the previous entry"}
+		case entry.Hash != entry.computeHash():
+			return last, &ChainError{Line: line, Reason: "hash does not match the entry"}
+		}
+		last = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return last, fmt.Errorf("reading audit log: %w", err)
+	}
+	return last, nil
+}
+
+// IsChainError reports whether err says the chain is broken, rather than that the log could not be read.
+func IsChainError(err error) bool {
+	var chainErr *ChainError
+	return errors.As(err, &chainErr)
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLog appends an entry for each receipt ID to a new log and returns what was written.
+func writeLog(t *testing.T, receiptIDs ...string) []string {
+	var buf bytes.Buffer
+	l := NewLog(&buf)
+	for _, id := range receiptIDs {
+		if _, err := l.Append(Entry{Action: ActionReceiptCreated, Principal: "alice", ReceiptID: id}); err != nil {
+			t.Fatalf("Error appending entry: %v", err)
+		}
+	}
+	return strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// TestAppendVerify tests that appended entries are chained and verify.
+func TestAppendVerify(t *testing.T) {
+	lines := writeLog(t, "a", "b", "c")
+	assert.Len(t, lines, 3)
+
+	last, err := Verify(strings.NewReader(strings.Join(lines, "")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), last.Seq)
+	assert.Equal(t, "c", last.ReceiptID)
+	assert.Equal(t, last.Hash, last.computeHash())
+
+	last, err = Verify(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Zero(t, last.Seq)
+}
+
+// TestVerifyTampering tests that a changed, removed or reordered entry breaks the chain where it happened.
+func TestVerifyTampering(t *testing.T) {
+	lines := writeLog(t, "a", "b", "c")
+
+	tests := []struct {
+		name string
+		log  []string
+		line int
+	}{
+		{"changed", []string{lines[0], strings.Replace(lines[1], `"alice"`, `"mallory"`, 1), lines[2]}, 2},
+		{"removed", []string{lines[0], lines[2]}, 2},
+		{"reordered", []string{lines[1], lines[0], lines[2]}, 1},
+		{"garbled", []string{lines[0], "{\n"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(strings.NewReader(strings.Join(tt.log, "")))
+			assert.True(t, IsChainError(err))
+			assert.Equal(t, tt.line, err.(*ChainError).Line)
+		})
+	}
+}
+
+// TestOpen tests that a reopened log continues the chain, and that a tampered one is not extended.
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, file, err := Open(path)
+	assert.NoError(t, err)
+	_, err = l.Append(Entry{Action: ActionReceiptCreated, ReceiptID: "a"})
+	assert.NoError(t, err)
+	file.Close()
+
+	l, file, err = Open(path)
+	assert.NoError(t, err)
+	entry, err := l.Append(Entry{Action: ActionReceiptDeleted, ReceiptID: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), entry.Seq)
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	_, err = Verify(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(path, bytes.Replace(data, []byte(`"a"`), []byte(`"b"`), 1), 0o600))
+	_, _, err = Open(path)
+	assert.True(t, IsChainError(err))
+}
+
+// TestOpenTornLine tests that a last line cut short by a crash is dropped, and the chain continued after the last
+// complete entry.
+func TestOpenTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	lines := writeLog(t, "a", "b")
+	torn := lines[1][:len(lines[1])/2]
+	assert.NoError(t, os.WriteFile(path, []byte(lines[0]+torn), 0o600))
+
+	l, file, err := Open(path)
+	assert.NoError(t, err)
+	entry, err := l.Append(Entry{Action: ActionReceiptCreated, ReceiptID: "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), entry.Seq)
+	file.Close()
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	last, err := Verify(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "c", last.ReceiptID)
+
+	// A log holding nothing but a torn line starts over
+	assert.NoError(t, os.WriteFile(path, []byte(torn), 0o600))
+	l, file, err = Open(path)
+	assert.NoError(t, err)
+	entry, err = l.Append(Entry{Action: ActionReceiptCreated, ReceiptID: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), entry.Seq)
+	file.Close()
+}
@@ -0,0 +1,71 @@
+package calculation
+
+import (
+	"fetch-app/server"
+)
+
+// RulePoints is the number of points one rule awards a receipt.
+type RulePoints struct {
+	// Rule identifies the rule, such as "retailer_name".
+	Rule string
+	// Description explains what the rule rewards.
+	Description string
+	// Points is the number of points the rule awards. Zero if the receipt does not qualify.
+	Points int
+}
+
+// Breakdown scores a receipt rule by rule, listing every rule in order whether or not it awards points. Custom rules
+// follow the built-in ones as "custom:" and their name, explained as they explain themselves. CalculatePoints adds
+// up the same rules, so the points always add up to it.
+func (c Calculator) Breakdown(receipt server.Receipt) []RulePoints {
+	builtin := c.builtinRules(receipt)
+	rules := make([]RulePoints, 0, len(builtin)+len(c.Scripts))
+	rules = append(rules, builtin[:]...)
+	points := 0
+	for _, rule := range rules {
+		points += rule.Points
+	}
+	for _, rule := range c.Scripts {
+		awarded, explanation := c.runScript(rule, receipt, points)
+		rules = append(rules, RulePoints{"custom:" + rule.Name, explanation, awarded})
+	}
+	return rules
+}
+
+// builtinRules scores a receipt under each of the built-in rules, in order. It returns an array rather than a
+// slice, so CalculatePoints can add it up without allocating.
+func (c Calculator) builtinRules(receipt server.Receipt) [7]RulePoints {
+	parsed := parseReceipt(&receipt, c.Currencies)
+
+	roundDollar, quarter, descriptions, oddDay, afternoon := 0, 0, 0, 0, 0
+	if parsed.validTotal && isMultiple(parsed.total, parsed.round) {
+		roundDollar = 50
+	}
+	if parsed.validTotal && isMultiple(parsed.total, parsed.quarter) {
+		quarter = 25
+	}
+	for i := range receipt.Items {
+		descriptions += c.Text.pointsForItemDescription(receipt.Items[i], parsed.rate)
+	}
+	if isOddDay(parsed.purchasedAt) {
+		oddDay = 6
+	}
+	if parsed.validTime && isBetweenTwoAndFourPM(parsed.purchasedAt) {
+		afternoon = 10
+	}
+
+	return [7]RulePoints{
+		{"retailer_name", "One point for every alphanumeric character in the retailer name", c.Text.countAlphanumeric(receipt.Retailer)},
+		{"round_dollar_total", "50 points if the total is a round dollar amount with no cents, or a round amount in the receipt's currency", roundDollar},
+		{"quarter_total", "25 points if the total is a multiple of 0.25, or of the quarter amount in the receipt's currency", quarter},
+		{"item_pairs", "5 points for every two items on the receipt, counting each unit of an item bought in a quantity", (itemUnits(receipt.Items) / 2) * 5},
+		{"item_descriptions", "The price, in the base currency when the receipt has an exchange rate, multiplied by 0.2 and rounded up for every item whose trimmed description length is a multiple of 3", descriptions},
+		{"odd_day", "6 points if the day in the purchase date is odd", oddDay},
+		{"afternoon_purchase", "10 points if the time of purchase is after 2:00pm and before 4:00pm", afternoon},
+	}
+}
+
+// Helper function to break down points with the original rules
+func Breakdown(receipt server.Receipt) []RulePoints {
+	return Calculator{}.Breakdown(receipt)
+}
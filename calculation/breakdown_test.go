@@ -0,0 +1,76 @@
+package calculation
+
+import (
+	"fetch-app/script"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Test that the breakdown lists every rule and adds up to the total
+func TestBreakdown(t *testing.T) {
+	receipt := createTestReceipt()
+
+	breakdown := Breakdown(receipt)
+	rules := make(map[string]int)
+	total := 0
+	for _, rule := range breakdown {
+		rules[rule.Rule] = rule.Points
+		total += rule.Points
+		assert.NotEmpty(t, rule.Description)
+	}
+
+	assert.Equal(t, map[string]int{
+		"retailer_name":      14,
+		"round_dollar_total": 50,
+		"quarter_total":      25,
+		"item_pairs":         5,
+		"item_descriptions":  0,
+		"odd_day":            0,
+		"afternoon_purchase": 10,
+	}, rules)
+	assert.Equal(t, CalculatePoints(receipt), total)
+}
+
+// Test that the breakdown applies the calculator's text options
+func TestBreakdownTextOptions(t *testing.T) {
+	receipt := createTestReceipt()
+	receipt.Retailer = "Café Ñandú"
+	receipt.Items = []server.Item{{ShortDescription: "東京ラーメン", Price: "10.00"}}
+
+	calculator := Calculator{Text: TextOptions{Unicode: true, Length: LengthRunes}}
+	total := 0
+	for _, rule := range calculator.Breakdown(receipt) {
+		total += rule.Points
+	}
+	assert.Equal(t, calculator.CalculatePoints(receipt), total)
+}
+
+// Test that custom rules are scored on top of the built-in rules and listed after them
+func TestBreakdownScripts(t *testing.T) {
+	receipt := createTestReceipt()
+	sundays, err := script.Compile("sundays", []byte(`
+def score(receipt, points):
+    if weekday(receipt["purchaseDate"]) == "Sunday":
+        return points, "Double points on Sundays"
+    return 0, "Double points on Sundays"
+`), script.Limits{})
+	assert.NoError(t, err)
+	broken, err := script.Compile("broken", []byte("def score(receipt, points):\n    return None\n"), script.Limits{})
+	assert.NoError(t, err)
+
+	calculator := Calculator{Scripts: []*script.Rule{sundays, broken}}
+	assert.Equal(t, 2*CalculatePoints(receipt), calculator.CalculatePoints(receipt))
+
+	breakdown := calculator.Breakdown(receipt)
+	if assert.Len(t, breakdown, 9) {
+		assert.Equal(t, RulePoints{"custom:sundays", "Double points on Sundays", CalculatePoints(receipt)}, breakdown[7])
+		assert.Equal(t, "custom:broken", breakdown[8].Rule)
+		assert.Zero(t, breakdown[8].Points)
+	}
+	total := 0
+	for _, rule := range breakdown {
+		total += rule.Points
+	}
+	assert.Equal(t, calculator.CalculatePoints(receipt), total)
+}
@@ -1,115 +1,211 @@
 package calculation
 
 import (
+	"fetch-app/script"
 	"fetch-app/server" // Corrected import path for Receipt
-	"math"
-	"regexp"
-	"strconv"
+	"fmt"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+	"log"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-// Helper function to calculate points
-func CalculatePoints(receipt server.Receipt) int {
-	points := 0
+// LengthMode selects how the length of an item description is measured for rule 5.
+type LengthMode int
 
-	// Rule 1: One point for every alphanumeric character in the retailer name
-	points += countAlphanumeric(receipt.Retailer)
+const (
+	// LengthBytes counts UTF-8 bytes, so "Café" has length 5. It is the original behaviour.
+	LengthBytes LengthMode = iota
+	// LengthRunes counts Unicode code points, so "Café" has length 4 once normalized to NFC.
+	LengthRunes
+	// LengthGraphemes counts user-perceived characters, so a flag emoji made of two code points has length 1.
+	LengthGraphemes
+)
 
-	// Rule 2: 50 points if the total is a round dollar amount (no cents)
-	if isRoundDollar(receipt.Total) {
-		points += 50
+// ParseLengthMode converts a configuration value into a LengthMode. An empty value selects LengthBytes.
+func ParseLengthMode(s string) (LengthMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "bytes":
+		return LengthBytes, nil
+	case "runes":
+		return LengthRunes, nil
+	case "graphemes":
+		return LengthGraphemes, nil
+	default:
+		return LengthBytes, fmt.Errorf("unknown description length mode %q", s)
 	}
+}
 
-	// Rule 3: 25 points if the total is a multiple of 0.25
-	if isMultipleOfQuarter(receipt.Total) {
-		points += 25
-	}
+// TextOptions controls how the rules read retailer names and item descriptions.
+// The zero value reproduces the original rules, which only understand ASCII.
+type TextOptions struct {
+	// Unicode counts letters and digits from every script for rule 1, rather than only [a-zA-Z0-9].
+	Unicode bool
+	// Length selects how item description lengths are measured for rule 5.
+	Length LengthMode
+	// NormalizeNFC composes text to Unicode Normalization Form C before scoring, so a precomposed "é" and an "e"
+	// followed by a combining accent score the same.
+	NormalizeNFC bool
+}
 
-	// Rule 4: 5 points for every two items on the receipt
-	points += (len(receipt.Items) / 2) * 5
+// Calculator scores receipts. The zero value applies the original rules.
+type Calculator struct {
+	Text TextOptions
+	// Currencies maps ISO 4217 codes to their variants of rules 2 and 3. Currencies without one look for whole
+	// units and quarters of them, or for hundreds and 25s if they have no minor unit.
+	Currencies map[string]TotalRules
+	// Scripts are custom rules run after the built-in ones, each awarding points on top of theirs.
+	Scripts []*script.Rule
+}
 
-	// Rule 5: Points based on item descriptions
-	for _, item := range receipt.Items {
-		points += pointsForItemDescription(item)
-	}
+// Helper function to calculate points with the original rules
+func CalculatePoints(receipt server.Receipt) int {
+	return Calculator{}.CalculatePoints(receipt)
+}
 
-	// Rule 6: 6 points if the day in the purchase date is odd
-	if isOddDay(receipt.PurchaseDate.String()) {
-		points += 6
+// CalculatePoints calculates the points a receipt earns under the calculator's options, including its custom rules,
+// by adding up its breakdown. The receipt is parsed once and scored without heap allocations, unless NFC
+// normalization has to rewrite its text or there are custom rules to run.
+func (c Calculator) CalculatePoints(receipt server.Receipt) int {
+	points := 0
+	for _, rule := range c.builtinRules(receipt) {
+		points += rule.Points
 	}
-
-	// Rule 7: 10 points if the time of purchase is after 2:00pm and before 4:00pm
-	if isBetweenTwoAndFourPM(receipt.PurchaseTime) {
-		points += 10
+	custom := 0
+	for _, rule := range c.Scripts {
+		awarded, _ := c.runScript(rule, receipt, points)
+		custom += awarded
 	}
+	return points + custom
+}
 
-	return points
+// runScript runs a custom rule on a receipt that earned points under the built-in rules. A rule that fails awards
+// nothing, and the failure is logged rather than failing the request.
+func (c Calculator) runScript(rule *script.Rule, receipt server.Receipt, points int) (int, string) {
+	awarded, explanation, err := rule.Score(receipt, points)
+	if err != nil {
+		log.Printf("custom rule %s failed: %v", rule.Name, err)
+		return 0, "The custom rule failed, so it awarded no points"
+	}
+	return awarded, explanation
 }
 
 // Rule 1: Count alphanumeric characters in retailer name
 func countAlphanumeric(s string) int {
-	re := regexp.MustCompile(`[a-zA-Z0-9]`)
-	return len(re.FindAllString(s, -1))
+	count := 0
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			count++
+		}
+	}
+	return count
 }
 
-// Rule 2: Check if total is a round dollar amount (i.e., no cents)
-func isRoundDollar(total string) bool {
-	// Try to parse the total as a float
-	val, err := strconv.ParseFloat(total, 64)
-	if err != nil {
-		return false
+// Rule 1 with Unicode character classes: count letters and digits from every script
+func countUnicodeAlphanumeric(s string) int {
+	count := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+		}
 	}
-	return val == math.Floor(val)
+	return count
 }
 
-// Rule 3: Check if total is a multiple of 0.25
-func isMultipleOfQuarter(total string) bool {
-	val, err := strconv.ParseFloat(total, 64)
-	if err != nil {
-		return false
+// countAlphanumeric applies rule 1 under the text options.
+func (o TextOptions) countAlphanumeric(s string) int {
+	s = o.normalize(s)
+	if o.Unicode {
+		return countUnicodeAlphanumeric(s)
 	}
-	// Check if it's a multiple of 0.25
-	return math.Mod(val, 0.25) == 0
+	return countAlphanumeric(s)
+}
+
+// Rule 2: Check if total is a round dollar amount (i.e., no cents)
+func isRoundDollar(total amount) bool {
+	return isMultiple(total, amountUnit)
+}
+
+// Rule 3: Check if total is a multiple of 0.25
+func isMultipleOfQuarter(total amount) bool {
+	return isMultiple(total, amountUnit/4)
+}
+
+// Rules 2 and 3 in any currency: Check if total is a multiple of the unit
+func isMultiple(total, unit amount) bool {
+	return unit > 0 && total%unit == 0
 }
 
-// Rule 4: 5 points for every two items on the receipt
-// This is handled directly by dividing the length of the items array
+// Rule 4: Count the units of the items on the receipt, so a line for 3 units counts as three items and a line
+// without a quantity as one
+func itemUnits(items []server.Item) int {
+	units := 0
+	for i := range items {
+		if quantity := items[i].Quantity; quantity != nil && *quantity > 1 {
+			units += *quantity
+		} else {
+			units++
+		}
+	}
+	return units
+}
 
 // Rule 5: Points based on item descriptions
 func pointsForItemDescription(item server.Item) int {
+	return TextOptions{}.pointsForItemDescription(item, 0)
+}
+
+// pointsForItemDescription applies rule 5 under the text options, to the price converted into the base currency
+// at the exchange rate unless it is zero.
+func (o TextOptions) pointsForItemDescription(item server.Item, rate amount) int {
 	// Trim the description (remove leading and trailing spaces)
-	trimmedDesc := strings.TrimSpace(item.ShortDescription)
+	trimmedDesc := strings.TrimSpace(o.normalize(item.ShortDescription))
 	// Check if length is a multiple of 3
-	if len(trimmedDesc)%3 == 0 {
-		price, err := strconv.ParseFloat(item.Price, 64)
-		if err != nil {
-			return 0
-		}
-		// Multiply price by 0.2 and round up
-		return int(math.Ceil(price * 0.2))
+	if descriptionLength(trimmedDesc, o.Length)%3 != 0 {
+		return 0
 	}
-	return 0
+	price, ok := parseAmount(item.Price)
+	if ok && rate > 0 {
+		price, ok = toBase(price, rate)
+	}
+	if !ok {
+		return 0
+	}
+	// Multiply price by 0.2 and round up, in exact integer arithmetic
+	return int(ceilDiv(price, 5*amountUnit))
 }
 
-// Rule 6: 6 points if the day in the purchase date is odd
-func isOddDay(date string) bool {
-	// Parse the date (assume format "YYYY-MM-DD")
-	parsedDate, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return false
+// descriptionLength measures a description in bytes, runes or grapheme clusters.
+func descriptionLength(s string, mode LengthMode) int {
+	switch mode {
+	case LengthRunes:
+		return utf8.RuneCountInString(s)
+	case LengthGraphemes:
+		return uniseg.GraphemeClusterCount(s)
+	default:
+		return len(s)
 	}
-	// Check if the day of the month is odd
-	return parsedDate.Day()%2 != 0
 }
 
-// Rule 7: 10 points if the time of purchase is after 2:00pm and before 4:00pm
-func isBetweenTwoAndFourPM(timeStr string) bool {
-	// Parse the time (assume 24-hour format "HH:MM")
-	parsedTime, err := time.Parse("15:04", timeStr)
-	if err != nil {
-		return false
+// normalize composes the text to NFC if the options ask for it. Text that is already in NFC is returned as is.
+func (o TextOptions) normalize(s string) string {
+	if o.NormalizeNFC {
+		return norm.NFC.String(s)
 	}
-	// Check if the time is between 2:00 PM and 4:00 PM
-	return parsedTime.Hour() >= 14 && parsedTime.Hour() < 16
+	return s
+}
+
+// Rule 6: 6 points if the day in the purchase date is odd
+func isOddDay(purchasedAt time.Time) bool {
+	// Check if the day of the month is odd in the purchase's own location
+	return purchasedAt.Day()%2 != 0
+}
+
+// Rule 7: 10 points if the time of purchase is after 2:00pm and before 4:00pm
+func isBetweenTwoAndFourPM(purchasedAt time.Time) bool {
+	// Check if the local time is between 2:00 PM and 4:00 PM
+	return purchasedAt.Hour() >= 14 && purchasedAt.Hour() < 16
 }
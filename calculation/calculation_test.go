@@ -1,9 +1,11 @@
 package calculation
 
 import (
+	"encoding/json"
 	"fetch-app/server"
 	"github.com/oapi-codegen/runtime/types"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"testing"
 	"time"
 )
@@ -76,7 +78,8 @@ func TestIsRoundDollar(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.total, func(t *testing.T) {
-			result := isRoundDollar(test.total)
+			total, _ := parseAmount(test.total)
+			result := isRoundDollar(total)
 			assert.Equal(t, test.expected, result)
 		})
 	}
@@ -96,7 +99,8 @@ func TestIsMultipleOfQuarter(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.total, func(t *testing.T) {
-			result := isMultipleOfQuarter(test.total)
+			total, _ := parseAmount(test.total)
+			result := isMultipleOfQuarter(total)
 			assert.Equal(t, test.expected, result)
 		})
 	}
@@ -135,7 +139,8 @@ func TestIsOddDay(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.date, func(t *testing.T) {
-			result := isOddDay(test.date)
+			purchasedAt, _ := time.Parse("2006-01-02", test.date)
+			result := isOddDay(purchasedAt)
 			assert.Equal(t, test.expected, result)
 		})
 	}
@@ -155,8 +160,187 @@ func TestIsBetweenTwoAndFourPM(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.timeStr, func(t *testing.T) {
-			result := isBetweenTwoAndFourPM(test.timeStr)
+			purchasedAt, _ := time.Parse("15:04", test.timeStr)
+			result := isBetweenTwoAndFourPM(purchasedAt)
 			assert.Equal(t, test.expected, result)
 		})
 	}
 }
+
+// corpusEntry is a text sample with its expected counts under each text option.
+type corpusEntry struct {
+	Name                string `json:"name"`
+	Text                string `json:"text"`
+	ASCIIAlphanumeric   int    `json:"asciiAlphanumeric"`
+	UnicodeAlphanumeric int    `json:"unicodeAlphanumeric"`
+	Bytes               int    `json:"bytes"`
+	Runes               int    `json:"runes"`
+	Graphemes           int    `json:"graphemes"`
+}
+
+// Helper function to load the accented, CJK and emoji samples from testdata
+func loadUnicodeCorpus(t *testing.T) []corpusEntry {
+	data, err := os.ReadFile("testdata/unicode_corpus.json")
+	if err != nil {
+		t.Fatalf("Error reading corpus: %v", err)
+	}
+	var corpus []corpusEntry
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		t.Fatalf("Error parsing corpus: %v", err)
+	}
+	return corpus
+}
+
+// Test rules 1 and 5 against the Unicode corpus
+func TestUnicodeCorpus(t *testing.T) {
+	for _, entry := range loadUnicodeCorpus(t) {
+		t.Run(entry.Name, func(t *testing.T) {
+			assert.Equal(t, entry.ASCIIAlphanumeric, TextOptions{}.countAlphanumeric(entry.Text))
+			assert.Equal(t, entry.UnicodeAlphanumeric, TextOptions{Unicode: true}.countAlphanumeric(entry.Text))
+			assert.Equal(t, entry.Bytes, descriptionLength(entry.Text, LengthBytes))
+			assert.Equal(t, entry.Runes, descriptionLength(entry.Text, LengthRunes))
+			assert.Equal(t, entry.Graphemes, descriptionLength(entry.Text, LengthGraphemes))
+		})
+	}
+}
+
+// Test that NFC normalization makes composed and decomposed accents score the same
+func TestNormalizeNFC(t *testing.T) {
+	composed := "Caf\u00e9 Au Lait"
+	decomposed := "Cafe\u0301 Au Lait"
+
+	// Without normalization the decomposed form is one rune longer
+	options := TextOptions{Unicode: true, Length: LengthRunes}
+	assert.Equal(t, 1, options.pointsForItemDescription(server.Item{ShortDescription: composed, Price: "5.00"}, 0))
+	assert.Equal(t, 0, options.pointsForItemDescription(server.Item{ShortDescription: decomposed, Price: "5.00"}, 0))
+
+	options.NormalizeNFC = true
+	assert.Equal(t, 1, options.pointsForItemDescription(server.Item{ShortDescription: decomposed, Price: "5.00"}, 0))
+	assert.Equal(t, options.countAlphanumeric(composed), options.countAlphanumeric(decomposed))
+}
+
+// Test that the calculator applies its text options while the default keeps the original rules
+func TestCalculatorTextOptions(t *testing.T) {
+	receipt := createTestReceipt()
+	receipt.Retailer = "Café Ñandú"
+	receipt.Items = []server.Item{{ShortDescription: "東京ラーメン", Price: "10.00"}}
+	receipt.Total = "10.00"
+
+	// "Café Ñandú" has 6 ASCII letters and "東京ラーメン" is 18 bytes long
+	legacy := CalculatePoints(receipt)
+	assert.Equal(t, 6+50+25+2+10, legacy)
+
+	// With Unicode classes there are 9 letters, and the description is 6 runes long
+	unicodeAware := Calculator{Text: TextOptions{Unicode: true, Length: LengthRunes, NormalizeNFC: true}}
+	assert.Equal(t, 9+50+25+2+10, unicodeAware.CalculatePoints(receipt))
+}
+
+func TestParseLengthMode(t *testing.T) {
+	tests := map[string]LengthMode{"": LengthBytes, "bytes": LengthBytes, "runes": LengthRunes, "Graphemes": LengthGraphemes}
+	for input, expected := range tests {
+		mode, err := ParseLengthMode(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+
+	_, err := ParseLengthMode("words")
+	assert.Error(t, err)
+}
+
+// Helper function to create a receipt with the given number of items
+func createLargeReceipt(items int) server.Receipt {
+	receipt := createTestReceipt()
+	receipt.Items = make([]server.Item, items)
+	descriptions := []string{"Gatorade", "Coca-Cola", "Gum", "Emils Cheese Pizza", "Klarbrunn 12-PK 12 FL OZ"}
+	for i := range receipt.Items {
+		receipt.Items[i] = server.Item{ShortDescription: descriptions[i%len(descriptions)], Price: "12.25"}
+	}
+	return receipt
+}
+
+// Test parsing amounts into exact millionths
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected amount
+		valid    bool
+	}{
+		{"9.00", 9 * amountUnit, true},
+		{"100", 100 * amountUnit, true},
+		{"0.25", amountUnit / 4, true},
+		{"-1.5", -3 * amountUnit / 2, true},
+		{".5", amountUnit / 2, true},
+		{"1.2345670", 1_234_567, true},
+		{"1.2345678", 0, false},
+		{"", 0, false},
+		{".", 0, false},
+		{" 9.00", 0, false},
+		{"9,00", 0, false},
+		{"1e3", 0, false},
+		{"9999999999999.00", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, ok := parseAmount(test.input)
+			assert.Equal(t, test.valid, ok)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+// Test that rule 5 rounds up exactly, where floating point would turn 35.00 * 0.2 into 7.000000000000001
+func TestPointsForItemDescriptionExact(t *testing.T) {
+	assert.Equal(t, 7, pointsForItemDescription(server.Item{ShortDescription: "Gum", Price: "35.00"}))
+	assert.Equal(t, 8, pointsForItemDescription(server.Item{ShortDescription: "Gum", Price: "35.01"}))
+	assert.Equal(t, 0, pointsForItemDescription(server.Item{ShortDescription: "Gum", Price: "-2.00"}))
+}
+
+// Test for Rule 4: Item pairs count the units of items bought in a quantity
+func TestItemUnits(t *testing.T) {
+	three, one, unitPrice := 3, 1, "2.25"
+	items := []server.Item{
+		{ShortDescription: "Gatorade", Price: "6.75", Quantity: &three, UnitPrice: &unitPrice},
+		{ShortDescription: "Candy", Price: "3.00", Quantity: &one},
+		{ShortDescription: "Gum", Price: "1.00"},
+	}
+	assert.Equal(t, 5, itemUnits(items))
+
+	receipt := createTestReceipt()
+	receipt.Items = items
+	withoutQuantities := createTestReceipt()
+	withoutQuantities.Items = []server.Item{items[0], items[1], items[2]}
+	withoutQuantities.Items[0].Quantity = nil
+	// Five units make two pairs, where three lines make one
+	assert.Equal(t, CalculatePoints(withoutQuantities)+5, CalculatePoints(receipt))
+}
+
+// Test that scoring does not allocate, with and without a time zone
+func TestCalculatePointsAllocations(t *testing.T) {
+	receipt := createLargeReceipt(1000)
+	assert.Zero(t, testing.AllocsPerRun(100, func() { CalculatePoints(receipt) }))
+
+	zone := "America/New_York"
+	receipt.Timezone = &zone
+	unicodeAware := Calculator{Text: TextOptions{Unicode: true, Length: LengthGraphemes, NormalizeNFC: true}}
+	assert.Zero(t, testing.AllocsPerRun(100, func() { unicodeAware.CalculatePoints(receipt) }))
+}
+
+func BenchmarkCalculatePoints(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		receipt server.Receipt
+	}{
+		{"small", createTestReceipt()},
+		{"1000items", createLargeReceipt(1000)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				CalculatePoints(bm.receipt)
+			}
+		})
+	}
+}
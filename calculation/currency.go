@@ -0,0 +1,85 @@
+package calculation
+
+import (
+	"fetch-app/currency"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// TotalRules are the variants of rules 2 and 3 for one currency: the amounts a total is a multiple of to earn
+// their points.
+type TotalRules struct {
+	// Round is the amount of a round total, such as "1" for whole dollars or "100" for hundreds of yen.
+	Round string
+	// Quarter is the amount rule 3 looks for multiples of, such as "0.25" dollars or "25" yen.
+	Quarter string
+}
+
+// defaultTotalRules apply to the currencies with a minor unit that have no rules of their own, as in dollars.
+var defaultTotalRules = TotalRules{Round: "1", Quarter: "0.25"}
+
+// wholeTotalRules apply to the currencies without a minor unit that have no rules of their own, whose every total
+// would otherwise be round.
+var wholeTotalRules = TotalRules{Round: "100", Quarter: "25"}
+
+// ParseCurrencyRules parses a comma-separated list of "code=round:quarter" variants of the total rules, such as
+// "JPY=1000:250,KWD=1:0.250", validating every code and amount.
+func ParseCurrencyRules(s string) (map[string]TotalRules, error) {
+	rules := make(map[string]TotalRules)
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		code, amounts, found := strings.Cut(entry, "=")
+		round, quarter, ok := strings.Cut(amounts, ":")
+		if !found || !ok {
+			return nil, fmt.Errorf("invalid currency rules %q, expected code=round:quarter", entry)
+		}
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if _, known := currency.Digits(code); !known {
+			return nil, fmt.Errorf("unknown currency %q", code)
+		}
+		variant := TotalRules{Round: strings.TrimSpace(round), Quarter: strings.TrimSpace(quarter)}
+		for _, unit := range []string{variant.Round, variant.Quarter} {
+			if parsed, valid := parseAmount(unit); !valid || parsed <= 0 {
+				return nil, fmt.Errorf("invalid amount %q in the rules for %s", unit, code)
+			}
+		}
+		rules[code] = variant
+	}
+	return rules, nil
+}
+
+// totalRules returns the units of rules 2 and 3 for a currency: its own rules, or the defaults for currencies with
+// and without a minor unit.
+func totalRules(code string, rules map[string]TotalRules) (round, quarter amount) {
+	variant, ok := rules[code]
+	if !ok {
+		variant = defaultTotalRules
+		if digits, known := currency.Digits(code); known && digits == 0 {
+			variant = wholeTotalRules
+		}
+	}
+	round, _ = parseAmount(variant.Round)
+	quarter, _ = parseAmount(variant.Quarter)
+	return round, quarter
+}
+
+// toBase converts a positive amount into the base currency at an exchange rate, the units of the amount's currency
+// one base unit buys, truncating to millionths. It reports false if the rate is not positive or the result would
+// overflow.
+func toBase(a, rate amount) (amount, bool) {
+	if rate <= 0 || a < 0 {
+		return 0, false
+	}
+	hi, lo := bits.Mul64(uint64(a), uint64(amountUnit))
+	if hi >= uint64(rate) {
+		return 0, false
+	}
+	quotient, _ := bits.Div64(hi, lo, uint64(rate))
+	if quotient > 1<<62 {
+		return 0, false
+	}
+	return amount(quotient), true
+}
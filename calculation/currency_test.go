@@ -0,0 +1,99 @@
+package calculation
+
+import (
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// currencyReceipt creates a receipt with one item that earns no description points, in a currency.
+func currencyReceipt(code, total string) server.Receipt {
+	receipt := createTestReceipt()
+	receipt.Currency = &code
+	receipt.Total = total
+	receipt.Items = []server.Item{{ShortDescription: "Gatorade", Price: total}}
+	return receipt
+}
+
+// totalPoints returns the points of rules 2 and 3 for a receipt.
+func totalPoints(calculator Calculator, receipt server.Receipt) int {
+	points := 0
+	for _, rule := range calculator.Breakdown(receipt) {
+		if rule.Rule == "round_dollar_total" || rule.Rule == "quarter_total" {
+			points += rule.Points
+		}
+	}
+	return points
+}
+
+// Test that rules 2 and 3 look for round amounts in the receipt's currency
+func TestTotalRulesByCurrency(t *testing.T) {
+	calculator := Calculator{}
+	tests := []struct {
+		code, total string
+		expected    int
+	}{
+		{"USD", "9.00", 75},
+		{"USD", "9.25", 25},
+		{"EUR", "9.10", 0},
+		// Without a minor unit, every total would be round, so hundreds and 25s are looked for instead
+		{"JPY", "1200", 75},
+		{"JPY", "1225", 25},
+		{"JPY", "1201", 0},
+		{"BHD", "3.000", 75},
+		{"BHD", "3.250", 25},
+		{"BHD", "3.125", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.code+" "+test.total, func(t *testing.T) {
+			assert.Equal(t, test.expected, totalPoints(calculator, currencyReceipt(test.code, test.total)))
+		})
+	}
+
+	// Configured variants replace the defaults
+	rules, err := ParseCurrencyRules("jpy=1000:250, KWD=1:0.500")
+	assert.NoError(t, err)
+	calculator.Currencies = rules
+	assert.Equal(t, 0, totalPoints(calculator, currencyReceipt("JPY", "1200")))
+	assert.Equal(t, 75, totalPoints(calculator, currencyReceipt("JPY", "2000")))
+	assert.Equal(t, 25, totalPoints(calculator, currencyReceipt("KWD", "2.500")))
+	assert.Equal(t, 0, totalPoints(calculator, currencyReceipt("KWD", "2.250")))
+}
+
+func TestParseCurrencyRules(t *testing.T) {
+	rules, err := ParseCurrencyRules("")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, invalid := range []string{"JPY=100", "XYZ=1:0.25", "JPY=0:25", "JPY=100:quarter"} {
+		_, err := ParseCurrencyRules(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// Test that rule 5 scores item prices in the base currency when the receipt has an exchange rate
+func TestPointsForItemDescriptionConverted(t *testing.T) {
+	receipt := currencyReceipt("JPY", "1500")
+	receipt.Items = []server.Item{{ShortDescription: "Gum", Price: "1500"}}
+	assert.Equal(t, 300, Calculator{}.Breakdown(receipt)[4].Points)
+
+	// 1500 yen at 150 yen to the dollar is 10 dollars, worth 2 points
+	rate := "150"
+	receipt.ExchangeRate = &rate
+	assert.Equal(t, 2, Calculator{}.Breakdown(receipt)[4].Points)
+
+	// Converted prices are rounded up like dollar prices: 1501 yen is 10.006667 dollars
+	receipt.Items[0].Price = "1501"
+	assert.Equal(t, 3, Calculator{}.Breakdown(receipt)[4].Points)
+}
+
+func TestToBase(t *testing.T) {
+	converted, ok := toBase(9_200_000, 920_000)
+	assert.True(t, ok)
+	assert.Equal(t, 10*amountUnit, converted)
+
+	_, ok = toBase(999_999_999_999*amountUnit, 1)
+	assert.False(t, ok)
+	_, ok = toBase(amountUnit, 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,124 @@
+package calculation
+
+import (
+	"fetch-app/currency"
+	"fetch-app/server"
+	"time"
+)
+
+// amount is a monetary amount in millionths of the currency unit, so the rules can use exact integer arithmetic.
+type amount int64
+
+// amountUnit is one whole currency unit, such as one dollar.
+const amountUnit amount = 1_000_000
+
+// maxAmountDigits bounds the whole part of an amount so it cannot overflow.
+const maxAmountDigits = 12
+
+// parsedReceipt holds the receipt-level values the rules need, each parsed exactly once.
+// Parsing does not allocate, so the scoring path stays allocation-free.
+type parsedReceipt struct {
+	total      amount
+	validTotal bool
+	// round and quarter are the units of rules 2 and 3 in the receipt's currency.
+	round, quarter amount
+	// rate is the exchange rate item prices are converted into the base currency at, or zero to score them as they
+	// are.
+	rate amount
+	// purchasedAt is the purchase time in the store's location, or the printed date if the time is invalid.
+	purchasedAt time.Time
+	validTime   bool
+}
+
+// parseReceipt parses the total, the currency rules and the purchase time of a receipt.
+func parseReceipt(receipt *server.Receipt, currencies map[string]TotalRules) parsedReceipt {
+	var parsed parsedReceipt
+	parsed.total, parsed.validTotal = parseAmount(receipt.Total)
+	code := currency.Default
+	if receipt.Currency != nil {
+		code = *receipt.Currency
+	}
+	parsed.round, parsed.quarter = totalRules(code, currencies)
+	if receipt.ExchangeRate != nil {
+		if rate, ok := parseAmount(*receipt.ExchangeRate); ok && rate > 0 {
+			parsed.rate = rate
+		}
+	}
+
+	// Rules 6 and 7 are evaluated in the store's local time
+	if purchasedAt, ok := purchaseTimestamp(receipt); ok {
+		parsed.purchasedAt, parsed.validTime = purchasedAt, true
+	} else {
+		// Without a valid purchase time or time zone only the printed date can be scored
+		parsed.purchasedAt = receipt.PurchaseDate.Time
+	}
+	return parsed
+}
+
+// AmountUnit is one whole currency unit in the millionths ParseAmount returns.
+const AmountUnit = int64(amountUnit)
+
+// ParseAmount parses a decimal amount such as "6.49" into exact millionths of the currency unit, the way the rules
+// read amounts, so other checks can do the same integer arithmetic. See parseAmount for the amounts it refuses.
+func ParseAmount(s string) (int64, bool) {
+	a, ok := parseAmount(s)
+	return int64(a), ok
+}
+
+// parseAmount parses a decimal amount such as "9.00", "-1.5" or "100".
+// Amounts with more than six significant decimal places, exponents or surrounding spaces are invalid.
+func parseAmount(s string) (amount, bool) {
+	i := 0
+	negative := false
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		negative = s[i] == '-'
+		i++
+	}
+
+	// Whole part
+	var whole amount
+	digits := 0
+	for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		whole = whole*10 + amount(s[i]-'0')
+		digits++
+	}
+	if digits > maxAmountDigits {
+		return 0, false
+	}
+
+	// Fractional part, padded to millionths
+	var fraction amount
+	scale := amountUnit
+	if i < len(s) && s[i] == '.' {
+		i++
+		for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+			digits++
+			if scale == 1 {
+				// Digits beyond millionths are only allowed if they are zeros
+				if s[i] != '0' {
+					return 0, false
+				}
+				continue
+			}
+			scale /= 10
+			fraction += amount(s[i]-'0') * scale
+		}
+	}
+	if i != len(s) || digits == 0 {
+		return 0, false
+	}
+
+	value := whole*amountUnit + fraction
+	if negative {
+		value = -value
+	}
+	return value, true
+}
+
+// ceilDiv divides a by a positive b, rounding towards positive infinity.
+func ceilDiv(a, b amount) amount {
+	if a > 0 {
+		return (a + b - 1) / b
+	}
+	return a / b
+}
@@ -0,0 +1,186 @@
+package calculation
+
+import (
+	"fetch-app/server"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// offsetPattern matches UTC offsets such as "+05:30", "-0800" and "+02". A colon must be followed by the minutes.
+var offsetPattern = regexp.MustCompile(`^([+-])(\d{2})(?::?(\d{2}))?$`)
+
+// ParseLocation converts an IANA time zone name or a UTC offset into a location.
+// Offsets produce a fixed zone without daylight saving time, names follow the zone's DST rules.
+func ParseLocation(s string) (*time.Location, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "Z" {
+		return time.UTC, nil
+	}
+
+	if m := offsetPattern.FindStringSubmatch(s); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3]) // Zero when the minutes are omitted
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset %q", s)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(s, offset), nil
+	}
+
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", s, err)
+	}
+	return loc, nil
+}
+
+// PurchaseTimestamp combines the printed purchase date and time with the receipt's time zone.
+// The printed values are local wall-clock time at the store. A wall-clock time skipped by a daylight saving
+// transition is moved forward by the length of the gap, so "02:30" on a spring-forward day becomes 03:30.
+//
+// Returns:
+//
+//	The purchase instant in the receipt's location, or an error if the time or time zone cannot be parsed.
+func PurchaseTimestamp(receipt server.Receipt) (time.Time, error) {
+	if receipt.Timezone != nil {
+		if _, err := loadLocation(*receipt.Timezone); err != nil {
+			return time.Time{}, err
+		}
+	}
+	purchasedAt, ok := purchaseTimestamp(&receipt)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid purchase time %q, expected 24-hour HH:MM", receipt.PurchaseTime)
+	}
+	return purchasedAt, nil
+}
+
+// purchaseTimestamp is PurchaseTimestamp for the scoring path, which only needs to know whether it succeeded.
+// It does not allocate once the receipt's time zone has been cached.
+func purchaseTimestamp(receipt *server.Receipt) (time.Time, bool) {
+	loc := time.UTC
+	if receipt.Timezone != nil {
+		var err error
+		if loc, err = loadLocation(*receipt.Timezone); err != nil {
+			return time.Time{}, false
+		}
+	}
+
+	hour, minute, ok := parseClock(receipt.PurchaseTime)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	year, month, day := receipt.PurchaseDate.Date()
+	purchasedAt := time.Date(year, month, day, hour, minute, 0, 0, loc)
+	if purchasedAt.Hour() != hour || purchasedAt.Minute() != minute {
+		// The wall-clock time falls in a daylight saving gap, where time.Date does not guarantee which offset it
+		// picks. Interpret it with the offset in effect before the transition to move it forward past the gap.
+		_, before := purchasedAt.Add(-24 * time.Hour).Zone()
+		wall := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+		purchasedAt = wall.Add(-time.Duration(before) * time.Second).In(loc)
+	}
+	return purchasedAt, true
+}
+
+// parseClock parses a 24-hour "HH:MM" time, also accepting a single-digit hour like time.Parse("15:04") does,
+// without allocating an error on failure.
+func parseClock(s string) (int, int, bool) {
+	colon := strings.IndexByte(s, ':')
+	if colon < 1 || colon > 2 || len(s) != colon+3 {
+		return 0, 0, false
+	}
+	hour, ok := parseDigits(s[:colon])
+	if !ok || hour > 23 {
+		return 0, 0, false
+	}
+	minute, ok := parseDigits(s[colon+1:])
+	if !ok || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// parseDigits parses a short run of ASCII digits.
+func parseDigits(s string) (int, bool) {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+		n = n*10 + int(s[i]-'0')
+	}
+	return n, true
+}
+
+// locations caches parsed time zones by name, so scoring receipts with a time zone does not allocate.
+// Only valid names are cached, which bounds its size by the number of real zones and offsets.
+var locations = struct {
+	sync.RWMutex
+	byName map[string]*time.Location
+}{byName: make(map[string]*time.Location)}
+
+// loadLocation is ParseLocation backed by the location cache.
+func loadLocation(s string) (*time.Location, error) {
+	locations.RLock()
+	loc, ok := locations.byName[s]
+	locations.RUnlock()
+	if ok {
+		return loc, nil
+	}
+
+	loc, err := ParseLocation(s)
+	if err != nil {
+		return nil, err
+	}
+	locations.Lock()
+	locations.byName[s] = loc
+	locations.Unlock()
+	return loc, nil
+}
+
+// TimezoneDefaults supplies a time zone for receipts that do not carry one.
+type TimezoneDefaults struct {
+	// Retailers maps lowercased retailer names to the time zone of their stores.
+	Retailers map[string]string
+	// Default applies to every other retailer. Empty means UTC.
+	Default string
+}
+
+// ParseRetailerTimezones parses a comma-separated list of "retailer=zone" pairs, such as
+// "Target=America/Chicago,Walmart=America/New_York", validating every zone.
+func ParseRetailerTimezones(s string) (map[string]string, error) {
+	retailers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		retailer, zone, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid retailer time zone %q, expected retailer=zone", pair)
+		}
+		if _, err := ParseLocation(zone); err != nil {
+			return nil, err
+		}
+		retailers[strings.ToLower(strings.TrimSpace(retailer))] = strings.TrimSpace(zone)
+	}
+	return retailers, nil
+}
+
+// Resolve returns the time zone a receipt should be evaluated in: its own, its retailer's or the default.
+// Empty means UTC.
+func (d TimezoneDefaults) Resolve(receipt server.Receipt) string {
+	if receipt.Timezone != nil && strings.TrimSpace(*receipt.Timezone) != "" {
+		return *receipt.Timezone
+	}
+	if zone, ok := d.Retailers[strings.ToLower(strings.TrimSpace(receipt.Retailer))]; ok {
+		return zone
+	}
+	return d.Default
+}
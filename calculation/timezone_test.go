@@ -0,0 +1,125 @@
+package calculation
+
+import (
+	"fetch-app/server"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Helper function to create a receipt purchased at the given local date and time
+func createZonedReceipt(date time.Time, clock string, zone string) server.Receipt {
+	receipt := server.Receipt{
+		Retailer:     "Target",
+		PurchaseDate: types.Date{Time: date},
+		PurchaseTime: clock,
+		Total:        "1.01",
+	}
+	if zone != "" {
+		receipt.Timezone = &zone
+	}
+	return receipt
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOffset int
+	}{
+		{"", 0},
+		{"Z", 0},
+		{"+05:30", 5*3600 + 30*60},
+		{"-0800", -8 * 3600},
+		{"+02", 2 * 3600},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			loc, err := ParseLocation(test.input)
+			assert.NoError(t, err)
+			_, offset := time.Date(2022, time.January, 1, 0, 0, 0, 0, loc).Zone()
+			assert.Equal(t, test.expectedOffset, offset)
+		})
+	}
+
+	loc, err := ParseLocation("America/New_York")
+	assert.NoError(t, err)
+	assert.Equal(t, "America/New_York", loc.String())
+
+	for _, invalid := range []string{"Mars/Olympus_Mons", "+25:00", "+05:75", "+05:", "+05:3"} {
+		_, err := ParseLocation(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPurchaseTimestamp(t *testing.T) {
+	date := time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	// Without a time zone the printed time is UTC
+	purchasedAt, err := PurchaseTimestamp(createZonedReceipt(date, "14:33", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, time.March, 20, 14, 33, 0, 0, time.UTC), purchasedAt)
+
+	// With a time zone the printed time is local to the store
+	purchasedAt, err = PurchaseTimestamp(createZonedReceipt(date, "14:33", "America/New_York"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-03-20T18:33:00Z", purchasedAt.UTC().Format(time.RFC3339))
+
+	_, err = PurchaseTimestamp(createZonedReceipt(date, "2:33 PM", ""))
+	assert.Error(t, err)
+	_, err = PurchaseTimestamp(createZonedReceipt(date, "14:33", "Nowhere"))
+	assert.Error(t, err)
+}
+
+func TestPurchaseTimestampDST(t *testing.T) {
+	// Clocks in New York jumped from 02:00 to 03:00 on 2022-03-13, so 02:30 was never shown
+	springForward := time.Date(2022, time.March, 13, 0, 0, 0, 0, time.UTC)
+	purchasedAt, err := PurchaseTimestamp(createZonedReceipt(springForward, "02:30", "America/New_York"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purchasedAt.Hour())
+	assert.Equal(t, "2022-03-13T07:30:00Z", purchasedAt.UTC().Format(time.RFC3339))
+
+	// The same wall-clock afternoon maps to different UTC instants either side of the transition
+	before, _ := PurchaseTimestamp(createZonedReceipt(springForward.AddDate(0, 0, -1), "14:30", "America/New_York"))
+	after, _ := PurchaseTimestamp(createZonedReceipt(springForward, "14:30", "America/New_York"))
+	assert.Equal(t, 19, before.UTC().Hour())
+	assert.Equal(t, 18, after.UTC().Hour())
+	assert.True(t, isBetweenTwoAndFourPM(before))
+	assert.True(t, isBetweenTwoAndFourPM(after))
+}
+
+func TestCalculatePointsUsesLocalTime(t *testing.T) {
+	date := time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	// 14:30 printed in any zone is within the 2:00pm to 4:00pm window in that zone
+	utc := CalculatePoints(createZonedReceipt(date, "14:30", ""))
+	tokyo := CalculatePoints(createZonedReceipt(date, "14:30", "Asia/Tokyo"))
+	assert.Equal(t, utc, tokyo)
+
+	// An unparseable time still scores the odd day but not the time window
+	odd := time.Date(2022, time.March, 21, 0, 0, 0, 0, time.UTC)
+	withTime := CalculatePoints(createZonedReceipt(odd, "14:30", ""))
+	withoutTime := CalculatePoints(createZonedReceipt(odd, "half past two", ""))
+	assert.Equal(t, withTime-10, withoutTime)
+}
+
+func TestTimezoneDefaults(t *testing.T) {
+	retailers, err := ParseRetailerTimezones("Target=America/Chicago, Walmart = -05:00")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"target": "America/Chicago", "walmart": "-05:00"}, retailers)
+
+	defaults := TimezoneDefaults{Retailers: retailers, Default: "Europe/London"}
+	date := time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "Asia/Tokyo", defaults.Resolve(createZonedReceipt(date, "14:30", "Asia/Tokyo")))
+	assert.Equal(t, "America/Chicago", defaults.Resolve(createZonedReceipt(date, "14:30", "")))
+	other := createZonedReceipt(date, "14:30", "")
+	other.Retailer = "Corner Shop"
+	assert.Equal(t, "Europe/London", defaults.Resolve(other))
+
+	_, err = ParseRetailerTimezones("Target")
+	assert.Error(t, err)
+	_, err = ParseRetailerTimezones("Target=Nowhere")
+	assert.Error(t, err)
+}
@@ -0,0 +1,482 @@
+// Package client is a typed Go client for the receipt API. It retries requests that fail with 429 or 5xx
+// responses or network errors, backing off exponentially, and sends an Idempotency-Key with every submission so
+// a retried receipt is only stored once.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how many times a failed request is retried unless WithRetries says otherwise.
+	DefaultMaxRetries = 3
+	// DefaultBackoff is the delay before the first retry unless WithRetries says otherwise. It doubles on each retry.
+	DefaultBackoff = 100 * time.Millisecond
+	// MaxBackoff caps the delay between retries, including one requested by a Retry-After header.
+	MaxBackoff = 10 * time.Second
+)
+
+// Client calls the receipt API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	adminToken string
+	principal  string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests with the given HTTP client instead of http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a failed request is retried and the delay before the first retry.
+// Zero retries disables retrying.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithAdminToken sends the admin token configured on the server with the requests to the admin routes, such as
+// Export.
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// WithPrincipal sends the principal as the X-Principal-Id of every request, so the receipts submitted are recorded as
+// theirs. A principal passed to a method, such as Import, takes precedence.
+func WithPrincipal(principal string) Option {
+	return func(c *Client) {
+		c.principal = principal
+	}
+}
+
+// New creates a Client for the API served at baseURL.
+//
+// Parameters:
+//
+//	baseURL - The URL the API is served at, such as "http://localhost:8080".
+//	opts    - Options overriding the defaults.
+//
+// Returns:
+//
+//	The Client, or an error if baseURL is not an absolute URL.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("base URL %q is not absolute", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Points is the outcome of asking for a receipt's points.
+type Points struct {
+	// Status is the review status of the receipt. Points is only set once it is approved.
+	Status server.Status
+	// Points is the number of points awarded.
+	Points int64
+}
+
+// Breakdown is the outcome of asking for a receipt's points rule by rule.
+type Breakdown struct {
+	// Status is the review status of the receipt. Points and Rules are only set once it is approved.
+	Status server.Status
+	// Points is the number of points awarded, which is the sum of the rules' points.
+	Points int64
+	// Rules lists the points each scoring rule awarded, in the order they are applied.
+	Rules []server.RulePoints
+}
+
+// Process submits a receipt under a new Idempotency-Key, on behalf of the client's principal if it has one. The key
+// is reused if the request is retried, so the receipt is stored once however many attempts it takes.
+//
+// Returns:
+//
+//	The ID assigned to the receipt, or an error. A failure reported by the API is a *problem.Problem.
+func (c *Client) Process(ctx context.Context, receipt server.Receipt) (string, error) {
+	return c.ProcessWithKey(ctx, uuid.New().String(), receipt)
+}
+
+// ProcessWithKey submits a receipt under the given Idempotency-Key. Callers that persist the key can resubmit
+// after a crash and get the original ID back instead of storing the receipt twice.
+//
+// Returns:
+//
+//	The ID assigned to the receipt, or an error. A failure reported by the API is a *problem.Problem.
+func (c *Client) ProcessWithKey(ctx context.Context, key string, receipt server.Receipt) (string, error) {
+	body, err := json.Marshal(receipt)
+	if err != nil {
+		return "", fmt.Errorf("encoding receipt: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Idempotency-Key", key)
+
+	var processed server.ProcessedReceipt
+	if _, err := c.do(ctx, http.MethodPost, "/receipts/process", header, body, &processed); err != nil {
+		return "", err
+	}
+	return processed.Id, nil
+}
+
+// Receipt returns the stored record for a receipt.
+//
+// Returns:
+//
+//	The receipt record, or an error. A failure reported by the API is a *problem.Problem.
+func (c *Client) Receipt(ctx context.Context, id string) (server.ReceiptRecord, error) {
+	var record server.ReceiptRecord
+	_, err := c.do(ctx, http.MethodGet, "/receipts/"+url.PathEscape(id), nil, nil, &record)
+	return record, err
+}
+
+// Receipts lists a page of the receipt records stored by the principal in ID order, starting after the given ID. An
+// empty after starts from the first record, and a limit of zero uses the server's default page size.
+//
+// Returns:
+//
+//	The page, whose Next is the after of the following page, or an error. A failure reported by the API is a
+//	*problem.Problem.
+func (c *Client) Receipts(ctx context.Context, principal, after string, limit int) (server.ReceiptPage, error) {
+	query := url.Values{}
+	if after != "" {
+		query.Set("after", after)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/receipts"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	header := http.Header{"X-Principal-Id": []string{principal}}
+	var page server.ReceiptPage
+	_, err := c.do(ctx, http.MethodGet, path, header, nil, &page)
+	return page, err
+}
+
+// Points returns the points awarded for a receipt, or only its status while it is pending review.
+//
+// Returns:
+//
+//	The points, or an error. A failure reported by the API, such as a rejected receipt, is a *problem.Problem.
+func (c *Client) Points(ctx context.Context, id string) (Points, error) {
+	var response struct {
+		server.Points
+		server.ReviewStatus
+	}
+	status, err := c.do(ctx, http.MethodGet, "/receipts/"+url.PathEscape(id)+"/points", nil, nil, &response)
+	if err != nil {
+		return Points{}, err
+	}
+	if status == http.StatusAccepted {
+		return Points{Status: response.Status}, nil
+	}
+	return Points{Status: server.Approved, Points: response.Points.Points}, nil
+}
+
+// Breakdown returns the points each scoring rule awarded a receipt, or only its status while it is pending review.
+//
+// Returns:
+//
+//	The breakdown, or an error. A failure reported by the API, such as a rejected receipt, is a *problem.Problem.
+func (c *Client) Breakdown(ctx context.Context, id string) (Breakdown, error) {
+	var response struct {
+		server.PointsBreakdown
+		server.ReviewStatus
+	}
+	status, err := c.do(ctx, http.MethodGet, "/receipts/"+url.PathEscape(id)+"/breakdown", nil, nil, &response)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	if status == http.StatusAccepted {
+		return Breakdown{Status: response.Status}, nil
+	}
+	return Breakdown{Status: server.Approved, Points: response.PointsBreakdown.Points, Rules: response.Rules}, nil
+}
+
+// ExportOptions filters and formats an export. The zero value exports every receipt as CSV with a row per receipt.
+type ExportOptions struct {
+	// Format is CSV or NDJSON. Empty uses CSV.
+	Format server.ExportFormat
+	// Rows is a row per receipt or per item, for CSV. Empty uses a row per receipt.
+	Rows server.ExportRows
+	// From and To limit the export to receipts purchased in the range, inclusive. A zero time leaves it open.
+	From, To time.Time
+	// Principal limits the export to the receipts submitted by the principal. Empty exports everyone's.
+	Principal string
+}
+
+// Export streams the stored receipts with their points to w as the server reads them. It is an admin route, so the
+// client needs WithAdminToken. A request that fails before the export starts is retried, but one that fails while it
+// is written is not, since part of it was written.
+//
+// Returns:
+//
+//	An error if the export failed, in which case what was written to w is incomplete. A failure reported by the
+//	API is a *problem.Problem.
+func (c *Client) Export(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	query := url.Values{}
+	if opts.Format != "" {
+		query.Set("format", string(opts.Format))
+	}
+	if opts.Rows != "" {
+		query.Set("rows", string(opts.Rows))
+	}
+	if !opts.From.IsZero() {
+		query.Set("from", opts.From.Format(time.DateOnly))
+	}
+	if !opts.To.IsZero() {
+		query.Set("to", opts.To.Format(time.DateOnly))
+	}
+	if opts.Principal != "" {
+		query.Set("principal", opts.Principal)
+	}
+	path := "/admin/receipts/export"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	header := http.Header{"Accept": []string{"text/csv, application/x-ndjson, " + problem.ContentType}}
+	if c.adminToken != "" {
+		header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+	_, err := c.do(ctx, http.MethodGet, path, header, nil, w)
+	return err
+}
+
+// Import uploads a CSV file of receipts, where each row is an item and the rows of a receipt share a receipt key.
+// It is not retried, since a retry after a lost response would import the receipts again.
+//
+// Parameters:
+//
+//	file      - The CSV file, with a header row.
+//	mapping   - Maps fields to the headers of their columns as field=header pairs, such as "total=Amount", or empty
+//	            for the default headers.
+//	principal - Who the receipts are submitted by, or empty.
+//
+// Returns:
+//
+//	The report of the receipts imported and the rows left out, or an error. A failure reported by the API, such
+//	as a file that is not CSV, is a *problem.Problem.
+func (c *Client) Import(ctx context.Context, file io.Reader, mapping, principal string) (server.ImportReport, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "receipts.csv")
+	if err != nil {
+		return server.ImportReport{}, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return server.ImportReport{}, fmt.Errorf("reading file: %w", err)
+	}
+	if mapping != "" {
+		if err := form.WriteField("mapping", mapping); err != nil {
+			return server.ImportReport{}, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return server.ImportReport{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/receipts/import", &body)
+	if err != nil {
+		return server.ImportReport{}, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Accept", "application/json, "+problem.ContentType)
+	if principal != "" {
+		req.Header.Set("X-Principal-Id", principal)
+	}
+	var report server.ImportReport
+	_, _, err = c.send(req, &report)
+	return report, err
+}
+
+// ProcessEmail submits an email message with a receipt, such as an e-receipt a customer forwarded. It is not
+// retried, since messages carry no Idempotency-Key and a retry after a lost response would store the receipt again.
+//
+// Parameters:
+//
+//	message   - The RFC 822 message, as stored in a .eml file or Maildir.
+//	principal - Who the receipt is submitted by, or empty.
+//
+// Returns:
+//
+//	The ID assigned to the receipt with the receipt as read, or an error. A failure reported by the API, such as
+//	a message without a receipt, is a *problem.Problem.
+func (c *Client) ProcessEmail(ctx context.Context, message io.Reader, principal string) (server.EmailReceipt, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/receipts/process/email", message)
+	if err != nil {
+		return server.EmailReceipt{}, err
+	}
+	req.Header.Set("Content-Type", "message/rfc822")
+	req.Header.Set("Accept", "application/json, "+problem.ContentType)
+	if principal != "" {
+		req.Header.Set("X-Principal-Id", principal)
+	}
+	var processed server.EmailReceipt
+	_, _, err = c.send(req, &processed)
+	return processed, err
+}
+
+// do sends a request to the path, which must already be escaped, retrying it while it fails in a way that may be
+// temporary, and decodes the successful response into out. An out that is an io.Writer receives the response body
+// as it arrives instead.
+//
+// Returns:
+//
+//	The status code of the successful response, or an error. A problem response is returned as a
+//	*problem.Problem, and a cancelled context ends the retries with its error.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, body []byte, out interface{}) (int, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/json, "+problem.ContentType)
+		}
+
+		status, retryAfter, err := c.send(req, out)
+		if err == nil {
+			return status, nil
+		}
+		if attempt >= c.maxRetries || !retryable(status, err) {
+			return status, err
+		}
+
+		timer := time.NewTimer(c.delay(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return status, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes one attempt at a request and decodes its response.
+//
+// Returns:
+//
+//	The status code, or zero if no response arrived.
+//	The delay the server asked for in a Retry-After header, or zero.
+//	An error if the request failed or the response is not a success.
+func (c *Client) send(req *http.Request, out interface{}) (int, time.Duration, error) {
+	if c.principal != "" && req.Header.Get("X-Principal-Id") == "" {
+		req.Header.Set("X-Principal-Id", c.principal)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer res.Body.Close()
+
+	// A streamed body is not retried once it started, since part of it was already written
+	if w, ok := out.(io.Writer); ok && res.StatusCode >= 200 && res.StatusCode < 300 {
+		if _, err := io.Copy(w, res.Body); err != nil {
+			return res.StatusCode, 0, fmt.Errorf("reading response: %w", err)
+		}
+		return res.StatusCode, 0, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, 0, fmt.Errorf("reading response: %w", err)
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return res.StatusCode, 0, fmt.Errorf("decoding response: %w", err)
+		}
+		return res.StatusCode, 0, nil
+	}
+
+	retryAfter := parseRetryAfter(res.Header.Get("Retry-After"))
+	p := &problem.Problem{}
+	if strings.HasPrefix(res.Header.Get("Content-Type"), problem.ContentType) && json.Unmarshal(body, p) == nil {
+		return res.StatusCode, retryAfter, p
+	}
+	return res.StatusCode, retryAfter, fmt.Errorf("unexpected response: %s", res.Status)
+}
+
+// retryable reports whether a failed attempt may succeed if repeated: the server was overloaded or failed, or the
+// request never got a response. Context errors are final.
+func retryable(status int, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// delay returns how long to wait before the retry following the given attempt. It honors the server's
+// Retry-After, and otherwise doubles the backoff on each attempt with full jitter so clients spread out.
+func (c *Client) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, MaxBackoff)
+	}
+	backoff := MaxBackoff
+	if attempt < 32 {
+		backoff = min(c.backoff<<attempt, MaxBackoff)
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff + 1)
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of seconds or an HTTP date.
+// It returns zero if the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
@@ -0,0 +1,349 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fetch-app/fraud"
+	"fetch-app/ids"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/server"
+	"github.com/google/uuid"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testReceipt returns a receipt earning 20 points, which is not held for review.
+func testReceipt() server.Receipt {
+	return server.Receipt{
+		Retailer:     "Target",
+		PurchaseDate: types.Date{Time: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "13:01",
+		Items: []server.Item{
+			{ShortDescription: "Mountain Dew 12PK", Price: "6.49"},
+			{ShortDescription: "Emils Cheese Pizza", Price: "12.25"},
+		},
+		Total: "18.74",
+	}
+}
+
+// writeProblem renders the errors of the real handlers the way the server does.
+func writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		p = problem.New(http.StatusInternalServerError, problem.CodeInternal, "")
+	}
+	p.Write(w)
+}
+
+// flakyHandler fails the first requests with the given status before passing them on, and records the
+// Idempotency-Key of every request it receives.
+type flakyHandler struct {
+	next     http.Handler
+	status   int
+	failures int
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.keys = append(h.keys, r.Header.Get("Idempotency-Key"))
+	fail := h.failures > 0
+	h.failures--
+	h.mu.Unlock()
+
+	if fail {
+		if h.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		problem.New(h.status, problem.CodeInternal, "").Write(w)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// newTestClient serves the service with the real handlers and returns a client for it that retries quickly.
+func newTestClient(t *testing.T, service *receipts.Service, handler func(http.Handler) http.Handler, opts ...Option) *Client {
+	strict := server.NewStrictHandlerWithOptions(service, nil, server.StrictHTTPServerOptions{
+		RequestErrorHandlerFunc:  writeProblem,
+		ResponseErrorHandlerFunc: writeProblem,
+	})
+	mux := server.HandlerWithOptions(strict, server.StdHTTPServerOptions{ErrorHandlerFunc: writeProblem})
+	if handler != nil {
+		mux = handler(mux)
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	c, err := New(ts.URL, append([]Option{WithHTTPClient(ts.Client()), WithRetries(3, time.Millisecond)}, opts...)...)
+	if err != nil {
+		t.Fatalf("Error creating client: %v", err)
+	}
+	return c
+}
+
+// TestProcessRetries tests that a submission is retried on 429 and 5xx with the same Idempotency-Key, and that
+// the receipt is only stored once.
+func TestProcessRetries(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			service := receipts.NewService(receipts.NewStorage())
+			service.DuplicatePolicy = fraud.PolicyReject
+			flaky := &flakyHandler{status: status, failures: 2}
+			c := newTestClient(t, service, func(next http.Handler) http.Handler {
+				flaky.next = next
+				return flaky
+			})
+
+			id, err := c.Process(context.Background(), testReceipt())
+			assert.NoError(t, err)
+			assert.NotEmpty(t, id)
+			assert.Len(t, flaky.keys, 3)
+			assert.NotEmpty(t, flaky.keys[0])
+			assert.Equal(t, flaky.keys[0], flaky.keys[1])
+			assert.Equal(t, flaky.keys[0], flaky.keys[2])
+			assert.Len(t, service.Storage.Receipts, 1)
+		})
+	}
+}
+
+// TestProcessWithKey tests that resubmitting under the same key returns the original ID even when the duplicate
+// policy would reject the receipt.
+func TestProcessWithKey(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+	c := newTestClient(t, service, nil)
+
+	first, err := c.ProcessWithKey(ctx, "order-42", testReceipt())
+	assert.NoError(t, err)
+	second, err := c.ProcessWithKey(ctx, "order-42", testReceipt())
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	_, err = c.Process(ctx, testReceipt())
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) {
+		assert.Equal(t, problem.CodeDuplicateReceipt, p.Code)
+		assert.Equal(t, []string{first}, p.DuplicateOf)
+	}
+}
+
+// TestPrincipal tests that the client's principal is sent with every request, and that the receipts are recorded as
+// theirs.
+func TestPrincipal(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	var principals []string
+	c := newTestClient(t, service, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principals = append(principals, r.Header.Get("X-Principal-Id"))
+			next.ServeHTTP(w, r)
+		})
+	}, WithPrincipal("user-1"))
+
+	id, err := c.Process(ctx, testReceipt())
+	assert.NoError(t, err)
+	_, err = c.Points(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-1"}, principals)
+	record, _ := service.Storage.Get(id)
+	assert.Equal(t, "user-1", record.Principal)
+
+	// A principal passed to a method takes precedence
+	_, err = c.Receipts(ctx, "user-2", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", principals[2])
+}
+
+// TestGiveUp tests that retries stop after the configured number and return the last problem.
+func TestGiveUp(t *testing.T) {
+	flaky := &flakyHandler{status: http.StatusBadGateway, failures: 10}
+	c := newTestClient(t, receipts.NewService(receipts.NewStorage()), func(next http.Handler) http.Handler {
+		flaky.next = next
+		return flaky
+	})
+
+	_, err := c.Points(context.Background(), "missing")
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) {
+		assert.Equal(t, http.StatusBadGateway, p.Status)
+	}
+	assert.Len(t, flaky.keys, 4)
+}
+
+// TestContextCancelled tests that a cancelled context stops the retries.
+func TestContextCancelled(t *testing.T) {
+	flaky := &flakyHandler{status: http.StatusServiceUnavailable, failures: 10}
+	ts := httptest.NewServer(flaky)
+	t.Cleanup(ts.Close)
+	c, err := New(ts.URL, WithRetries(10, time.Hour))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.Points(ctx, "missing")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, flaky.keys, 1)
+}
+
+// TestPointsAndBreakdown tests the helpers through the review workflow.
+func TestPointsAndBreakdown(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	c := newTestClient(t, service, nil)
+
+	id, err := c.Process(ctx, testReceipt())
+	assert.NoError(t, err)
+	points, err := c.Points(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, Points{Status: server.Approved, Points: 20}, points)
+
+	breakdown, err := c.Breakdown(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), breakdown.Points)
+	assert.Len(t, breakdown.Rules, 7)
+	assert.Equal(t, "retailer_name", breakdown.Rules[0].Rule)
+
+	record, err := c.Receipt(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Target", record.Receipt.Retailer)
+
+	// The resubmission is flagged for review, so only its status is visible
+	duplicateID, err := c.Process(ctx, testReceipt())
+	assert.NoError(t, err)
+	points, err = c.Points(ctx, duplicateID)
+	assert.NoError(t, err)
+	assert.Equal(t, Points{Status: server.PendingReview}, points)
+	breakdown, err = c.Breakdown(ctx, duplicateID)
+	assert.NoError(t, err)
+	assert.Equal(t, Breakdown{Status: server.PendingReview}, breakdown)
+}
+
+// TestReceipts tests paging through the receipts stored by a principal.
+func TestReceipts(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	service.IDs = ids.StrategyUUIDv7
+	c := newTestClient(t, service, nil)
+	file := "receipt,retailer,purchaseDate,purchaseTime,total,shortDescription,price\n" +
+		"A,Target,2022-01-01,13:01,6.49,Mountain Dew 12PK,6.49\n" +
+		"B,Walgreens,2022-01-02,08:13,1.25,Pepsi,1.25\n"
+
+	report, err := c.Import(ctx, strings.NewReader(file), "", "user-1")
+	assert.NoError(t, err)
+	if !assert.Len(t, report.Imported, 2) {
+		return
+	}
+	_, err = c.Process(ctx, testReceipt())
+	assert.NoError(t, err)
+
+	page, err := c.Receipts(ctx, "user-1", "", 1)
+	assert.NoError(t, err)
+	assert.Len(t, page.Receipts, 1)
+	assert.Equal(t, report.Imported[0].Id, page.Receipts[0].Id)
+	page, err = c.Receipts(ctx, "user-1", *page.Next, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Imported[1].Id, page.Receipts[0].Id)
+	assert.Nil(t, page.Next)
+}
+
+// TestExport tests streaming an export filtered by principal.
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	c := newTestClient(t, service, nil)
+
+	id, err := c.Process(ctx, testReceipt())
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Export(ctx, &buf, ExportOptions{Format: server.Ndjson}))
+	assert.Contains(t, buf.String(), `"id":"`+id+`"`)
+	assert.Contains(t, buf.String(), `"points":`)
+
+	buf.Reset()
+	assert.NoError(t, c.Export(ctx, &buf, ExportOptions{Principal: "someone-else"}))
+	assert.Equal(t, "id,principal,status,retailer,purchaseDate,purchaseTime,total,subtotal,tax,tip,currency,exchangeRate,points,submittedAt\n", buf.String())
+
+	err = c.Export(ctx, &buf, ExportOptions{From: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)})
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) {
+		assert.Equal(t, http.StatusBadRequest, p.Status)
+	}
+}
+
+// TestImport tests uploading a CSV file with a header mapping.
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	c := newTestClient(t, service, nil)
+	file := "Key,Store,Date,Time,Amount,Item,Price\n" +
+		"A,Target,2022-01-01,13:01,18.74,Mountain Dew 12PK,6.49\n" +
+		"A,Target,2022-01-01,13:01,18.74,Emils Cheese Pizza,12.25\n"
+
+	report, err := c.Import(ctx, strings.NewReader(file),
+		"receipt=Key,retailer=Store,purchaseDate=Date,purchaseTime=Time,total=Amount,shortDescription=Item", "partner-1")
+	assert.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	if assert.Len(t, report.Imported, 1) {
+		points, err := c.Points(ctx, report.Imported[0].Id)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), points.Points)
+	}
+
+	_, err = c.Import(ctx, strings.NewReader(file), "", "")
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) {
+		assert.Equal(t, http.StatusBadRequest, p.Status)
+	}
+}
+
+// TestProcessEmail tests submitting an email message, and that a message without a receipt is a problem.
+func TestProcessEmail(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, receipts.NewService(receipts.NewStorage()), nil)
+	message := "From: Corner Market <hello@cornermarket.example>\r\nSubject: Your receipt\r\n" +
+		"Date: Sun, 20 Mar 2022 14:40:00 +0000\r\n\r\n2022-03-20 14:33\r\nGatorade 2.25\r\nTOTAL 2.25\r\n"
+
+	processed, err := c.ProcessEmail(ctx, strings.NewReader(message), "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Corner Market", processed.Receipt.Retailer)
+	_, err = c.Receipt(ctx, processed.Id)
+	assert.NoError(t, err)
+
+	_, err = c.ProcessEmail(ctx, strings.NewReader("From: a@example.com\r\n\r\nThanks!\r\n"), "")
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) {
+		assert.Equal(t, problem.CodeReceiptUnreadable, p.Code)
+	}
+}
+
+// TestNotFound tests that a problem response is returned as a *problem.Problem without retrying.
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t, receipts.NewService(receipts.NewStorage()), nil)
+
+	_, err := c.Breakdown(context.Background(), uuid.New().String())
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) {
+		assert.Equal(t, http.StatusNotFound, p.Status)
+		assert.Equal(t, problem.CodeReceiptNotFound, p.Code)
+	}
+}
+
+// TestNew tests that the base URL must be absolute.
+func TestNew(t *testing.T) {
+	_, err := New("localhost:8080")
+	assert.Error(t, err)
+	_, err = New("http://localhost:8080/")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,147 @@
+// Command email submits the receipts in email messages to a running server, such as the e-receipts customers
+// forward to a receipts mailbox.
+//
+// Usage:
+//
+//	email [-url http://localhost:8080] [-principal id] [-keep] <maildir|directory|file.eml>...
+//
+// A Maildir is read from its new folder, and each message submitted is moved to its cur folder marked as seen, so
+// running the command again only submits the messages that arrived since. -keep leaves them in place. Other
+// directories are read for their .eml files, in name order. Each receipt submitted is printed with its ID, and each
+// message that failed with its error. It exits with status 1 if any message failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"fetch-app/client"
+	"fetch-app/problem"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the receipt API")
+	principal := flag.String("principal", "", "who the receipts are submitted by")
+	keep := flag.Bool("keep", false, "leave the messages submitted from a Maildir in its new folder")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fail(2, "usage: email [flags] <maildir|directory|file.eml>...")
+	}
+	c, err := client.New(*baseURL)
+	if err != nil {
+		fail(2, "invalid -url: %v", err)
+	}
+
+	failed := 0
+	for _, path := range flag.Args() {
+		messages, maildir, err := list(path)
+		if err != nil {
+			fail(2, "%v", err)
+		}
+		for _, message := range messages {
+			if err := submit(c, message, *principal); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
+				failed++
+				continue
+			}
+			if maildir && !*keep {
+				if err := markSeen(path, message); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", message, err)
+					failed++
+				}
+			}
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// list returns the messages to submit from a path, and whether it is a Maildir.
+func list(path string) ([]string, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if !info.IsDir() {
+		return []string{path}, false, nil
+	}
+	if isMaildir(path) {
+		entries, err := os.ReadDir(filepath.Join(path, "new"))
+		if err != nil {
+			return nil, false, err
+		}
+		messages := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			// Maildir names starting with a dot are not messages
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				messages = append(messages, filepath.Join(path, "new", entry.Name()))
+			}
+		}
+		sort.Strings(messages)
+		return messages, true, nil
+	}
+	messages, err := filepath.Glob(filepath.Join(path, "*.eml"))
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Strings(messages)
+	return messages, false, nil
+}
+
+// isMaildir reports whether a directory is a Maildir, which has new, cur and tmp folders.
+func isMaildir(path string) bool {
+	for _, folder := range []string{"new", "cur", "tmp"} {
+		if info, err := os.Stat(filepath.Join(path, folder)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// submit submits the receipt in a message file and prints its ID.
+func submit(c *client.Client, path, principal string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	processed, err := c.ProcessEmail(context.Background(), file, principal)
+	var p *problem.Problem
+	if errors.As(err, &p) && len(p.Errors) > 0 {
+		fields := make([]string, 0, len(p.Errors))
+		for _, field := range p.Errors {
+			fields = append(fields, field.Field)
+		}
+		return fmt.Errorf("%s (%s)", p.Detail, strings.Join(fields, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: receipt %s from %s, total %s\n", path, processed.Id, processed.Receipt.Retailer, processed.Receipt.Total)
+	return nil
+}
+
+// markSeen moves a message from the new folder of a Maildir to its cur folder with the seen flag, as mail readers
+// do with the messages they have read.
+func markSeen(maildir, message string) error {
+	name := filepath.Base(message)
+	if !strings.Contains(name, ":2,") {
+		name += ":2,"
+	}
+	if !strings.HasSuffix(name, "S") {
+		name += "S"
+	}
+	return os.Rename(message, filepath.Join(maildir, "cur", name))
+}
+
+// fail prints the message to standard error and exits with the status.
+func fail(status int, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(status)
+}
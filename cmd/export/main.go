@@ -0,0 +1,74 @@
+// Command export streams the receipts stored by a running server, with their points, as CSV or NDJSON.
+//
+// Usage:
+//
+//	export [-url http://localhost:8080] [-token admin-token] [-format csv|ndjson] [-rows receipt|item] [-from 2022-01-01] [-to 2022-12-31]
+//	       [-principal id] [-o file]
+//
+// The export is an admin route, so it needs the server's admin token, which -token defaults to $ADMIN_TOKEN. The
+// export is written to standard output unless -o names a file. It exits with status 1 if the export fails,
+// in which case the output is incomplete.
+package main
+
+import (
+	"context"
+	"fetch-app/client"
+	"fetch-app/server"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the receipt API")
+	token := flag.String("token", os.Getenv("ADMIN_TOKEN"), "admin token of the server, by default $ADMIN_TOKEN")
+	format := flag.String("format", "csv", "export format: csv or ndjson")
+	rows := flag.String("rows", "receipt", "CSV rows: one per receipt or per item")
+	from := flag.String("from", "", "only receipts purchased on or after this date (YYYY-MM-DD)")
+	to := flag.String("to", "", "only receipts purchased on or before this date (YYYY-MM-DD)")
+	principal := flag.String("principal", "", "only receipts submitted by this principal")
+	output := flag.String("o", "", "file to write the export to instead of standard output")
+	flag.Parse()
+
+	opts := client.ExportOptions{
+		Format:    server.ExportFormat(*format),
+		Rows:      server.ExportRows(*rows),
+		Principal: *principal,
+	}
+	var err error
+	if *from != "" {
+		if opts.From, err = time.Parse(time.DateOnly, *from); err != nil {
+			fail(2, "invalid -from: %v", err)
+		}
+	}
+	if *to != "" {
+		if opts.To, err = time.Parse(time.DateOnly, *to); err != nil {
+			fail(2, "invalid -to: %v", err)
+		}
+	}
+
+	c, err := client.New(*baseURL, client.WithAdminToken(*token))
+	if err != nil {
+		fail(2, "invalid -url: %v", err)
+	}
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fail(2, "%v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := c.Export(context.Background(), w, opts); err != nil {
+		fail(1, "exporting receipts: %v", err)
+	}
+}
+
+// fail prints the message to standard error and exits with the status.
+func fail(status int, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(status)
+}
@@ -0,0 +1,73 @@
+// Command import uploads a CSV file of receipts to a running server and writes the report of the rows left out.
+//
+// Usage:
+//
+//	import [-url http://localhost:8080] [-map field=header,...] [-principal id] [-report file] <receipts.csv>
+//
+// Each row of the file is an item, and the rows of a receipt share a receipt key. The columns default to the
+// field names: receipt, retailer, purchaseDate, purchaseTime, total, timezone, currency, shortDescription and
+// price. The report is CSV with a row per error, written to standard output unless -report names a file. It exits
+// with status 1 if any row was left out.
+package main
+
+import (
+	"context"
+	"fetch-app/client"
+	"fetch-app/importer"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of the receipt API")
+	mapping := flag.String("map", "", "field=header pairs mapping fields to columns, such as total=Amount")
+	principal := flag.String("principal", "", "who the receipts are submitted by")
+	reportPath := flag.String("report", "", "file to write the error report to instead of standard output")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fail(2, "usage: import [flags] <receipts.csv>")
+	}
+
+	// Check the mapping before uploading, since the server would refuse it anyway
+	if _, err := importer.ParseMapping(*mapping); err != nil {
+		fail(2, "invalid -map: %v", err)
+	}
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	defer file.Close()
+	c, err := client.New(*baseURL)
+	if err != nil {
+		fail(2, "invalid -url: %v", err)
+	}
+
+	report, err := c.Import(context.Background(), file, *mapping, *principal)
+	if err != nil {
+		fail(1, "importing receipts: %v", err)
+	}
+	var w io.Writer = os.Stdout
+	if *reportPath != "" {
+		out, err := os.Create(*reportPath)
+		if err != nil {
+			fail(2, "%v", err)
+		}
+		defer out.Close()
+		w = out
+	}
+	if err := importer.WriteReport(w, report.Errors); err != nil {
+		fail(2, "writing report: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d receipts, %d errors\n", len(report.Imported), len(report.Errors))
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// fail prints the message to standard error and exits with the status.
+func fail(status int, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(status)
+}
@@ -0,0 +1,175 @@
+// Package currency knows the ISO 4217 currencies receipts can be in, how many minor units each has, and how to
+// convert amounts into a base currency with a table of exchange rates.
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Default is the currency of receipts that do not name one.
+const Default = "USD"
+
+// digits maps the ISO 4217 codes of the currencies in use to the number of digits of their minor unit, such as 2
+// for the cents of a dollar. Funds, precious metals and other codes that are not legal tender are left out.
+var digits = map[string]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2, "AWG": 2, "AZN": 2,
+	"BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BIF": 0, "BMD": 2, "BND": 2, "BOB": 2, "BRL": 2,
+	"BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2, "CAD": 2, "CDF": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DJF": 0, "DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2,
+	"ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2, "FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2,
+	"GNF": 0, "GTQ": 2, "GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"IQD": 3, "IRR": 2, "ISK": 0, "JMD": 2, "JOD": 3, "JPY": 0, "KES": 2, "KGS": 2, "KHR": 2, "KMF": 0,
+	"KPW": 2, "KRW": 0, "KWD": 3, "KYD": 2, "KZT": 2, "LAK": 2, "LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2,
+	"LYD": 3, "MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2,
+	"MVR": 2, "MWK": 2, "MXN": 2, "MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2,
+	"NZD": 2, "OMR": 3, "PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2, "PLN": 2, "PYG": 0, "QAR": 2,
+	"RON": 2, "RSD": 2, "RUB": 2, "RWF": 0, "SAR": 2, "SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2,
+	"SHP": 2, "SLE": 2, "SOS": 2, "SRD": 2, "SSP": 2, "STN": 2, "SVC": 2, "SYP": 2, "SZL": 2, "THB": 2,
+	"TJS": 2, "TMT": 2, "TND": 3, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2, "UGX": 0,
+	"USD": 2, "UYU": 2, "UZS": 2, "VES": 2, "VND": 0, "VUV": 0, "WST": 2, "XAF": 0, "XCD": 2, "XCG": 2,
+	"XOF": 0, "XPF": 0, "YER": 2, "ZAR": 2, "ZMW": 2, "ZWG": 2,
+}
+
+// Digits returns the number of digits of the minor unit of a currency, and whether the code is a known ISO 4217
+// currency.
+func Digits(code string) (int, bool) {
+	n, ok := digits[code]
+	return n, ok
+}
+
+// Code returns the currency of a receipt, which is Default if it names none.
+func Code(receipt server.Receipt) string {
+	if receipt.Currency == nil || *receipt.Currency == "" {
+		return Default
+	}
+	return *receipt.Currency
+}
+
+// CheckAmount checks that an amount is written with exactly as many decimals as the currency has minor
+// units, such as "6.49" in dollars, "1200" in yen or "1.250" in Bahraini dinars.
+func CheckAmount(amount string, digits int) error {
+	whole, fraction, found := strings.Cut(amount, ".")
+	if whole == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return fmt.Errorf("%q is not an amount", amount)
+	}
+	switch {
+	case digits == 0 && found:
+		return errors.New("must have no decimals")
+	case digits > 0 && len(fraction) != digits:
+		return fmt.Errorf("must have %d decimals", digits)
+	}
+	return nil
+}
+
+// Check checks that a receipt is in a known currency and that each of its amounts has as many decimals as the
+// currency has minor units.
+//
+// Returns:
+//
+//	An error for each field at fault, with its JSON pointer like the schema violations, or nil if there is none.
+func Check(receipt server.Receipt) []validation.FieldError {
+	code := Code(receipt)
+	n, ok := Digits(code)
+	if !ok {
+		return []validation.FieldError{{Field: "/currency", Message: fmt.Sprintf("%q is not an ISO 4217 currency code", code)}}
+	}
+	var errs []validation.FieldError
+	check := func(field, amount string) {
+		if err := CheckAmount(amount, n); err != nil {
+			errs = append(errs, validation.FieldError{Field: field, Message: err.Error() + " in " + code})
+		}
+	}
+	check("/total", receipt.Total)
+	optional := []struct {
+		field  string
+		amount *string
+	}{{"/subtotal", receipt.Subtotal}, {"/tax", receipt.Tax}, {"/tip", receipt.Tip}}
+	for _, o := range optional {
+		if o.amount != nil {
+			check(o.field, *o.amount)
+		}
+	}
+	for i, item := range receipt.Items {
+		check(fmt.Sprintf("/items/%d/price", i), item.Price)
+		if item.UnitPrice != nil {
+			check(fmt.Sprintf("/items/%d/unitPrice", i), *item.UnitPrice)
+		}
+		if item.Discount != nil {
+			check(fmt.Sprintf("/items/%d/discount", i), *item.Discount)
+		}
+	}
+	return errs
+}
+
+// ratePattern matches the exchange rates of a rates file, as stored in the Receipt schema.
+var ratePattern = regexp.MustCompile(`^\d+(\.\d{1,6})?$`)
+
+// Rates converts amounts into a base currency. The rate of a currency is how many of its units one unit of the
+// base currency buys, as published by most rate feeds.
+type Rates struct {
+	// Base is the currency the rates are relative to.
+	Base string
+	// rates maps currency codes to their rate, as a decimal string.
+	rates map[string]string
+}
+
+// NewRates creates the rates for a base currency, validating the codes and that every rate is a positive decimal.
+// The rates are copied.
+func NewRates(base string, rates map[string]string) (*Rates, error) {
+	if _, ok := Digits(base); !ok {
+		return nil, fmt.Errorf("unknown base currency %q", base)
+	}
+	checked := make(map[string]string, len(rates))
+	for code, rate := range rates {
+		if _, ok := Digits(code); !ok {
+			return nil, fmt.Errorf("unknown currency %q", code)
+		}
+		if !ratePattern.MatchString(rate) || strings.Trim(rate, "0.") == "" {
+			return nil, fmt.Errorf("invalid rate %q for %s, expected a positive decimal with up to 6 decimals", rate, code)
+		}
+		checked[code] = rate
+	}
+	return &Rates{Base: base, rates: checked}, nil
+}
+
+// LoadRates reads the rates from a JSON file such as {"base": "USD", "rates": {"EUR": 0.92, "JPY": "151.20"}}.
+// Rates may be written as numbers or strings.
+func LoadRates(path string) (*Rates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Base  string                 `json:"base"`
+		Rates map[string]json.Number `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	rates := make(map[string]string, len(file.Rates))
+	for code, rate := range file.Rates {
+		rates[code] = rate.String()
+	}
+	return NewRates(file.Base, rates)
+}
+
+// Resolve returns the exchange rate a receipt should be scored with: the rate of its currency when it is not in the
+// base currency. Any rate the receipt carries is ignored, since only the server's rates are trusted. Empty means the
+// receipt is scored in its own currency, because it is in the base currency or its rate is unknown.
+func (r *Rates) Resolve(receipt server.Receipt) string {
+	if r == nil {
+		return ""
+	}
+	code := Code(receipt)
+	if code == r.Base {
+		return ""
+	}
+	return r.rates[code]
+}
@@ -0,0 +1,85 @@
+package currency
+
+import (
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		digits int
+		valid  bool
+	}{
+		{"6.49", 2, true},
+		{"6.5", 2, false},
+		{"6", 2, false},
+		{"1200", 0, true},
+		{"1200.00", 0, false},
+		{"1.250", 3, true},
+		{"1.25", 3, false},
+		{"", 2, false},
+		{"-1.00", 2, false},
+	}
+	for _, test := range tests {
+		t.Run(test.amount, func(t *testing.T) {
+			assert.Equal(t, test.valid, CheckAmount(test.amount, test.digits) == nil)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	code, tax := "JPY", "8.00"
+	receipt := server.Receipt{
+		Currency: &code,
+		Total:    "108",
+		Tax:      &tax,
+		Items:    []server.Item{{ShortDescription: "Tea", Price: "100"}},
+	}
+	assert.Equal(t, []validation.FieldError{
+		{Field: "/tax", Message: "must have no decimals in JPY"},
+	}, Check(receipt))
+
+	code = "usd"
+	assert.Equal(t, "/currency", Check(receipt)[0].Field)
+
+	// Receipts without a currency are in dollars
+	receipt.Currency = nil
+	assert.Len(t, Check(receipt), 2)
+}
+
+func TestLoadRates(t *testing.T) {
+	rates, err := LoadRates(filepath.Join("testdata", "rates.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", rates.Base)
+
+	code := "JPY"
+	receipt := server.Receipt{Currency: &code}
+	assert.Equal(t, "151.20", rates.Resolve(receipt))
+	code = "USD"
+	assert.Empty(t, rates.Resolve(receipt))
+	code = "GBP"
+	assert.Empty(t, rates.Resolve(receipt))
+
+	// A rate the receipt carries is never trusted
+	own := "0.01"
+	receipt.ExchangeRate = &own
+	assert.Empty(t, rates.Resolve(receipt))
+	assert.Empty(t, (*Rates)(nil).Resolve(receipt))
+	code = "JPY"
+	assert.Equal(t, "151.20", rates.Resolve(receipt))
+}
+
+func TestNewRatesInvalid(t *testing.T) {
+	_, err := NewRates("XYZ", nil)
+	assert.Error(t, err)
+	for _, rate := range []string{"0", "0.000", "-1", "1e3", "0.0000001", "one"} {
+		_, err := NewRates("USD", map[string]string{"EUR": rate})
+		assert.Error(t, err, rate)
+	}
+	_, err = NewRates("USD", map[string]string{"ABC": "1"})
+	assert.Error(t, err)
+}
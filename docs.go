@@ -0,0 +1,49 @@
+package main
+
+import (
+	_ "embed"
+	"fetch-app/server"
+	"fmt"
+	"net/http"
+)
+
+// docsPage renders the API reference with Redoc from the spec served at /openapi.json. The Redoc bundle is pinned
+// to a release, so a new one published to the CDN cannot change what the page runs.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// registerDocs serves the OpenAPI spec the server was generated from at /openapi.json
+// and a human-readable reference for it at /docs. The type URIs of problems, /problems/{code},
+// redirect to the reference's section on errors.
+//
+// Parameters:
+//
+//	mux - The ServeMux to register the routes on.
+//
+// Returns:
+//
+//	An error if the embedded spec cannot be loaded.
+func registerDocs(mux *http.ServeMux) error {
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		return fmt.Errorf("loading embedded OpenAPI spec: %w", err)
+	}
+	spec, err := swagger.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("encoding OpenAPI spec: %w", err)
+	}
+
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	})
+	mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.Write(docsPage)
+	})
+	mux.HandleFunc("GET /problems/{code}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs#section/Errors", http.StatusFound)
+	})
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRegisterDocs tests that the spec and its reference page are served.
+func TestRegisterDocs(t *testing.T) {
+	mux := http.NewServeMux()
+	assert.NoError(t, registerDocs(mux))
+
+	// The spec is served as JSON and documents the receipt routes
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var spec map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Contains(t, spec["paths"], "/receipts/process")
+	assert.Contains(t, spec["paths"], "/receipts/{id}/points")
+
+	// The reference page loads the spec
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `spec-url="/openapi.json"`)
+	assert.NotContains(t, rec.Body.String(), "/latest/")
+
+	// Problem types lead to the section on errors
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/problems/receipt_not_found", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/docs#section/Errors", rec.Header().Get("Location"))
+}
@@ -0,0 +1,130 @@
+package email
+
+import (
+	"errors"
+	"fetch-app/normalize"
+	"fetch-app/textparse"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readSample reads a sample message from testdata.
+func readSample(t *testing.T, name string) Message {
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Error opening sample: %v", err)
+	}
+	defer file.Close()
+	message, err := Read(file)
+	if err != nil {
+		t.Fatalf("Error reading sample: %v", err)
+	}
+	return message
+}
+
+// TestReadHTML tests that a quoted-printable HTML body is converted to text with a line per block and table row.
+func TestReadHTML(t *testing.T) {
+	message := readSample(t, "target.eml")
+	assert.Equal(t, "Your Target receipt — thanks!", message.Subject)
+	if assert.Len(t, message.From, 1) {
+		assert.Equal(t, "orders@e.target.com", message.Sender().Address)
+	}
+	assert.Equal(t, "2022-01-01T13:05:00-06:00", message.Date.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Contains(t, message.Text, "\nMountain Dew 12PK $6.49\n")
+	assert.Contains(t, message.Text, "we're open")
+	assert.NotContains(t, message.Text, "padding")
+	assert.NotContains(t, message.Text, "Receipt\n")
+}
+
+// TestReadAttached tests that the text and sender of an attached message are read, the original sender first.
+func TestReadAttached(t *testing.T) {
+	message := readSample(t, "forwarded.eml")
+	if assert.Len(t, message.From, 2) {
+		assert.Equal(t, "receipts@walgreens.com", message.From[0].Address)
+		assert.Equal(t, "jane@example.com", message.From[1].Address)
+	}
+	assert.Equal(t, "2022-01-02", message.Date.Format("2006-01-02"))
+	assert.True(t, strings.HasPrefix(message.Text, "See attached.\n"), message.Text)
+	assert.Contains(t, message.Text, "Pepsi - 12-oz        1.25")
+}
+
+// TestReadInline tests that the plain text alternative is preferred, in its charset, and that the headers of a
+// message forwarded inline name the original sender without being left in the text.
+func TestReadInline(t *testing.T) {
+	message := readSample(t, "inline.eml")
+	if assert.Len(t, message.From, 2) {
+		assert.Equal(t, "Café Corner Market", message.Sender().Name)
+	}
+	assert.Equal(t, "2022-03-20 14:40", message.Date.Format("2006-01-02 15:04"))
+	assert.True(t, strings.HasPrefix(message.Text, "Café Corner Market\n2:33 PM\n"), message.Text)
+}
+
+// TestReadInvalid tests that messages without text are refused.
+func TestReadInvalid(t *testing.T) {
+	_, err := Read(strings.NewReader("not a message"))
+	assert.Error(t, err)
+
+	_, err = Read(strings.NewReader("From: a@example.com\r\nContent-Type: image/png\r\n\r\nPNG"))
+	assert.EqualError(t, err, "the message has no text")
+}
+
+// TestExtract tests reading receipts with templates, and with the generic parser otherwise.
+func TestExtract(t *testing.T) {
+	templates, err := LoadTemplates(filepath.Join("testdata", "templates.json"))
+	assert.NoError(t, err)
+	extractor := Extractor{Templates: templates}
+
+	// The template cuts the receipt out of the promotions around it, and names the retailer
+	extraction, err := extractor.Extract(readSample(t, "target.eml"), normalize.MonthFirst)
+	assert.NoError(t, err)
+	assert.Equal(t, "Target", extraction.Template)
+	assert.Equal(t, "Target", extraction.Receipt.Retailer)
+	assert.Equal(t, 1.0, extraction.Confidence.Retailer)
+	assert.Equal(t, "13:01", extraction.Receipt.PurchaseTime)
+	assert.Equal(t, "18.74", extraction.Receipt.Total)
+	assert.Len(t, extraction.Receipt.Items, 2)
+	assert.Equal(t, 0.95, extraction.Confidence.Items)
+
+	// The attached receipt is matched by its original sender rather than the customer forwarding it
+	extraction, err = extractor.Extract(readSample(t, "forwarded.eml"), normalize.MonthFirst)
+	assert.NoError(t, err)
+	assert.Equal(t, "Walgreens", extraction.Template)
+	assert.Equal(t, "2.65", extraction.Receipt.Total)
+
+	// Without a template, the original sender names the retailer
+	extraction, err = extractor.Extract(readSample(t, "inline.eml"), normalize.MonthFirst)
+	assert.NoError(t, err)
+	assert.Empty(t, extraction.Template)
+	assert.Equal(t, "Café Corner Market", extraction.Receipt.Retailer)
+	assert.Equal(t, "2022-03-20", extraction.Receipt.PurchaseDate.String())
+	assert.Equal(t, 0.5, extraction.Confidence.PurchaseDate)
+	assert.Equal(t, "14:33", extraction.Receipt.PurchaseTime)
+	assert.Len(t, extraction.Receipt.Items, 4)
+}
+
+// TestExtractMissing tests that a message without a receipt reports the fields missing from its text.
+func TestExtractMissing(t *testing.T) {
+	message := Message{Text: "Thanks for shopping with us!\nSee you soon"}
+	_, err := Extractor{}.Extract(message, normalize.MonthFirst)
+	var missing *textparse.MissingError
+	if assert.True(t, errors.As(err, &missing)) {
+		assert.Equal(t, []string{"purchaseDate", "purchaseTime", "items", "total"}, missing.Fields)
+	}
+}
+
+// TestLoadTemplates tests that invalid templates are refused.
+func TestLoadTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	for _, content := range []string{
+		`[{"senders": ["target.com"]}]`,
+		`[{"retailer": "Target", "senders": ["target.com"], "start": "("}]`,
+		`{}`,
+	} {
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := LoadTemplates(path)
+		assert.Error(t, err, content)
+	}
+}
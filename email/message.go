@@ -0,0 +1,350 @@
+// Package email reads receipts from email messages, such as e-receipts forwarded by customers. A message is
+// decoded into its text, whether it was sent as plain text or HTML, and the receipt is read from the text by the
+// template of the retailer that sent it, or by the generic text parser.
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/htmlindex"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxDepth is how deeply multiparts and attached messages may nest before the rest of a message is ignored.
+const maxDepth = 10
+
+// Message is the content of an email relevant to reading a receipt from it.
+type Message struct {
+	// From are the senders, the original sender of a forwarded message first and the one who forwarded it last.
+	From []*mail.Address
+	// Subject is the decoded subject of the message.
+	Subject string
+	// Date is when the original message was sent, or zero if it has no valid Date header.
+	Date time.Time
+	// Text is the body as text. An HTML body is converted to text with a line per block, and the text of attached
+	// messages is included where they are attached.
+	Text string
+}
+
+// Sender returns the original sender of the message, or nil if it has none.
+func (m Message) Sender() *mail.Address {
+	if len(m.From) == 0 {
+		return nil
+	}
+	return m.From[0]
+}
+
+var (
+	// forwardMarker matches the line introducing a message forwarded inline, above its headers.
+	forwardMarker = regexp.MustCompile(`(?i)^-*\s*(forwarded message|original message|begin forwarded message:?)\s*-*$`)
+	// headerLine matches a header of a message forwarded inline.
+	headerLine = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*):\s*(.*)$`)
+	// quoteMarks matches the marks quoting a line in a reply or forward.
+	quoteMarks = regexp.MustCompile(`(?m)^(>\s?)+`)
+)
+
+// decoder decodes the encoded words in headers, in any charset the text package knows.
+var decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Read reads an RFC 822 message, decoding its MIME parts. The body is taken from the text/plain part, or the
+// text/html part if it has none; attachments other than attached messages are ignored.
+//
+// Returns:
+//
+//	The message, or an error if it is not a message or has no text.
+func Read(r io.Reader) (Message, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return Message{}, fmt.Errorf("reading message: %w", err)
+	}
+	var message Message
+	if message.Text, err = message.read(msg.Header, msg.Body, 0); err != nil {
+		return Message{}, err
+	}
+	if subject, err := decoder.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		message.Subject = subject
+	}
+	message.Text = message.unforward(strings.TrimSpace(quoteMarks.ReplaceAllString(message.Text, "")))
+	if message.Text == "" {
+		return Message{}, errors.New("the message has no text")
+	}
+	return message, nil
+}
+
+// read adds the senders of a message or attached message to m, and returns its text. The senders of attached
+// messages are added first, so they come before the one who attached them.
+func (m *Message) read(header mail.Header, body io.Reader, depth int) (string, error) {
+	text, err := m.part(textproto.MIMEHeader(header), body, depth)
+	if err != nil {
+		return "", err
+	}
+	if from, err := header.AddressList("From"); err == nil {
+		for _, address := range from {
+			if name, err := decoder.DecodeHeader(address.Name); err == nil {
+				address.Name = name
+			}
+		}
+		m.From = append(m.From, from...)
+	}
+	if date, err := header.Date(); err == nil && m.Date.IsZero() {
+		m.Date = date
+	}
+	return text, nil
+}
+
+// forwardedDates are the layouts mail clients write the date of a message forwarded inline in, besides RFC 5322.
+var forwardedDates = []string{
+	"Mon, Jan 2, 2006 at 3:04 PM",
+	"Monday, January 2, 2006 3:04 PM",
+	"January 2, 2006 at 3:04:05 PM MST",
+	"Mon, 2 Jan 2006 15:04",
+}
+
+// unforward removes the headers of the messages forwarded inline from the text, so their dates and addresses are not
+// read as part of the receipt. The senders and dates of the forwarded messages become the original ones.
+func (m *Message) unforward(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if !forwardMarker.MatchString(strings.TrimSpace(lines[i])) {
+			kept = append(kept, lines[i])
+			continue
+		}
+		for i+1 < len(lines) {
+			header := headerLine.FindStringSubmatch(strings.TrimSpace(lines[i+1]))
+			if header == nil {
+				break
+			}
+			i++
+			switch strings.ToLower(header[1]) {
+			case "from":
+				if address, err := mail.ParseAddress(header[2]); err == nil {
+					if name, err := decoder.DecodeHeader(address.Name); err == nil {
+						address.Name = name
+					}
+					m.From = append([]*mail.Address{address}, m.From...)
+				}
+			case "date", "sent":
+				if date, ok := parseForwardedDate(header[2]); ok {
+					m.Date = date
+				}
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// parseForwardedDate parses the date of a message forwarded inline.
+func parseForwardedDate(value string) (time.Time, bool) {
+	if date, err := mail.ParseDate(value); err == nil {
+		return date, true
+	}
+	for _, layout := range forwardedDates {
+		if date, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// part returns the text of a MIME part, which for a multipart is the text of its preferred alternative followed by
+// the text of the other parts.
+func (m *Message) part(header textproto.MIMEHeader, body io.Reader, depth int) (string, error) {
+	if depth > maxDepth {
+		return "", nil
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045 reads a part without a valid content type as plain text
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	body = decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		return m.multipart(mediaType, params["boundary"], body, depth)
+	case mediaType == "message/rfc822":
+		attached, err := mail.ReadMessage(body)
+		if err != nil {
+			return "", nil
+		}
+		return m.read(attached.Header, attached.Body, depth+1)
+	case isAttachment(header):
+		return "", nil
+	case mediaType == "text/plain", mediaType == "text/html":
+		content, err := io.ReadAll(charsetDecoder(params["charset"], body))
+		if err != nil {
+			return "", fmt.Errorf("reading %s part: %w", mediaType, err)
+		}
+		if mediaType == "text/html" {
+			return htmlText(string(content)), nil
+		}
+		return strings.ReplaceAll(string(content), "\r\n", "\n"), nil
+	}
+	return "", nil
+}
+
+// multipart returns the text of a multipart. Of alternatives, the plain text is preferred since it is laid out like
+// the printed receipt; the parts of other multiparts are all kept, in order.
+func (m *Message) multipart(mediaType, boundary string, body io.Reader, depth int) (string, error) {
+	if boundary == "" {
+		return "", errors.New("the multipart has no boundary")
+	}
+	reader := multipart.NewReader(body, boundary)
+	var texts []string
+	var plain, other string
+	for {
+		part, err := reader.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading multipart: %w", err)
+		}
+		text, err := m.part(part.Header, part, depth+1)
+		if err != nil {
+			return "", err
+		}
+		if mediaType != "multipart/alternative" {
+			texts = append(texts, text)
+			continue
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch {
+		case partType == "text/plain" && strings.TrimSpace(text) != "":
+			plain = text
+		case other == "":
+			other = text
+		}
+	}
+	if mediaType == "multipart/alternative" {
+		if plain != "" {
+			return plain, nil
+		}
+		return other, nil
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// isAttachment reports whether a part is attached rather than displayed in the message.
+func isAttachment(header textproto.MIMEHeader) bool {
+	disposition, _, err := mime.ParseMediaType(header.Get("Content-Disposition"))
+	return err == nil && disposition == "attachment"
+}
+
+// decodeTransfer undoes the content transfer encoding of a part. Identity encodings, and unknown ones, are read as
+// they are.
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &lineStripper{r: body})
+	}
+	return body
+}
+
+// lineStripper drops the line breaks base64 bodies are wrapped with.
+type lineStripper struct {
+	r io.Reader
+}
+
+// Read reads from the underlying reader without carriage returns and line feeds.
+func (l *lineStripper) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	kept := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			kept = append(kept, b)
+		}
+	}
+	return len(kept), err
+}
+
+// charsetDecoder converts a body in the charset to UTF-8. Bodies in unknown charsets are read as they are.
+func charsetDecoder(charset string, body io.Reader) io.Reader {
+	if r, err := charsetReader(charset, body); err == nil {
+		return r
+	}
+	return body
+}
+
+// charsetReader converts text in the charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if charset == "" || strings.EqualFold(charset, "utf-8") || strings.EqualFold(charset, "us-ascii") {
+		return input, nil
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unknown charset %q", charset)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// blockElements end a line when converting HTML to text. Table cells are separated by spaces instead, so a row
+// reads like a line of a printed receipt.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "br": true, "dd": true, "div": true, "dl": true,
+	"dt": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"tbody": true, "tfoot": true, "thead": true, "tr": true, "ul": true,
+}
+
+// htmlText converts an HTML body to text, with a line per block and table row and no blank lines. Scripts, styles and the head are
+// left out.
+func htmlText(body string) string {
+	var buf bytes.Buffer
+	tokenizer := html.NewTokenizer(strings.NewReader(body))
+	skipping := ""
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			lines := make([]string, 0)
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if line = strings.Join(strings.Fields(line), " "); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			return strings.Join(lines, "\n")
+		case html.TextToken:
+			if skipping == "" {
+				buf.WriteString(strings.Join(strings.Fields(string(tokenizer.Text())), " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			switch {
+			case skipping != "":
+			case tag == "script" || tag == "style" || tag == "head" || tag == "title":
+				skipping = tag
+			case blockElements[tag]:
+				buf.WriteByte('\n')
+			case tag == "td" || tag == "th":
+				buf.WriteByte(' ')
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			switch {
+			case tag == skipping:
+				skipping = ""
+			case skipping != "":
+			case blockElements[tag]:
+				buf.WriteByte('\n')
+			case tag == "td" || tag == "th":
+				buf.WriteByte(' ')
+			}
+		}
+	}
+}
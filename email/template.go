@@ -0,0 +1,185 @@
+package email
+
+import (
+	"encoding/json"
+	"errors"
+	"fetch-app/normalize"
+	"fetch-app/textparse"
+	"fmt"
+	"github.com/oapi-codegen/runtime/types"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Template reads the receipts of one retailer from its e-receipts. E-receipts surround the receipt with greetings,
+// links and promotions, so the template marks where the receipt starts and ends, and names the retailer, which the
+// text seldom does on its first line.
+type Template struct {
+	// Retailer is the retailer the receipts are from.
+	Retailer string `json:"retailer"`
+	// Senders are the domains the retailer's e-receipts are sent from. Subdomains match too.
+	Senders []string `json:"senders"`
+	// Start matches the first line of the receipt. Empty starts at the top of the message.
+	Start string `json:"start,omitempty"`
+	// End matches the last line of the receipt. Empty ends at the bottom of the message.
+	End string `json:"end,omitempty"`
+
+	start, end *regexp.Regexp
+}
+
+// LoadTemplates reads templates from a JSON file holding an array of them.
+//
+// Returns:
+//
+//	The templates, or an error if the file cannot be read, a template has no retailer or senders, or a pattern
+//	is not a valid regular expression.
+func LoadTemplates(path string) ([]Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var templates []Template
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("decoding templates: %w", err)
+	}
+	for i := range templates {
+		if err := templates[i].Compile(); err != nil {
+			return nil, fmt.Errorf("template %d: %w", i+1, err)
+		}
+	}
+	return templates, nil
+}
+
+// Compile checks the template and compiles its patterns, which a template must be before use. Templates loaded
+// with LoadTemplates already are.
+func (t *Template) Compile() error {
+	if t.Retailer == "" || len(t.Senders) == 0 {
+		return errors.New("a template needs a retailer and senders")
+	}
+	var err error
+	if t.Start != "" {
+		if t.start, err = regexp.Compile(t.Start); err != nil {
+			return fmt.Errorf("invalid start: %w", err)
+		}
+	}
+	if t.End != "" {
+		if t.end, err = regexp.Compile(t.End); err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether an e-receipt sent from the address is the template's retailer's.
+func (t *Template) matches(address string) bool {
+	_, domain, ok := strings.Cut(strings.ToLower(address), "@")
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(t.Senders, func(sender string) bool {
+		sender = strings.ToLower(sender)
+		return domain == sender || strings.HasSuffix(domain, "."+sender)
+	})
+}
+
+// cut returns the lines of the text from the first one matching the start up to the next one matching the end,
+// or the whole text if they match nothing.
+func (t *Template) cut(text string) string {
+	lines := strings.Split(text, "\n")
+	first := 0
+	if t.start != nil {
+		first = slices.IndexFunc(lines, t.start.MatchString)
+		if first < 0 {
+			return text
+		}
+	}
+	last := len(lines) - 1
+	if t.end != nil {
+		if i := slices.IndexFunc(lines[first:], t.end.MatchString); i >= 0 {
+			last = first + i
+		}
+	}
+	return strings.Join(lines[first:last+1], "\n")
+}
+
+// forward matches the subject of a forwarded message.
+var forward = regexp.MustCompile(`(?i)^\s*(fwd?|tr|wg|rv)\s*:`)
+
+// Extraction is a receipt read from a message.
+type Extraction struct {
+	textparse.Result
+	// Template is the retailer of the template the receipt was read with, or empty if it was read by the generic
+	// text parser.
+	Template string
+}
+
+// Extractor reads receipts from messages.
+type Extractor struct {
+	// Templates are tried in order against the senders of each message, the original sender first.
+	Templates []Template
+}
+
+// Extract reads the receipt from a message. The message is read with the template of the first of its senders
+// that has one, and otherwise by the generic text parser, with the original sender's name as the retailer if it is
+// known. A date or time missing from the text is taken from when the message was sent, in the sender's time zone,
+// with a low confidence. Numeric dates that could be either way round are read in the order.
+//
+// Returns:
+//
+//	The receipt and the confidence in each field.
+//	A *textparse.MissingError if the message has no receipt, in which case the extraction is incomplete.
+func (e Extractor) Extract(message Message, order normalize.Order) (Extraction, error) {
+	var template *Template
+	for _, sender := range message.From {
+		for i := range e.Templates {
+			if e.Templates[i].matches(sender.Address) {
+				template = &e.Templates[i]
+				break
+			}
+		}
+		if template != nil {
+			break
+		}
+	}
+
+	var extraction Extraction
+	var err error
+	if template != nil {
+		extraction.Template = template.Retailer
+		extraction.Result, err = textparse.Parse(template.cut(message.Text), order)
+		extraction.Receipt.Retailer, extraction.Confidence.Retailer = template.Retailer, 1
+	} else {
+		extraction.Result, err = textparse.Parse(message.Text, order)
+		// Unless the message names its original sender, a forwarded message is only known to be from the customer
+		if sender := message.Sender(); sender != nil && sender.Name != "" && (len(message.From) > 1 || !forward.MatchString(message.Subject)) {
+			extraction.Receipt.Retailer, extraction.Confidence.Retailer = sender.Name, 0.6
+		}
+	}
+
+	var missing *textparse.MissingError
+	if !errors.As(err, &missing) {
+		return extraction, err
+	}
+	fields := make([]string, 0, len(missing.Fields))
+	for _, field := range missing.Fields {
+		switch {
+		case field == "retailer" && extraction.Receipt.Retailer != "":
+		case field == "purchaseDate" && !message.Date.IsZero():
+			year, month, day := message.Date.Date()
+			extraction.Receipt.PurchaseDate = types.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+			extraction.Confidence.PurchaseDate = 0.5
+		case field == "purchaseTime" && !message.Date.IsZero():
+			extraction.Receipt.PurchaseTime = message.Date.Format("15:04")
+			extraction.Confidence.PurchaseTime = 0.5
+		default:
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) > 0 {
+		return extraction, &textparse.MissingError{Fields: fields}
+	}
+	return extraction, nil
+}
@@ -0,0 +1,205 @@
+package envelope
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeySize is the size of master and data keys in bytes, which selects AES-256.
+const KeySize = 32
+
+// ErrUnknownKey is returned when an envelope was sealed under a master key missing from the keyring.
+var ErrUnknownKey = errors.New("unknown master key")
+
+// Envelope is a payload encrypted under its own data key, which is stored alongside it wrapped by a master key.
+// Rotating the master key only rewraps the small data key, leaving the payload as it is.
+type Envelope struct {
+	// KeyID identifies the master key that wrapped the data key.
+	KeyID string `json:"keyId"`
+	// WrappedKey is the data key encrypted with AES-GCM under the master key, prefixed by its nonce.
+	WrappedKey []byte `json:"wrappedKey"`
+	// Ciphertext is the payload encrypted with AES-GCM under the data key, prefixed by its nonce.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keyring holds the master keys. The last key loaded is active and seals new envelopes, while the others are
+// kept to open envelopes sealed before a rotation.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+// NewKeyring creates a Keyring from master keys in the order they were created, so the last one is active.
+func NewKeyring(ids []string, keys [][]byte) (*Keyring, error) {
+	if len(ids) == 0 || len(ids) != len(keys) {
+		return nil, errors.New("a keyring needs at least one master key and an ID for each")
+	}
+	k := &Keyring{keys: make(map[string][]byte, len(ids))}
+	for i, id := range ids {
+		if id == "" {
+			return nil, errors.New("master key ID is empty")
+		}
+		if len(keys[i]) != KeySize {
+			return nil, fmt.Errorf("master key %s is %d bytes, not %d", id, len(keys[i]), KeySize)
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("master key %s is listed twice", id)
+		}
+		k.keys[id] = keys[i]
+	}
+	k.active = ids[len(ids)-1]
+	return k, nil
+}
+
+// LoadKeyring reads master keys from a key file, with one "id:base64-key" line per key, oldest first.
+// Blank lines and lines starting with # are ignored. To rotate, append a new key and restart.
+func LoadKeyring(path string) (*Keyring, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening key file: %w", err)
+	}
+	defer file.Close()
+
+	var ids []string
+	var keys [][]byte
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("key file line %d is not id:base64-key", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("key file line %d: %w", line, err)
+		}
+		ids = append(ids, strings.TrimSpace(id))
+		keys = append(keys, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	return NewKeyring(ids, keys)
+}
+
+// Active returns the ID of the master key sealing new envelopes.
+func (k *Keyring) Active() string {
+	return k.active
+}
+
+// Seal encrypts the payload under a new data key wrapped by the active master key.
+//
+// Parameters:
+//
+//	plaintext - The payload.
+//	aad       - Data the envelope is bound to without encrypting it, such as the record ID, so it cannot be
+//	            moved to another record. The same data must be passed to Open.
+//
+// Returns:
+//
+//	The Envelope, or an error if no random data key could be generated.
+func (k *Keyring) Seal(plaintext, aad []byte) (Envelope, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return Envelope{}, fmt.Errorf("generating data key: %w", err)
+	}
+	ciphertext, err := encrypt(dataKey, plaintext, aad)
+	if err != nil {
+		return Envelope{}, err
+	}
+	wrapped, err := encrypt(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{KeyID: k.active, WrappedKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts the payload of an envelope sealed with the given additional data.
+// It fails with ErrUnknownKey if the master key is not in the keyring, and with an error if the envelope or
+// the additional data was tampered with.
+func (k *Keyring) Open(env Envelope, aad []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(env)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decrypt(dataKey, env.Ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap wraps the data key of an envelope under the active master key. The payload is not re-encrypted.
+func (k *Keyring) Rewrap(env Envelope) (Envelope, error) {
+	if env.KeyID == k.active {
+		return env, nil
+	}
+	dataKey, err := k.unwrap(env)
+	if err != nil {
+		return Envelope{}, err
+	}
+	wrapped, err := encrypt(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{KeyID: k.active, WrappedKey: wrapped, Ciphertext: env.Ciphertext}, nil
+}
+
+// unwrap decrypts the data key of an envelope.
+func (k *Keyring) unwrap(env Envelope) ([]byte, error) {
+	masterKey, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, env.KeyID)
+	}
+	dataKey, err := decrypt(masterKey, env.WrappedKey, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// encrypt seals plaintext with AES-GCM under key, prefixing a random nonce.
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// decrypt opens a ciphertext produced by encrypt.
+func decrypt(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, aad)
+}
+
+// newGCM creates an AES-GCM cipher for the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
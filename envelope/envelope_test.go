@@ -0,0 +1,95 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testKeyring returns a keyring with a key for each ID, the last one active. Key i is filled with the byte i+1.
+func testKeyring(t *testing.T, ids ...string) *Keyring {
+	keys := make([][]byte, len(ids))
+	for i := range ids {
+		keys[i] = bytes.Repeat([]byte{byte(i + 1)}, KeySize)
+	}
+	keyring, err := NewKeyring(ids, keys)
+	if err != nil {
+		t.Fatalf("Error creating keyring: %v", err)
+	}
+	return keyring
+}
+
+// TestSealOpen tests that a sealed payload opens only with the same additional data.
+func TestSealOpen(t *testing.T) {
+	keyring := testKeyring(t, "k1")
+
+	env, err := keyring.Seal([]byte(`{"retailer":"Target"}`), []byte("receipt-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", env.KeyID)
+	assert.NotContains(t, string(env.Ciphertext), "Target")
+
+	plaintext, err := keyring.Open(env, []byte("receipt-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"retailer":"Target"}`, string(plaintext))
+
+	// An envelope moved to another record does not open
+	_, err = keyring.Open(env, []byte("receipt-2"))
+	assert.Error(t, err)
+
+	// Neither does a tampered one
+	env.Ciphertext[len(env.Ciphertext)-1] ^= 1
+	_, err = keyring.Open(env, []byte("receipt-1"))
+	assert.Error(t, err)
+}
+
+// TestRewrap tests that rotating the master key rewraps the data key and keeps the payload.
+func TestRewrap(t *testing.T) {
+	old := testKeyring(t, "k1")
+	env, err := old.Seal([]byte("payload"), nil)
+	assert.NoError(t, err)
+
+	rotated := testKeyring(t, "k1", "k2")
+	assert.Equal(t, "k2", rotated.Active())
+	rewrapped, err := rotated.Rewrap(env)
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", rewrapped.KeyID)
+	assert.Equal(t, env.Ciphertext, rewrapped.Ciphertext)
+
+	plaintext, err := rotated.Open(rewrapped, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(plaintext))
+
+	// Once the retired key is dropped, only the rewrapped envelope opens
+	current, err := NewKeyring([]string{"k2"}, [][]byte{bytes.Repeat([]byte{2}, KeySize)})
+	assert.NoError(t, err)
+	_, err = current.Open(env, nil)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = current.Open(rewrapped, nil)
+	assert.NoError(t, err)
+}
+
+// TestLoadKeyring tests reading master keys from a key file.
+func TestLoadKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	key1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize))
+	key2 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, KeySize))
+	assert.NoError(t, os.WriteFile(path, []byte("# master keys\nk1:"+key1+"\n\nk2: "+key2+"\n"), 0o600))
+
+	keyring, err := LoadKeyring(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", keyring.Active())
+
+	assert.NoError(t, os.WriteFile(path, []byte("k1:"+base64.StdEncoding.EncodeToString([]byte("short"))), 0o600))
+	_, err = LoadKeyring(path)
+	assert.ErrorContains(t, err, "5 bytes")
+
+	assert.NoError(t, os.WriteFile(path, []byte("k1:"+key1+"\nk1:"+key2), 0o600))
+	_, err = LoadKeyring(path)
+	assert.ErrorContains(t, err, "listed twice")
+
+	_, err = LoadKeyring(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// bodyError reports a request body the generated handlers could not decode.
+type bodyError struct {
+	err error
+}
+
+// Error returns the decoding error.
+func (e *bodyError) Error() string {
+	return "decoding request body: " + e.err.Error()
+}
+
+// Unwrap returns the decoding error.
+func (e *bodyError) Unwrap() error {
+	return e.err
+}
+
+// writeError renders every error as application/problem+json, so clients see one error format whether the failure
+// came from the service, a middleware or the routing. It is the error handler of the generated routes and of the
+// strict handler's responses.
+//
+// Parameters:
+//
+//	w   - The writer of the failed request's response.
+//	r   - The failed request.
+//	err - The error to render.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := toProblem(err, r)
+	if p.Code == problem.CodeInternal {
+		// The cause stays in the logs, since its text may reveal implementation details
+		log.Print(err)
+	}
+	if err := p.Write(w); err != nil {
+		log.Print(err)
+	}
+}
+
+// writeRequestError is the strict handler's error handler for request bodies it cannot decode.
+func writeRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, &bodyError{err: err})
+}
+
+// toProblem converts an error from the handler chain into a Problem for the request.
+// Problems pass through, decoding and parameter errors are Bad Requests (400), and anything else is an internal
+// error.
+func toProblem(err error, r *http.Request) *problem.Problem {
+	p := errorProblem(err)
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	return p
+}
+
+// errorProblem converts an error into a Problem, without the request-specific members.
+func errorProblem(err error) *problem.Problem {
+	var p *problem.Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	// Decoding and parsing errors repeat Go's error text, so they get a fixed detail
+	var bodyErr *bodyError
+	if errors.As(err, &bodyErr) {
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request body is not valid JSON")
+	}
+	var (
+		invalidParam  *server.InvalidParamFormatError
+		unmarshaling  *server.UnmarshalingParamError
+		tooMany       *server.TooManyValuesForParamError
+		missingParam  *server.RequiredParamError
+		missingHeader *server.RequiredHeaderError
+	)
+	switch {
+	case errors.As(err, &invalidParam):
+		return invalidParameter(invalidParam.ParamName)
+	case errors.As(err, &unmarshaling):
+		return invalidParameter(unmarshaling.ParamName)
+	case errors.As(err, &tooMany):
+		return invalidParameter(tooMany.ParamName)
+	case errors.As(err, &missingParam):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("Missing parameter %s", missingParam.ParamName))
+	case errors.As(err, &missingHeader):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("Missing header %s", missingHeader.ParamName))
+	}
+	return problem.New(http.StatusInternalServerError, problem.CodeInternal, "")
+}
+
+// invalidParameter is the problem of a parameter that could not be parsed.
+func invalidParameter(name string) *problem.Problem {
+	return problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("Invalid format for parameter %s", name))
+}
+
+// discardedResponse records the status and headers a handler responds with, discarding the body.
+type discardedResponse struct {
+	header http.Header
+	status int
+}
+
+// Header returns the headers the handler set.
+func (d *discardedResponse) Header() http.Header {
+	return d.header
+}
+
+// WriteHeader records the status.
+func (d *discardedResponse) WriteHeader(status int) {
+	d.status = status
+}
+
+// Write discards the body.
+func (d *discardedResponse) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// routeProblems renders the responses of a ServeMux to requests no route matches as problems: Not Found (404) for
+// an unknown path and Method Not Allowed (405), with its Allow header, for a method the path does not support.
+func routeProblems(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		// Let the ServeMux tell the two apart, keeping its Allow header but not its plain text body
+		response := &discardedResponse{header: make(http.Header)}
+		handler.ServeHTTP(response, r)
+		if response.status == http.StatusMethodNotAllowed {
+			w.Header().Set("Allow", response.header.Get("Allow"))
+			writeError(w, r, problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, ""))
+			return
+		}
+		writeError(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, ""))
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveProblem serves the request and decodes the problem it responds with.
+func serveProblem(t *testing.T, handler http.Handler, req *http.Request) problem.Problem {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+
+	var response problem.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, rec.Code, response.Status)
+	return response
+}
+
+// TestWriteError tests that errors from every source are rendered as problems.
+func TestWriteError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /receipts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, receipts.NotFound(r.PathValue("id")))
+	})
+	mux.HandleFunc("GET /failure", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, errors.New("dial tcp 10.0.0.1:5432: connection refused"))
+	})
+	mux.HandleFunc("GET /bind", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, &server.InvalidParamFormatError{ParamName: "id", Err: errors.New("strconv.Atoi: parsing \"x\": invalid syntax")})
+	})
+	handler := routeProblems(mux)
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected problem.Problem
+	}{
+		{
+			"handler problem",
+			httptest.NewRequest(http.MethodGet, "/receipts/abc", nil),
+			problem.Problem{
+				Type: "/problems/receipt_not_found", Title: "Receipt not found", Status: http.StatusNotFound,
+				Detail: "Receipt with ID abc not found", Instance: "/receipts/abc", Code: problem.CodeReceiptNotFound,
+			},
+		},
+		{
+			"unknown route",
+			httptest.NewRequest(http.MethodGet, "/unknown", nil),
+			problem.Problem{
+				Type: "/problems/not_found", Title: "Not found", Status: http.StatusNotFound,
+				Instance: "/unknown", Code: problem.CodeNotFound,
+			},
+		},
+		{
+			"method not allowed",
+			httptest.NewRequest(http.MethodDelete, "/receipts/abc", nil),
+			problem.Problem{
+				Type: "/problems/method_not_allowed", Title: "Method not allowed", Status: http.StatusMethodNotAllowed,
+				Instance: "/receipts/abc", Code: problem.CodeMethodNotAllowed,
+			},
+		},
+		{
+			"parameter error",
+			httptest.NewRequest(http.MethodGet, "/bind", nil),
+			problem.Problem{
+				Type: "/problems/invalid_request", Title: "Invalid request", Status: http.StatusBadRequest,
+				Detail: "Invalid format for parameter id", Instance: "/bind", Code: problem.CodeInvalidRequest,
+			},
+		},
+		{
+			// The cause is logged, never sent to the client
+			"internal error",
+			httptest.NewRequest(http.MethodGet, "/failure", nil),
+			problem.Problem{
+				Type: "/problems/internal_error", Title: "Internal server error", Status: http.StatusInternalServerError,
+				Instance: "/failure", Code: problem.CodeInternal,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, serveProblem(t, handler, test.req))
+		})
+	}
+}
+
+// TestWriteErrorDecodeFailure tests that a malformed receipt or parameter gets a problem without Go error text, when
+// the request reaches the routes without being checked against the spec.
+func TestWriteErrorDecodeFailure(t *testing.T) {
+	api, err := NewAPI(receipts.NewService(receipts.NewStorage()), APIOptions{AdminToken: testAdminToken})
+	assert.NoError(t, err)
+
+	response := serveProblem(t, api, jsonRequest(http.MethodPost, "/receipts/process", `{"retailer": `))
+	assert.Equal(t, problem.CodeInvalidRequest, response.Code)
+	assert.Equal(t, "The request body is not valid JSON", response.Detail)
+
+	response = serveProblem(t, api, asPrincipal(httptest.NewRequest(http.MethodGet, "/receipts?limit=ten", nil), "user-1"))
+	assert.Equal(t, problem.CodeInvalidRequest, response.Code)
+	assert.Equal(t, "Invalid format for parameter limit", response.Detail)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/receipts/export?from=January", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	response = serveProblem(t, api, req)
+	assert.Equal(t, "Invalid format for parameter from", response.Detail)
+}
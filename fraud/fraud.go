@@ -0,0 +1,177 @@
+package fraud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fetch-app/currency"
+	"fetch-app/server"
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Policy decides what happens to a receipt whose fingerprint matches one that was already submitted.
+type Policy int
+
+const (
+	// PolicyFlag accepts the duplicate but flags it for manual review. It is the default policy.
+	PolicyFlag Policy = iota
+	// PolicyZeroPoints accepts the duplicate but awards it no points.
+	PolicyZeroPoints
+	// PolicyReject refuses to store the duplicate.
+	PolicyReject
+)
+
+// String returns the configuration name of the policy.
+func (p Policy) String() string {
+	switch p {
+	case PolicyZeroPoints:
+		return "zero_points"
+	case PolicyReject:
+		return "reject"
+	default:
+		return "flag"
+	}
+}
+
+// ParsePolicy converts a configuration value into a Policy.
+// An empty value selects PolicyFlag.
+func ParsePolicy(s string) (Policy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "flag":
+		return PolicyFlag, nil
+	case "zero_points", "zero":
+		return PolicyZeroPoints, nil
+	case "reject":
+		return PolicyReject, nil
+	default:
+		return PolicyFlag, fmt.Errorf("unknown duplicate policy %q", s)
+	}
+}
+
+// Fingerprint returns a stable hash identifying the physical receipt behind a submission.
+// Two submissions of the same receipt produce the same fingerprint even if the retailer name differs in case or
+// punctuation, amounts differ in formatting, or the items are listed in a different order.
+func Fingerprint(receipt server.Receipt) string {
+	items := make([]string, 0, len(receipt.Items))
+	for _, item := range receipt.Items {
+		items = append(items, normalizeText(item.ShortDescription)+"|"+normalizeAmount(item.Price))
+	}
+	// The items form a multiset, so sort them while keeping repeated entries
+	sort.Strings(items)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "retailer:%s\n", normalizeRetailer(receipt.Retailer))
+	fmt.Fprintf(h, "date:%s\n", receipt.PurchaseDate.String())
+	fmt.Fprintf(h, "time:%s\n", normalizeTime(receipt.PurchaseTime))
+	fmt.Fprintf(h, "total:%s\n", normalizeAmount(receipt.Total))
+	// Receipts in dollars keep the fingerprints they had before receipts had currencies
+	if code := currency.Code(receipt); code != currency.Default {
+		fmt.Fprintf(h, "currency:%s\n", code)
+	}
+	for _, item := range items {
+		fmt.Fprintf(h, "item:%s\n", item)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeRetailer lowercases the retailer name and drops everything but letters and digits,
+// so "M&M Corner Market" and "m & m corner market" match.
+func normalizeRetailer(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFC.String(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// normalizeText composes a description to NFC, lowercases it and collapses runs of whitespace into single spaces.
+func normalizeText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(norm.NFC.String(s)), " "))
+}
+
+// normalizeAmount converts an amount into whole cents, so "9", "9.0" and "9.00" match.
+// Amounts that cannot be parsed are used as written.
+func normalizeAmount(s string) string {
+	val, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return strconv.FormatInt(int64(math.Round(val*100)), 10)
+}
+
+// normalizeTime reformats a 24-hour "HH:MM" time, so "9:05" and "09:05" match.
+// Times that cannot be parsed are used as written.
+func normalizeTime(s string) string {
+	parsedTime, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return parsedTime.Format("15:04")
+}
+
+// Index maps fingerprints to the IDs of the receipts submitted with them.
+// It is safe for concurrent use.
+type Index struct {
+	mu            sync.Mutex
+	byFingerprint map[string][]string
+}
+
+// NewIndex initializes and returns an empty Index.
+func NewIndex() *Index {
+	return &Index{
+		byFingerprint: make(map[string][]string),
+	}
+}
+
+// Lookup returns the IDs of the receipts previously indexed under the fingerprint, oldest first.
+func (ix *Index) Lookup(fingerprint string) []string {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return append([]string(nil), ix.byFingerprint[fingerprint]...)
+}
+
+// Register looks up prior matches for the fingerprint and, unless the policy rejects the submission, indexes id
+// under it. The lookup and the insertion happen atomically, so concurrent submissions of the same receipt cannot
+// both slip through as originals.
+//
+// Returns:
+//
+//	The IDs of the prior matches, and whether the submission was accepted.
+func (ix *Index) Register(fingerprint, id string, policy Policy) ([]string, bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	prior := append([]string(nil), ix.byFingerprint[fingerprint]...)
+	if len(prior) > 0 && policy == PolicyReject {
+		return prior, false
+	}
+	ix.byFingerprint[fingerprint] = append(ix.byFingerprint[fingerprint], id)
+	return prior, true
+}
+
+// Remove stops indexing id under the fingerprint, so later submissions of the receipt no longer match it.
+func (ix *Index) Remove(fingerprint, id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	remaining := make([]string, 0, len(ix.byFingerprint[fingerprint]))
+	for _, indexed := range ix.byFingerprint[fingerprint] {
+		if indexed != id {
+			remaining = append(remaining, indexed)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(ix.byFingerprint, fingerprint)
+		return
+	}
+	ix.byFingerprint[fingerprint] = remaining
+}
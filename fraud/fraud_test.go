@@ -0,0 +1,130 @@
+package fraud
+
+import (
+	"fetch-app/server"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Helper function to create a test receipt
+func createTestReceipt() server.Receipt {
+	return server.Receipt{
+		Retailer:     "M&M Corner Market",
+		PurchaseDate: types.Date{Time: time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "14:33",
+		Total:        "9.00",
+		Items: []server.Item{
+			{ShortDescription: "Gatorade", Price: "2.25"},
+			{ShortDescription: "Gatorade", Price: "2.25"},
+			{ShortDescription: "Candy", Price: "4.50"},
+		},
+	}
+}
+
+func TestFingerprintIgnoresCosmeticDifferences(t *testing.T) {
+	expected := Fingerprint(createTestReceipt())
+
+	tests := map[string]func(r *server.Receipt){
+		"retailer case and punctuation": func(r *server.Receipt) { r.Retailer = "m & m CORNER market" },
+		"amount formatting":             func(r *server.Receipt) { r.Total = "9"; r.Items[2].Price = "4.5" },
+		"time whitespace":               func(r *server.Receipt) { r.PurchaseTime = " 14:33 " },
+		"description whitespace":        func(r *server.Receipt) { r.Items[0].ShortDescription = "  gatorade " },
+		"item order": func(r *server.Receipt) {
+			r.Items[0], r.Items[2] = r.Items[2], r.Items[0]
+		},
+		"explicit default currency": func(r *server.Receipt) { code := "USD"; r.Currency = &code },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			receipt := createTestReceipt()
+			mutate(&receipt)
+			assert.Equal(t, expected, Fingerprint(receipt))
+		})
+	}
+}
+
+func TestFingerprintDistinguishesReceipts(t *testing.T) {
+	original := Fingerprint(createTestReceipt())
+
+	tests := map[string]func(r *server.Receipt){
+		"retailer": func(r *server.Receipt) { r.Retailer = "Target" },
+		"date": func(r *server.Receipt) {
+			r.PurchaseDate = types.Date{Time: time.Date(2022, time.March, 21, 0, 0, 0, 0, time.UTC)}
+		},
+		"time":              func(r *server.Receipt) { r.PurchaseTime = "14:34" },
+		"total":             func(r *server.Receipt) { r.Total = "9.01" },
+		"item price":        func(r *server.Receipt) { r.Items[2].Price = "4.51" },
+		"item multiplicity": func(r *server.Receipt) { r.Items = r.Items[1:] },
+		"currency":          func(r *server.Receipt) { code := "EUR"; r.Currency = &code },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			receipt := createTestReceipt()
+			mutate(&receipt)
+			assert.NotEqual(t, original, Fingerprint(receipt))
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Policy
+	}{
+		{"", PolicyFlag},
+		{"flag", PolicyFlag},
+		{"zero_points", PolicyZeroPoints},
+		{"Reject", PolicyReject},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			policy, err := ParsePolicy(test.input)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, policy)
+		})
+	}
+
+	_, err := ParsePolicy("ignore")
+	assert.Error(t, err)
+}
+
+func TestIndexRegister(t *testing.T) {
+	index := NewIndex()
+
+	prior, accepted := index.Register("abc", "first", PolicyReject)
+	assert.Empty(t, prior)
+	assert.True(t, accepted)
+
+	// A rejected duplicate is not indexed
+	prior, accepted = index.Register("abc", "second", PolicyReject)
+	assert.Equal(t, []string{"first"}, prior)
+	assert.False(t, accepted)
+
+	// An accepted duplicate is indexed after the original
+	prior, accepted = index.Register("abc", "third", PolicyFlag)
+	assert.Equal(t, []string{"first"}, prior)
+	assert.True(t, accepted)
+	assert.Equal(t, []string{"first", "third"}, index.Lookup("abc"))
+	assert.Empty(t, index.Lookup("other"))
+}
+
+// TestIndexRemove tests that removed receipts no longer match later submissions.
+func TestIndexRemove(t *testing.T) {
+	index := NewIndex()
+	index.Register("abc", "first", PolicyFlag)
+	index.Register("abc", "second", PolicyFlag)
+
+	index.Remove("abc", "first")
+	assert.Equal(t, []string{"second"}, index.Lookup("abc"))
+
+	index.Remove("abc", "second")
+	assert.Empty(t, index.Lookup("abc"))
+	prior, accepted := index.Register("abc", "third", PolicyReject)
+	assert.Empty(t, prior)
+	assert.True(t, accepted)
+}
@@ -0,0 +1,173 @@
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategy decides how receipt IDs are generated.
+type Strategy int
+
+const (
+	// StrategyUUIDv4 generates random version 4 UUIDs. It is the default strategy.
+	StrategyUUIDv4 Strategy = iota
+	// StrategyUUIDv7 generates version 7 UUIDs, which start with the creation time in milliseconds.
+	StrategyUUIDv7
+	// StrategyULID generates ULIDs, which start with the creation time in milliseconds and are shorter than UUIDs.
+	StrategyULID
+)
+
+// String returns the configuration name of the strategy.
+func (s Strategy) String() string {
+	switch s {
+	case StrategyUUIDv7:
+		return "uuidv7"
+	case StrategyULID:
+		return "ulid"
+	default:
+		return "uuidv4"
+	}
+}
+
+// ParseStrategy converts a configuration value into a Strategy.
+// An empty value selects StrategyUUIDv4.
+func ParseStrategy(s string) (Strategy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "uuidv4", "uuid":
+		return StrategyUUIDv4, nil
+	case "uuidv7":
+		return StrategyUUIDv7, nil
+	case "ulid":
+		return StrategyULID, nil
+	default:
+		return StrategyUUIDv4, fmt.Errorf("unknown ID strategy %q", s)
+	}
+}
+
+// TimeOrdered reports whether the IDs sort in the order they were generated, so they can serve as cursors.
+func (s Strategy) TimeOrdered() bool {
+	return s == StrategyUUIDv7 || s == StrategyULID
+}
+
+// New generates an ID.
+func (s Strategy) New() string {
+	switch s {
+	case StrategyUUIDv7:
+		return uuid.Must(uuid.NewV7()).String()
+	case StrategyULID:
+		return ulids.next(time.Now())
+	default:
+		return uuid.New().String()
+	}
+}
+
+// Valid reports whether id is well formed under any strategy: a UUID in its canonical lowercase form or a ULID.
+// IDs of every strategy are accepted, so receipts stored before the strategy changed remain reachable.
+func Valid(id string) bool {
+	switch len(id) {
+	case 36:
+		parsed, err := uuid.Parse(id)
+		return err == nil && parsed.String() == id
+	case ulidLength:
+		return validULID(id)
+	default:
+		return false
+	}
+}
+
+// ulidLength is the length of an encoded ULID: 10 characters of timestamp followed by 16 of randomness.
+const ulidLength = 26
+
+// crockford is the Crockford base32 alphabet ULIDs are encoded in. It leaves out I, L, O and U.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator generates monotonic ULIDs: an ID generated in the same millisecond as the previous one increments
+// its randomness, so IDs sort in the order they were generated even within a millisecond.
+type ulidGenerator struct {
+	mu      sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+}
+
+var ulids ulidGenerator
+
+// next generates the ULID for the given time.
+func (g *ulidGenerator) next(now time.Time) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(now.UnixMilli())
+	if ms <= g.lastMs {
+		// Increment the randomness of the previous ID, moving on to the next millisecond if it overflows
+		ms = g.lastMs
+		if increment(g.entropy[:]) {
+			ms++
+		}
+	} else {
+		if _, err := rand.Read(g.entropy[:]); err != nil {
+			panic(fmt.Sprintf("reading random bytes: %v", err))
+		}
+	}
+	g.lastMs = ms
+
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(id[6:], g.entropy[:])
+	return encodeULID(id)
+}
+
+// increment adds one to the big-endian number in b, reporting whether it overflowed.
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeULID encodes the 128 bits of a ULID as 26 Crockford base32 characters, most significant first.
+// The first character only holds the top 3 bits.
+func encodeULID(id [16]byte) string {
+	var encoded [ulidLength]byte
+	for i := range encoded {
+		// Character i holds bits 130-5*(i+1) to 130-5*i of the 130 bit number, whose top 2 bits are zero
+		bit := 5*i - 2
+		value := 0
+		for j := 0; j < 5; j++ {
+			b := bit + j
+			if b < 0 {
+				continue
+			}
+			value = value<<1 | int(id[b/8]>>(7-b%8)&1)
+		}
+		encoded[i] = crockford[value]
+	}
+	return string(encoded[:])
+}
+
+// validULID reports whether id is a ULID: 26 Crockford base32 characters, in either case, whose first character
+// does not overflow 128 bits.
+func validULID(id string) bool {
+	for i := 0; i < len(id); i++ {
+		if !strings.ContainsRune(crockford, rune(upper(id[i]))) {
+			return false
+		}
+	}
+	return id[0] <= '7'
+}
+
+// upper converts an ASCII letter to upper case.
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
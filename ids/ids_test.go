@@ -0,0 +1,88 @@
+package ids
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestParseStrategy tests that configuration values select the right strategy.
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Strategy
+	}{
+		{"", StrategyUUIDv4},
+		{"uuidv4", StrategyUUIDv4},
+		{"UUIDv7", StrategyUUIDv7},
+		{" ulid ", StrategyULID},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			strategy, err := ParseStrategy(test.input)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, strategy)
+		})
+	}
+
+	_, err := ParseStrategy("snowflake")
+	assert.Error(t, err)
+}
+
+// TestNew tests that every strategy generates valid IDs, and the time-ordered ones sort in generation order.
+func TestNew(t *testing.T) {
+	for _, strategy := range []Strategy{StrategyUUIDv4, StrategyUUIDv7, StrategyULID} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			generated := make([]string, 1000)
+			for i := range generated {
+				generated[i] = strategy.New()
+				assert.True(t, Valid(generated[i]), generated[i])
+			}
+			if strategy.TimeOrdered() {
+				assert.True(t, sort.StringsAreSorted(generated))
+			}
+		})
+	}
+
+	assert.Equal(t, uuid.Version(7), uuid.MustParse(StrategyUUIDv7.New()).Version())
+	assert.Len(t, StrategyULID.New(), 26)
+}
+
+// TestULIDTimestamp tests that a ULID encodes its time in the first 10 characters.
+func TestULIDTimestamp(t *testing.T) {
+	var g ulidGenerator
+	id := g.next(time.UnixMilli(1469918176385))
+	assert.Equal(t, "01ARYZ6S41", id[:10])
+
+	// IDs generated in the same millisecond, or with a clock that went back, still increase
+	next := g.next(time.UnixMilli(1469918176000))
+	assert.Equal(t, "01ARYZ6S41", next[:10])
+	assert.Greater(t, next, id)
+}
+
+// TestValid tests which IDs are well formed.
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"adb6b560-0eef-42bc-9d16-df48f30e89b2", true},
+		{"ADB6B560-0EEF-42BC-9D16-DF48F30E89B2", false},
+		{"adb6b5600eef42bc9d16df48f30e89b2", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", true},
+		{"01arz3ndektsv4rrffq69g5fav", true},
+		{"81ARZ3NDEKTSV4RRFFQ69G5FAV", false},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAU", false},
+		{"missing", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			assert.Equal(t, test.valid, Valid(test.id))
+		})
+	}
+}
@@ -0,0 +1,314 @@
+// Package importer reads receipts from flat CSV files, where each row is one item and the rows of a receipt share
+// a receipt key. The columns are mapped to receipt fields by header name, so partners' files can be imported as
+// they are.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fetch-app/currency"
+	"fetch-app/normalize"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"fmt"
+	"github.com/oapi-codegen/runtime/types"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is a receipt field a column can be mapped to.
+type Field string
+
+const (
+	// FieldReceipt is the receipt key grouping the rows of one receipt.
+	FieldReceipt Field = "receipt"
+	// FieldRetailer is the retailer of the receipt.
+	FieldRetailer Field = "retailer"
+	// FieldPurchaseDate is the purchase date of the receipt, in any form a submitted receipt's date may take.
+	FieldPurchaseDate Field = "purchaseDate"
+	// FieldPurchaseTime is the purchase time of the receipt.
+	FieldPurchaseTime Field = "purchaseTime"
+	// FieldTotal is the total of the receipt.
+	FieldTotal Field = "total"
+	// FieldTimezone is the optional time zone of the receipt.
+	FieldTimezone Field = "timezone"
+	// FieldCurrency is the optional ISO 4217 currency of the receipt.
+	FieldCurrency Field = "currency"
+	// FieldShortDescription is the description of the row's item.
+	FieldShortDescription Field = "shortDescription"
+	// FieldPrice is the price of the row's item.
+	FieldPrice Field = "price"
+)
+
+// receiptFields are the fields every row of a receipt repeats, which must agree, in the order they are checked.
+var receiptFields = []Field{FieldRetailer, FieldPurchaseDate, FieldPurchaseTime, FieldTotal, FieldTimezone, FieldCurrency}
+
+// fields are all the fields, in the order they are listed.
+var fields = append(append([]Field{FieldReceipt}, receiptFields...), FieldShortDescription, FieldPrice)
+
+// Mapping names the header of the column holding each field. Headers are matched ignoring case and surrounding
+// spaces.
+type Mapping map[Field]string
+
+// DefaultMapping maps each field to the column named after it, such as "retailer" or "shortDescription".
+func DefaultMapping() Mapping {
+	mapping := make(Mapping, len(fields))
+	for _, field := range fields {
+		mapping[field] = string(field)
+	}
+	return mapping
+}
+
+// ParseMapping converts a configuration value into a mapping. The value lists field=header pairs separated by
+// commas, such as "retailer=Store,total=Amount". Fields that are not listed keep their default header.
+func ParseMapping(s string) (Mapping, error) {
+	mapping := DefaultMapping()
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, header, ok := strings.Cut(pair, "=")
+		field := Field(strings.TrimSpace(name))
+		if _, known := mapping[field]; !known {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		if !ok || strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("field %q is not mapped to a header", name)
+		}
+		mapping[field] = strings.TrimSpace(header)
+	}
+	return mapping, nil
+}
+
+// Group is the receipt read from the rows sharing a receipt key.
+type Group struct {
+	// Key is the receipt key of the rows.
+	Key string
+	// Rows are the row numbers of the receipt's items, in order. The header is row 1, like in a spreadsheet.
+	Rows []int
+	// Receipt is the receipt read from the rows.
+	Receipt server.Receipt
+}
+
+// Reader reads receipts from CSV.
+type Reader struct {
+	// Mapping maps the columns to fields.
+	Mapping Mapping
+	// Validator checks each receipt against the Receipt schema, like the HTTP API does. Nil skips the check.
+	Validator *validation.Validator
+	// Normalizer rewrites the purchase dates and times into their canonical forms, like the HTTP API does.
+	Normalizer normalize.Normalizer
+}
+
+// Read reads the receipts from a CSV file with a header row, grouping the rows by receipt key in the order the keys
+// first appear. A receipt with any invalid row is left out, and every problem found with it is reported.
+//
+// Returns:
+//
+//	The valid receipts, and the errors of the rows left out, in row order.
+//	An error if the file is not CSV or the header lacks a mapped column, in which case nothing is read.
+func (r Reader) Read(input io.Reader) ([]Group, []server.RowError, error) {
+	csvReader := csv.NewReader(input)
+	csvReader.FieldsPerRecord = -1
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns, err := r.columns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make([]*Group, 0)
+	byKey := make(map[string]*Group)
+	failed := make(map[string][]server.RowError)
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, nil, fmt.Errorf("reading row %d: %w", parseErr.StartLine, parseErr.Err)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row, _ := csvReader.FieldPos(0)
+		value := func(field Field) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			cell := strings.TrimSpace(record[i])
+			switch field {
+			case FieldPurchaseDate:
+				if canonical, ok := r.Normalizer.Date(cell, r.Normalizer.Order); ok {
+					return canonical
+				}
+			case FieldPurchaseTime:
+				if canonical, ok := r.Normalizer.Time(cell); ok {
+					return canonical
+				}
+			}
+			return cell
+		}
+
+		key := value(FieldReceipt)
+		if key == "" {
+			failed[""] = append(failed[""], server.RowError{Row: row, Field: string(FieldReceipt), Message: "the row has no receipt key"})
+			continue
+		}
+		group, exists := byKey[key]
+		if !exists {
+			group = &Group{Key: key, Receipt: server.Receipt{Items: make([]server.Item, 0)}}
+			byKey[key] = group
+			groups = append(groups, group)
+			failed[key] = append(failed[key], group.setReceipt(row, value)...)
+		} else {
+			failed[key] = append(failed[key], group.checkReceipt(row, value)...)
+		}
+		group.Rows = append(group.Rows, row)
+		group.Receipt.Items = append(group.Receipt.Items, server.Item{
+			ShortDescription: value(FieldShortDescription),
+			Price:            value(FieldPrice),
+		})
+	}
+
+	valid := make([]Group, 0, len(groups))
+	rowErrors := failed[""]
+	for _, group := range groups {
+		errs := append(failed[group.Key], r.validate(group)...)
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		valid = append(valid, *group)
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+	return valid, rowErrors, nil
+}
+
+// columns finds the column of each mapped field in the header. The time zone and currency columns are optional.
+func (r Reader) columns(header []string) (map[Field]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save a byte order mark before the first header
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, exists := positions[name]; !exists {
+			positions[name] = i
+		}
+	}
+	mapping := r.Mapping
+	if mapping == nil {
+		mapping = DefaultMapping()
+	}
+
+	columns := make(map[Field]int, len(fields))
+	var missing []string
+	for _, field := range fields {
+		i, ok := positions[strings.ToLower(mapping[field])]
+		switch {
+		case ok:
+			columns[field] = i
+		case field != FieldTimezone && field != FieldCurrency:
+			missing = append(missing, fmt.Sprintf("%q for %s", mapping[field], field))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the header has no column %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// setReceipt fills in the receipt fields from the first row of the group.
+func (g *Group) setReceipt(row int, value func(Field) string) []server.RowError {
+	g.Receipt.Retailer = value(FieldRetailer)
+	g.Receipt.PurchaseTime = value(FieldPurchaseTime)
+	g.Receipt.Total = value(FieldTotal)
+	if zone := value(FieldTimezone); zone != "" {
+		g.Receipt.Timezone = &zone
+	}
+	if code := value(FieldCurrency); code != "" {
+		g.Receipt.Currency = &code
+	}
+	purchaseDate, err := time.Parse(types.DateFormat, value(FieldPurchaseDate))
+	if err != nil {
+		return []server.RowError{{Row: row, Receipt: g.Key, Field: string(FieldPurchaseDate), Message: "must be a date, such as 2022-03-20 or 03/20/2022"}}
+	}
+	g.Receipt.PurchaseDate = types.Date{Time: purchaseDate}
+	return nil
+}
+
+// checkReceipt checks that a later row of the group repeats the receipt fields of the first.
+func (g *Group) checkReceipt(row int, value func(Field) string) []server.RowError {
+	first := map[Field]string{
+		FieldRetailer:     g.Receipt.Retailer,
+		FieldPurchaseDate: g.Receipt.PurchaseDate.Format(types.DateFormat),
+		FieldPurchaseTime: g.Receipt.PurchaseTime,
+		FieldTotal:        g.Receipt.Total,
+	}
+	if g.Receipt.Timezone != nil {
+		first[FieldTimezone] = *g.Receipt.Timezone
+	}
+	if g.Receipt.Currency != nil {
+		first[FieldCurrency] = *g.Receipt.Currency
+	}
+	var errs []server.RowError
+	for _, field := range receiptFields {
+		if got := value(field); got != first[field] {
+			errs = append(errs, server.RowError{Row: row, Receipt: g.Key, Field: string(field),
+				Message: fmt.Sprintf("%q differs from %q on row %d", got, first[field], g.Rows[0])})
+		}
+	}
+	return errs
+}
+
+// validate checks the receipt of the group against the schema and the decimals of its currency, reporting item
+// errors on the item's row and the others on the first row.
+func (r Reader) validate(group *Group) []server.RowError {
+	if r.Validator == nil {
+		return nil
+	}
+	var violation *validation.Error
+	err := r.Validator.ValidateSchema("Receipt", group.Receipt)
+	fields := currency.Check(group.Receipt)
+	if errors.As(err, &violation) {
+		// The amounts are only checked against the currency once they are amounts
+		fields = violation.Fields
+	} else if err != nil {
+		return []server.RowError{{Row: group.Rows[0], Receipt: group.Key, Message: err.Error()}}
+	}
+	errs := make([]server.RowError, 0, len(fields))
+	for _, field := range fields {
+		row, name := group.Rows[0], strings.TrimPrefix(field.Field, "/")
+		if rest, ok := strings.CutPrefix(name, "items/"); ok {
+			index, itemField, _ := strings.Cut(rest, "/")
+			if i, err := strconv.Atoi(index); err == nil && i < len(group.Rows) {
+				row, name = group.Rows[i], itemField
+			}
+		}
+		errs = append(errs, server.RowError{Row: row, Receipt: group.Key, Field: name, Message: field.Message})
+	}
+	return errs
+}
+
+// WriteReport writes the errors of the rows left out as CSV with the columns row, receipt, field and message.
+func WriteReport(w io.Writer, errs []server.RowError) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"row", "receipt", "field", "message"}); err != nil {
+		return err
+	}
+	for _, e := range errs {
+		if err := csvWriter.Write([]string{strconv.Itoa(e.Row), e.Receipt, e.Field, e.Message}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
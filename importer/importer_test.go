@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"bytes"
+	"fetch-app/normalize"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// testValidator returns a validator for the spec the server is generated from.
+func testValidator(t *testing.T) *validation.Validator {
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		t.Fatalf("Error loading spec: %v", err)
+	}
+	validator, err := validation.New(swagger)
+	if err != nil {
+		t.Fatalf("Error creating validator: %v", err)
+	}
+	return validator
+}
+
+// TestRead tests that rows are grouped into receipts by their key, in the order the keys first appear.
+func TestRead(t *testing.T) {
+	file := "\ufeffReceipt,Store,purchaseDate,purchaseTime,total,shortDescription,price\n" +
+		"b,Target,2022-01-01,13:01,18.74,Mountain Dew 12PK,6.49\n" +
+		"a,Walgreens,2022-01-02,08:13,2.65,Pepsi - 12-oz,1.25\n" +
+		"b,Target,2022-01-01,13:01,18.74,Emils Cheese Pizza,12.25\n"
+	mapping, err := ParseMapping("retailer=store")
+	assert.NoError(t, err)
+
+	groups, rowErrors, err := Reader{Mapping: mapping, Validator: testValidator(t)}.Read(strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	if assert.Len(t, groups, 2) {
+		assert.Equal(t, "b", groups[0].Key)
+		assert.Equal(t, []int{2, 4}, groups[0].Rows)
+		assert.Equal(t, "Target", groups[0].Receipt.Retailer)
+		assert.Equal(t, "2022-01-01", groups[0].Receipt.PurchaseDate.String())
+		assert.Equal(t, []server.Item{
+			{ShortDescription: "Mountain Dew 12PK", Price: "6.49"},
+			{ShortDescription: "Emils Cheese Pizza", Price: "12.25"},
+		}, groups[0].Receipt.Items)
+		assert.Nil(t, groups[0].Receipt.Timezone)
+		assert.Equal(t, "a", groups[1].Key)
+	}
+}
+
+// TestReadRowErrors tests that a receipt with an invalid row is left out and each problem reported on its row.
+func TestReadRowErrors(t *testing.T) {
+	file := "receipt,retailer,purchaseDate,purchaseTime,total,shortDescription,price,timezone\n" +
+		"ok,Target,2022-01-01,13:01,1.00,Pepsi,1.00,\n" +
+		"date,Target,02/30/2022,13:01,1.00,Pepsi,1.00,\n" +
+		"price,Target,2022-01-01,13:01,2.00,Pepsi,1.00,\n" +
+		"price,Target,2022-01-01,13:01,2.00,Pepsi,1,\n" +
+		",Target,2022-01-01,13:01,1.00,Pepsi,1.00,\n" +
+		"zone,Target,2022-01-01,13:01,2.00,Pepsi,1.00,America/Chicago\n" +
+		"zone,Target,2022-01-01,13:01,2.00,Pepsi,1.00,UTC\n"
+
+	groups, rowErrors, err := Reader{Validator: testValidator(t)}.Read(strings.NewReader(file))
+	assert.NoError(t, err)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "ok", groups[0].Key)
+	}
+	assert.Equal(t, []server.RowError{
+		{Row: 3, Receipt: "date", Field: "purchaseDate", Message: "must be a date, such as 2022-03-20 or 03/20/2022"},
+		{Row: 5, Receipt: "price", Field: "price", Message: "must have 2 decimals in USD"},
+		{Row: 6, Field: "receipt", Message: "the row has no receipt key"},
+		{Row: 8, Receipt: "zone", Field: "timezone", Message: `"UTC" differs from "America/Chicago" on row 7`},
+	}, rowErrors)
+}
+
+// TestReadNormalizes tests that dates and times are read in the forms the HTTP API accepts, in the configured order,
+// and compared across the rows of a receipt once rewritten.
+func TestReadNormalizes(t *testing.T) {
+	file := "receipt,retailer,purchaseDate,purchaseTime,total,shortDescription,price\n" +
+		"a,Target,03/04/2022,2:33 PM,2.00,Pepsi,1.00\n" +
+		"a,Target,2022-04-03,14:33,2.00,Pepsi,1.00\n"
+
+	groups, rowErrors, err := Reader{Validator: testValidator(t), Normalizer: normalize.Normalizer{Order: normalize.DayFirst}}.Read(strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "2022-04-03", groups[0].Receipt.PurchaseDate.String())
+		assert.Equal(t, "14:33", groups[0].Receipt.PurchaseTime)
+	}
+}
+
+// TestReadHeader tests that a file whose header lacks a mapped column is refused as a whole.
+func TestReadHeader(t *testing.T) {
+	_, _, err := Reader{}.Read(strings.NewReader("receipt,retailer,purchaseDate,purchaseTime,shortDescription,price\n"))
+	assert.EqualError(t, err, `the header has no column "total" for total`)
+
+	_, _, err = Reader{}.Read(strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+// TestParseMapping tests that only known fields can be mapped, and that the others keep their defaults.
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping(" total = Amount ,price=Item Price")
+	assert.NoError(t, err)
+	assert.Equal(t, "Amount", mapping[FieldTotal])
+	assert.Equal(t, "Item Price", mapping[FieldPrice])
+	assert.Equal(t, "retailer", mapping[FieldRetailer])
+
+	_, err = ParseMapping("store=Store")
+	assert.Error(t, err)
+	_, err = ParseMapping("total")
+	assert.Error(t, err)
+}
+
+// TestWriteReport tests writing row errors as CSV.
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteReport(&buf, []server.RowError{{Row: 3, Receipt: "a", Field: "price", Message: "must be 1.00, not 1"}}))
+	assert.Equal(t, "row,receipt,field,message\n3,a,price,\"must be 1.00, not 1\"\n", buf.String())
+}
@@ -1,109 +1,354 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fetch-app/audit"
 	"fetch-app/calculation"
+	"fetch-app/currency"
+	"fetch-app/email"
+	"fetch-app/envelope"
+	"fetch-app/fraud"
+	"fetch-app/ids"
+	"fetch-app/normalize"
+	"fetch-app/receipts"
+	"fetch-app/retention"
+	"fetch-app/review"
+	"fetch-app/rpc"
+	"fetch-app/script"
 	"fetch-app/server"
+	"fetch-app/validation"
 	"fmt"
-	"github.com/google/uuid"
-	"github.com/labstack/echo"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"io"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embed the time zone database so receipt time zones resolve in minimal containers
 )
 
-// ReceiptStorage holds the receipts and provides a storage mechanism.
-type ReceiptStorage struct {
-	Receipts map[string]server.Receipt
+// ReceiptHandler adapts the receipt service to net/http, implementing the server routes.
+// Requests are decoded into the service's typed request objects, and its typed responses are written back.
+type ReceiptHandler struct {
+	server.ServerInterface
 }
 
-// NewReceiptStorage initializes and returns a new ReceiptStorage instance.
-func NewReceiptStorage() *ReceiptStorage {
-	return &ReceiptStorage{
-		Receipts: make(map[string]server.Receipt), // Initialize the map
-	}
+// NewReceiptHandler initializes and returns a ReceiptHandler serving the given service.
+// Bodies that cannot be decoded and the service's errors are rendered as problems.
+func NewReceiptHandler(service *receipts.Service) *ReceiptHandler {
+	return &ReceiptHandler{ServerInterface: server.NewStrictHandlerWithOptions(service, nil, server.StrictHTTPServerOptions{
+		RequestErrorHandlerFunc:  writeRequestError,
+		ResponseErrorHandlerFunc: writeError,
+	})}
 }
 
-// Global receiptStorage instance that will be used across the application
-var receiptStorage = NewReceiptStorage()
+// PostAdminReceiptsIdApprove approves a receipt without a decision too, since the spec makes the body optional but
+// the generated handler always decodes one.
+func (h *ReceiptHandler) PostAdminReceiptsIdApprove(w http.ResponseWriter, r *http.Request, id server.ReceiptId) {
+	if r.ContentLength == 0 {
+		r.Body = io.NopCloser(strings.NewReader("{}"))
+	}
+	h.ServerInterface.PostAdminReceiptsIdApprove(w, r, id)
+}
 
-// ReceiptHandler implements the server routes related to receipt processing and points retrieval.
-type ReceiptHandler struct{}
+// APIOptions configures the guards in front of the routes.
+type APIOptions struct {
+	// AdminToken is the bearer token the /admin routes require. Empty denies them all.
+	AdminToken string
+	// Validator checks requests against the spec. Nil skips the checks.
+	Validator *validation.Validator
+	// ValidateResponses also checks the responses, in development and tests.
+	ValidateResponses bool
+}
 
-// PostReceiptsProcess handles the POST request to process a new receipt.
-// It accepts a receipt in JSON format, stores it with a unique ID, and returns the ID in the response.
+// NewAPI builds the HTTP API of a service: the generated routes and their documentation on a ServeMux, guarded by
+// the admin token, date normalization and spec validation in that order. It is a plain http.Handler, so the same
+// guarded API can be served directly, mounted on another ServeMux or wrapped by Echo.
 //
 // Parameters:
 //
-//	ctx - The Echo context, which holds information about the request and response.
+//	service - The receipt service.
+//	options - The guards' configuration.
 //
 // Returns:
 //
-//	A JSON response containing the generated receipt ID if successful.
-//	If the JSON is invalid or the binding fails, it returns a Bad Request (400) error with a relevant message.
-func (h *ReceiptHandler) PostReceiptsProcess(ctx echo.Context) error {
-	var receipt server.PostReceiptsProcessJSONRequestBody
-
-	// Bind the incoming JSON request body to the receipt struct
-	if err := ctx.Bind(&receipt); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+//	The handler, or an error if the embedded spec cannot be loaded.
+func NewAPI(service *receipts.Service, options APIOptions) (http.Handler, error) {
+	mux := http.NewServeMux()
+	server.HandlerWithOptions(NewReceiptHandler(service), server.StdHTTPServerOptions{BaseRouter: mux, ErrorHandlerFunc: writeError})
+	if err := registerDocs(mux); err != nil {
+		return nil, err
 	}
-
-	// Print the received receipt for debugging
-	fmt.Printf("Received receipt: %+v\n", receipt)
-
-	// Generate a unique ID for the receipt and store it in the receiptStorage map
-	receiptID := uuid.New().String()
-	receiptStorage.Receipts[receiptID] = receipt
-
-	// Return a success response with the generated receipt ID
-	return ctx.JSON(http.StatusOK, map[string]string{"id": receiptID})
+	handler := routeProblems(mux)
+	if options.Validator != nil {
+		handler = validateAgainstSpec(options.Validator, options.ValidateResponses)(handler)
+	}
+	handler = normalizeDates(service.Normalizer)(handler)
+	return adminAuth(options.AdminToken)(handler), nil
 }
 
-// GetReceiptsIdPoints handles the GET request to retrieve points for a given receipt by ID.
-// It checks if the receipt exists in storage, calculates points based on receipt data, and returns the result.
-//
-// Parameters:
-//
-//	ctx - The Echo context, which holds information about the request and response.
-//	id  - The unique ID of the receipt whose points need to be retrieved.
+// main configures the receipt service from the environment variables listed in the README, exiting on any invalid
+// value, and opens its storage, encrypted under DATA_DIR if set. It serves the gRPC API on GRPC_ADDRESS, runs the
+// retention sweeper and, with encrypted storage, the master key rotation loop in the background, and serves the
+// HTTP API behind its guards on port 8080.
 //
 // Returns:
 //
-//	A JSON response containing the calculated points if the receipt exists.
-//	If the receipt does not exist, it returns a Not Found (404) error with a relevant message.
-func (h *ReceiptHandler) GetReceiptsIdPoints(ctx echo.Context, id string) error {
-	// Check if the receipt exists in the storage
-	receipt, exists := receiptStorage.Receipts[id]
-	if !exists {
-		// If the receipt does not exist, return a 404 error with a relevant message
-		return ctx.JSON(http.StatusNotFound, map[string]interface{}{
-			"message": fmt.Sprintf("Receipt with ID %s not found", id),
-		})
-	}
-
-	// If the receipt exists, calculate and return the points
-	points := calculation.CalculatePoints(receipt)
-
-	// Return the points in the response
-	return ctx.JSON(http.StatusOK, map[string]interface{}{
-		"points": points,
+//	None (this is the entry point of the program, which starts the HTTP and gRPC servers).
+func main() {
+	// Create the handler, configured from the environment
+	policy, err := fraud.ParsePolicy(os.Getenv("DUPLICATE_POLICY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	maxPoints := review.DefaultMaxPoints
+	if value := os.Getenv("REVIEW_POINTS_THRESHOLD"); value != "" {
+		if maxPoints, err = strconv.Atoi(value); err != nil {
+			log.Fatalf("invalid REVIEW_POINTS_THRESHOLD: %v", err)
+		}
+	}
+	retailerZones, err := calculation.ParseRetailerTimezones(os.Getenv("RETAILER_TIMEZONES"))
+	if err != nil {
+		log.Fatalf("invalid RETAILER_TIMEZONES: %v", err)
+	}
+	defaultZone := os.Getenv("DEFAULT_TIMEZONE")
+	if _, err := calculation.ParseLocation(defaultZone); err != nil {
+		log.Fatalf("invalid DEFAULT_TIMEZONE: %v", err)
+	}
+	currencyRules, err := calculation.ParseCurrencyRules(os.Getenv("CURRENCY_RULES"))
+	if err != nil {
+		log.Fatalf("invalid CURRENCY_RULES: %v", err)
+	}
+	var rates *currency.Rates
+	if path := os.Getenv("CURRENCY_RATES_FILE"); path != "" {
+		if rates, err = currency.LoadRates(path); err != nil {
+			log.Fatalf("invalid CURRENCY_RATES_FILE: %v", err)
+		}
+	}
+	lengthMode, err := calculation.ParseLengthMode(os.Getenv("SCORING_LENGTH"))
+	if err != nil {
+		log.Fatalf("invalid SCORING_LENGTH: %v", err)
+	}
+	idStrategy, err := ids.ParseStrategy(os.Getenv("ID_STRATEGY"))
+	if err != nil {
+		log.Fatalf("invalid ID_STRATEGY: %v", err)
+	}
+	itemRetention, err := retention.ParseDays(os.Getenv("RETENTION_ITEM_DAYS"))
+	if err != nil {
+		log.Fatalf("invalid RETENTION_ITEM_DAYS: %v", err)
+	}
+	recordRetention, err := retention.ParseDays(os.Getenv("RETENTION_RECORD_DAYS"))
+	if err != nil {
+		log.Fatalf("invalid RETENTION_RECORD_DAYS: %v", err)
+	}
+	sweepInterval := time.Hour
+	if value := os.Getenv("RETENTION_SWEEP_INTERVAL"); value != "" {
+		if sweepInterval, err = time.ParseDuration(value); err != nil || sweepInterval <= 0 {
+			log.Fatalf("invalid RETENTION_SWEEP_INTERVAL: %q", value)
+		}
+	}
+	keyRotationInterval := time.Hour
+	if value := os.Getenv("KEY_ROTATION_INTERVAL"); value != "" {
+		if keyRotationInterval, err = time.ParseDuration(value); err != nil || keyRotationInterval <= 0 {
+			log.Fatalf("invalid KEY_ROTATION_INTERVAL: %q", value)
+		}
+	}
+	idempotencyTTL := receipts.DefaultIdempotencyTTL
+	if value := os.Getenv("IDEMPOTENCY_KEY_TTL"); value != "" {
+		if idempotencyTTL, err = time.ParseDuration(value); err != nil || idempotencyTTL <= 0 {
+			log.Fatalf("invalid IDEMPOTENCY_KEY_TTL: %q", value)
+		}
+	}
+	var templates []email.Template
+	if path := os.Getenv("EMAIL_TEMPLATES_FILE"); path != "" {
+		if templates, err = email.LoadTemplates(path); err != nil {
+			log.Fatalf("invalid EMAIL_TEMPLATES_FILE: %v", err)
+		}
+	}
+	maxImageSize := int64(receipts.DefaultMaxImageSize)
+	if value := os.Getenv("IMAGE_MAX_BYTES"); value != "" {
+		if maxImageSize, err = strconv.ParseInt(value, 10, 64); err != nil || maxImageSize <= 0 {
+			log.Fatalf("invalid IMAGE_MAX_BYTES: %q", value)
+		}
+	}
+	var limits script.Limits
+	if value := os.Getenv("CUSTOM_RULES_MAX_STEPS"); value != "" {
+		if limits.MaxSteps, err = strconv.ParseUint(value, 10, 64); err != nil || limits.MaxSteps == 0 {
+			log.Fatalf("invalid CUSTOM_RULES_MAX_STEPS: %q", value)
+		}
+	}
+	if value := os.Getenv("CUSTOM_RULES_TIMEOUT"); value != "" {
+		if limits.Timeout, err = time.ParseDuration(value); err != nil || limits.Timeout <= 0 {
+			log.Fatalf("invalid CUSTOM_RULES_TIMEOUT: %q", value)
+		}
+	}
+	var scripts []*script.Rule
+	if dir := os.Getenv("CUSTOM_RULES_DIR"); dir != "" {
+		if scripts, err = script.LoadDir(dir, limits); err != nil {
+			log.Fatalf("invalid CUSTOM_RULES_DIR: %v", err)
+		}
+	}
+	dateOrder, err := normalize.ParseOrder(os.Getenv("DATE_ORDER"))
+	if err != nil {
+		log.Fatalf("invalid DATE_ORDER: %v", err)
+	}
+	normalizer := normalize.Normalizer{
+		Order:       dateOrder,
+		DateLayouts: normalize.ParseLayouts(os.Getenv("DATE_LAYOUTS")),
+		TimeLayouts: normalize.ParseLayouts(os.Getenv("TIME_LAYOUTS")),
+	}
+	storage, backend, images, err := openStorage()
+	if err != nil {
+		log.Fatal(err)
+	}
+	storage.IdempotencyTTL = idempotencyTTL
+	service := &receipts.Service{
+		Storage:         storage,
+		DuplicatePolicy: policy,
+		ReviewRules:     review.Rules{MaxPoints: maxPoints},
+		Timezones:       calculation.TimezoneDefaults{Retailers: retailerZones, Default: defaultZone},
+		Normalizer:      normalizer,
+		Calculator: calculation.Calculator{
+			Text: calculation.TextOptions{
+				Unicode:      os.Getenv("SCORING_UNICODE") == "true",
+				Length:       lengthMode,
+				NormalizeNFC: os.Getenv("SCORING_NFC") == "true",
+			},
+			Currencies: currencyRules,
+			Scripts:    scripts,
+		},
+		Rates:        rates,
+		IDs:          idStrategy,
+		Retention:    retention.Policy{ItemDetails: itemRetention, Records: recordRetention},
+		Email:        email.Extractor{Templates: templates},
+		Images:       images,
+		MaxImageSize: maxImageSize,
+	}
+
+	// Record every change to the receipts in a hash-chained log, refusing to extend one that was tampered with
+	if path := os.Getenv("AUDIT_LOG_FILE"); path != "" {
+		auditLog, file, err := audit.Open(path)
+		if err != nil {
+			log.Fatalf("invalid AUDIT_LOG_FILE: %v", err)
+		}
+		defer file.Close()
+		service.Audit = auditLog
+	}
+
+	// Key the hashes the audit log and purge log identify principals by, so they cannot be reversed by guessing
+	if path := os.Getenv("PRINCIPAL_HASH_KEY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("invalid PRINCIPAL_HASH_KEY_FILE: %v", err)
+		}
+		if service.PrincipalKey, err = retention.ParsePrincipalKey(string(data)); err != nil {
+			log.Fatalf("invalid PRINCIPAL_HASH_KEY_FILE: %v", err)
+		}
+	} else {
+		log.Print("PRINCIPAL_HASH_KEY_FILE is not set, so principals are hashed under a random key that changes on restart")
+		service.PrincipalKey = make([]byte, retention.MinPrincipalKeySize)
+		if _, err := rand.Read(service.PrincipalKey); err != nil {
+			log.Fatalf("generating principal hash key: %v", err)
+		}
+	}
+
+	// Check requests against the spec the server was generated from, and in development and tests the responses too
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		log.Fatalf("loading embedded OpenAPI spec: %v", err)
+	}
+	validator, err := validation.New(swagger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	service.Validator = validator
+	appEnv := os.Getenv("APP_ENV")
+
+	// Guard the admin routes and build the server routes along with their documentation
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		log.Print("ADMIN_TOKEN is not set, so the /admin routes are disabled")
+	}
+	api, err := NewAPI(service, APIOptions{
+		AdminToken:        adminToken,
+		Validator:         validator,
+		ValidateResponses: appEnv == "development" || appEnv == "test",
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Serve the gRPC API alongside, sharing the service and therefore the store and scoring
+	grpcAddress := os.Getenv("GRPC_ADDRESS")
+	if grpcAddress == "" {
+		grpcAddress = ":9090"
+	}
+	listener, err := net.Listen("tcp", grpcAddress)
+	if err != nil {
+		log.Fatalf("listening for gRPC: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	rpc.RegisterReceiptsServer(grpcServer, rpc.NewServer(service, validator))
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("serving gRPC: %v", err)
+		}
+	}()
+
+	// Purge the receipt data the retention policy no longer allows keeping, and move stored records off retired keys
+	go service.RunSweeper(context.Background(), sweepInterval)
+	if backend != nil {
+		go backend.RunRotation(context.Background(), keyRotationInterval)
+	}
+
+	// Start the Echo server on port 8080, handing every request to the API
+	e := echo.New()
+	e.Any("/*", echo.WrapHandler(api))
+	e.Start(":8080")
 }
 
-// main sets up the Echo server, registers the routes, and starts the application.
-// It initializes the ReceiptHandler, sets up the routes, and begins listening on port 8080.
+// openStorage creates the receipt storage and the store of their images. With DATA_DIR set, the receipts, images,
+// ledger, purge log and idempotency keys are persisted there encrypted under the master keys in ENCRYPTION_KEY_FILE,
+// and what was stored before the restart is restored.
 //
 // Returns:
 //
-//	None (this is the entry point of the program, which starts the HTTP server).
-func main() {
-	// Create a new Echo instance
-	e := echo.New()
-
-	// Create the handler
-	handler := &ReceiptHandler{}
-
-	// Register the server routes
-	server.RegisterHandlers(e, handler)
+//	The storage, its backend if it has one, and the image store, or an error if the configuration is invalid or
+//	the stored receipts cannot be restored.
+func openStorage() (*receipts.Storage, *receipts.EncryptedBackend, receipts.BlobStore, error) {
+	storage := receipts.NewStorage()
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		return storage, nil, receipts.NewMemoryBlobStore(), nil
+	}
 
-	// Start the Echo server on port 8080
-	e.Start(":8080")
+	keyFile := os.Getenv("ENCRYPTION_KEY_FILE")
+	if keyFile == "" {
+		return nil, nil, nil, errors.New("ENCRYPTION_KEY_FILE is required with DATA_DIR, since receipts are only stored encrypted")
+	}
+	keys, err := envelope.LoadKeyring(keyFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid ENCRYPTION_KEY_FILE: %w", err)
+	}
+	backend, err := receipts.NewEncryptedBackend(dataDir, keys)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	storage.Backend = backend
+	if err := storage.Restore(); err != nil {
+		return nil, nil, nil, fmt.Errorf("restoring receipts: %w", err)
+	}
+	files, err := receipts.NewFileBlobStore(filepath.Join(dataDir, "images"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	backend.Images = &receipts.SealedBlobStore{Store: files, Keys: keys}
+	return storage, backend, backend.Images, nil
 }
@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fetch-app/calculation"
+	"fetch-app/fraud"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/review"
 	"fetch-app/server"
 	"fmt"
 	"github.com/google/uuid"
-	"github.com/labstack/echo"
+	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime/types"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -19,7 +23,8 @@ import (
 // TestPostReceiptsProcess tests the PostReceiptsProcess handler.
 func TestPostReceiptsProcess(t *testing.T) {
 	e := echo.New()
-	handler := &ReceiptHandler{}
+	service := receipts.NewService(receipts.NewStorage())
+	handler := NewReceiptHandler(service)
 
 	// Create a test request with a valid receipt
 	receipt := server.PostReceiptsProcessJSONRequestBody{
@@ -44,7 +49,10 @@ func TestPostReceiptsProcess(t *testing.T) {
 	rec := httptest.NewRecorder()
 
 	// Call the handler
-	e.POST("/receipts/process", handler.PostReceiptsProcess)
+	e.POST("/receipts/process", func(c echo.Context) error {
+		handler.PostReceiptsProcess(c.Response(), c.Request(), server.PostReceiptsProcessParams{})
+		return nil
+	})
 	e.ServeHTTP(rec, req)
 
 	// Check the response status
@@ -58,14 +66,15 @@ func TestPostReceiptsProcess(t *testing.T) {
 
 	// Verify the receipt was added to the storage
 	receiptID := response["id"]
-	_, exists := receiptStorage.Receipts[receiptID]
+	_, exists := service.Storage.Get(receiptID)
 	assert.True(t, exists)
 }
 
 // TestGetReceiptsIdPoints tests the GetReceiptsIdPoints handler.
 func TestGetReceiptsIdPoints(t *testing.T) {
 	e := echo.New()
-	handler := &ReceiptHandler{}
+	service := receipts.NewService(receipts.NewStorage())
+	handler := NewReceiptHandler(service)
 
 	// First, create a receipt and store it manually for testing
 	receipt := server.PostReceiptsProcessJSONRequestBody{
@@ -79,7 +88,7 @@ func TestGetReceiptsIdPoints(t *testing.T) {
 		Total: "9.00",
 	}
 	receiptID := uuid.New().String() // Generate a new receipt ID
-	receiptStorage.Receipts[receiptID] = receipt
+	service.Storage.Put(&receipts.Record{ID: receiptID, Receipt: receipt, Status: review.StatusApproved})
 
 	// Create a test request to retrieve points for the stored receipt
 	req := httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID+"/points", nil)
@@ -87,7 +96,8 @@ func TestGetReceiptsIdPoints(t *testing.T) {
 
 	// Call the handler
 	e.GET("/receipts/:id/points", func(c echo.Context) error {
-		return handler.GetReceiptsIdPoints(c, receiptID) // Call the handler with the ID
+		handler.GetReceiptsIdPoints(c.Response(), c.Request(), receiptID) // Call the handler with the ID
+		return nil
 	})
 	e.ServeHTTP(rec, req)
 
@@ -110,7 +120,7 @@ func TestGetReceiptsIdPoints(t *testing.T) {
 // TestGetReceiptsIdPointsNotFound tests the case where the receipt does not exist.
 func TestGetReceiptsIdPointsNotFound(t *testing.T) {
 	e := echo.New()
-	handler := &ReceiptHandler{}
+	handler := NewReceiptHandler(receipts.NewService(receipts.NewStorage()))
 
 	// Create a test request to retrieve points for a non-existing receipt
 	nonExistentID := uuid.New().String() // Random ID for testing
@@ -119,17 +129,191 @@ func TestGetReceiptsIdPointsNotFound(t *testing.T) {
 
 	// Call the handler
 	e.GET("/receipts/:id/points", func(c echo.Context) error {
-		return handler.GetReceiptsIdPoints(c, nonExistentID) // Call the handler with the ID
+		handler.GetReceiptsIdPoints(c.Response(), c.Request(), nonExistentID) // Call the handler with the ID
+		return nil
 	})
 	e.ServeHTTP(rec, req)
 
 	// Check the response status
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 
-	// Verify the message in the response
-	var response map[string]interface{}
+	// Verify the problem in the response
+	assert.Equal(t, problem.ContentType, rec.Header().Get(echo.HeaderContentType))
+	var response problem.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response, "message")
-	assert.Equal(t, fmt.Sprintf("Receipt with ID %s not found", nonExistentID), response["message"])
+	assert.Equal(t, problem.CodeReceiptNotFound, response.Code)
+	assert.Equal(t, fmt.Sprintf("Receipt with ID %s not found", nonExistentID), response.Detail)
+}
+
+// postReceipt submits a receipt through the handler and returns the recorded response.
+func postReceipt(t *testing.T, handler *ReceiptHandler, receipt server.Receipt) *httptest.ResponseRecorder {
+	e := echo.New()
+	reqBody, err := json.Marshal(receipt)
+	if err != nil {
+		t.Fatalf("Error marshalling request body: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/receipts/process", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.POST("/receipts/process", func(c echo.Context) error {
+		handler.PostReceiptsProcess(c.Response(), c.Request(), server.PostReceiptsProcessParams{})
+		return nil
+	})
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// duplicateTestReceipt returns a receipt used only by the duplicate detection tests.
+func duplicateTestReceipt() server.Receipt {
+	return server.Receipt{
+		Retailer:     "Target",
+		PurchaseDate: types.Date{Time: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "13:01",
+		Items: []server.Item{
+			{ShortDescription: "Mountain Dew 12PK", Price: "6.49"},
+			{ShortDescription: "Emils Cheese Pizza", Price: "12.25"},
+		},
+		Total: "18.74",
+	}
+}
+
+// TestPostReceiptsProcessDuplicatePolicies tests how each duplicate policy treats a resubmitted receipt.
+func TestPostReceiptsProcessDuplicatePolicies(t *testing.T) {
+	tests := []struct {
+		policy         fraud.Policy
+		expectedStatus int
+		zeroPoints     bool
+		expectedState  review.Status
+	}{
+		{fraud.PolicyFlag, http.StatusOK, false, review.StatusPendingReview},
+		{fraud.PolicyZeroPoints, http.StatusOK, true, review.StatusApproved},
+		{fraud.PolicyReject, http.StatusConflict, false, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			service := receipts.NewService(receipts.NewStorage())
+			service.DuplicatePolicy = test.policy
+			handler := NewReceiptHandler(service)
+
+			// The first submission is always accepted as an original
+			first := postReceipt(t, handler, duplicateTestReceipt())
+			assert.Equal(t, http.StatusOK, first.Code)
+			var original map[string]string
+			assert.NoError(t, json.Unmarshal(first.Body.Bytes(), &original))
+
+			// Resubmit the same receipt with cosmetic differences and the items reordered
+			duplicate := duplicateTestReceipt()
+			duplicate.Retailer = "TARGET "
+			duplicate.Items[0], duplicate.Items[1] = duplicate.Items[1], duplicate.Items[0]
+			second := postReceipt(t, handler, duplicate)
+			assert.Equal(t, test.expectedStatus, second.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, json.Unmarshal(second.Body.Bytes(), &response))
+			if test.expectedStatus == http.StatusConflict {
+				assert.Equal(t, []interface{}{original["id"]}, response["duplicateOf"])
+				assert.Len(t, service.Storage.Receipts, 1)
+				return
+			}
+
+			record, exists := service.Storage.Get(response["id"].(string))
+			assert.True(t, exists)
+			assert.Equal(t, []string{original["id"]}, record.DuplicateOf)
+			assert.Equal(t, test.zeroPoints, record.ZeroPoints)
+			assert.Equal(t, test.expectedState, record.Status)
+		})
+	}
+}
+
+// TestGetReceiptsIdZeroPoints tests that a duplicate under the zero points policy earns no points.
+func TestGetReceiptsIdZeroPoints(t *testing.T) {
+	e := echo.New()
+	service := receipts.NewService(receipts.NewStorage())
+	handler := NewReceiptHandler(service)
+
+	receiptID := uuid.New().String()
+	service.Storage.Put(&receipts.Record{
+		ID:         receiptID,
+		Receipt:    duplicateTestReceipt(),
+		ZeroPoints: true,
+		Status:     review.StatusApproved,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID+"/points", nil)
+	rec := httptest.NewRecorder()
+	e.GET("/receipts/:id/points", func(c echo.Context) error {
+		handler.GetReceiptsIdPoints(c.Response(), c.Request(), receiptID)
+		return nil
+	})
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"points": 0}`, rec.Body.String())
+}
+
+// TestGetReceiptsId tests that the stored record, including duplicate matches, is returned.
+func TestGetReceiptsId(t *testing.T) {
+	e := echo.New()
+	service := receipts.NewService(receipts.NewStorage())
+	handler := NewReceiptHandler(service)
+
+	receiptID := uuid.New().String()
+	originalID := uuid.New().String()
+	service.Storage.Put(&receipts.Record{
+		ID:          receiptID,
+		Receipt:     duplicateTestReceipt(),
+		Fingerprint: fraud.Fingerprint(duplicateTestReceipt()),
+		DuplicateOf: []string{originalID},
+		Status:      review.StatusPendingReview,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID, nil)
+	rec := httptest.NewRecorder()
+	e.GET("/receipts/:id", func(c echo.Context) error {
+		handler.GetReceiptsId(c.Response(), c.Request(), receiptID)
+		return nil
+	})
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, receiptID, response["id"])
+	assert.Equal(t, []interface{}{originalID}, response["duplicateOf"])
+	assert.Equal(t, "pending_review", response["status"])
+}
+
+// TestPostReceiptsProcessTimezone tests that receipts inherit a time zone and record their purchase instant.
+func TestPostReceiptsProcessTimezone(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	service.Timezones = calculation.TimezoneDefaults{
+		Retailers: map[string]string{"walgreens": "America/Chicago"},
+		Default:   "Europe/London",
+	}
+	handler := NewReceiptHandler(service)
+
+	receipt := server.Receipt{
+		Retailer:     "Walgreens",
+		PurchaseDate: types.Date{Time: time.Date(2022, time.July, 4, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "15:10",
+		Items:        []server.Item{{ShortDescription: "Pepsi - 12-oz", Price: "1.25"}},
+		Total:        "1.25",
+	}
+	rec := postReceipt(t, handler, receipt)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]string
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	record, _ := service.Storage.Get(response["id"])
+	assert.Equal(t, "America/Chicago", *record.Receipt.Timezone)
+	assert.Equal(t, "2022-07-04T20:10:00Z", record.PurchasedAt.UTC().Format(time.RFC3339))
+
+	// An explicit but unknown time zone is rejected
+	zone := "Atlantis/Capital"
+	receipt.Timezone = &zone
+	rec = postReceipt(t, handler, receipt)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fetch-app/normalize"
+	"fetch-app/problem"
+	"fetch-app/validation"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// bufferedResponse holds back a handler's response until it has been validated.
+// Headers are set directly on the wrapped writer.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status instead of sending it.
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+// Write records the body instead of sending it.
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// validateAgainstSpec returns middleware that rejects requests violating the API contract with a Bad Request (400)
+// problem before they reach the routes.
+//
+// Parameters:
+//
+//	validator         - The validator for the embedded OpenAPI document.
+//	validateResponses - Whether to also check every response the handlers write. A response violating the contract
+//	                    is replaced with an Internal Server Error (500) describing the violation, so drift between
+//	                    the handlers and the spec fails loudly. It buffers every response, so it is meant for
+//	                    development and tests.
+//
+// Returns:
+//
+//	The middleware.
+func validateAgainstSpec(validator *validation.Validator, validateResponses bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, err := validator.ValidateRequest(r)
+			if err != nil {
+				writeError(w, r, contractProblem(http.StatusBadRequest, problem.CodeValidationFailed, err))
+				return
+			}
+			if route == nil || !validateResponses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Hold back the response until it has been checked
+			buffered := &bufferedResponse{ResponseWriter: w}
+			next.ServeHTTP(buffered, r)
+			if err := validator.ValidateResponse(route, buffered.status, w.Header(), buffered.body.Bytes()); err != nil {
+				writeError(w, r, contractProblem(http.StatusInternalServerError, problem.CodeResponseInvalid, err))
+				return
+			}
+			w.WriteHeader(buffered.status)
+			w.Write(buffered.body.Bytes())
+		})
+	}
+}
+
+// contractProblem converts a violation of the API contract into a problem listing each invalid field.
+func contractProblem(status int, code problem.Code, err error) *problem.Problem {
+	p := problem.New(status, code, err.Error())
+	var violation *validation.Error
+	if errors.As(err, &violation) {
+		p.Detail = violation.Message
+		p.Errors = violation.Fields
+	}
+	return p
+}
+
+// normalizeDates returns middleware that rewrites the purchase dates and times of submitted and corrected receipts
+// into their canonical forms before validateAgainstSpec checks them, so clients can send "03/20/2022" or "2:33 PM".
+// Numeric dates are read in the order of the request's Content-Language, or the normalizer's without one. The
+// changes are passed to the service in the request context, which reports those made to submissions.
+func normalizeDates(normalizer normalize.Normalizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !submitsReceipt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request body could not be read"))
+				return
+			}
+			order := normalize.OrderOf(r.Header.Get("Content-Language"), normalizer.Order)
+			body, changes := normalizer.Body(body, order)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			if len(changes) > 0 {
+				r = r.WithContext(normalize.NewContext(r.Context(), changes))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// submitsReceipt reports whether a request carries a receipt or a merge patch of one as JSON: a submission or a
+// correction.
+func submitsReceipt(req *http.Request) bool {
+	contentType := req.Header.Get("Content-Type")
+	switch {
+	case req.Method == http.MethodPost && req.URL.Path == "/receipts/process":
+		return strings.HasPrefix(contentType, "application/json")
+	case req.Method == http.MethodPut || req.Method == http.MethodPatch:
+		id, found := strings.CutPrefix(req.URL.Path, "/receipts/")
+		return found && id != "" && !strings.Contains(id, "/") &&
+			(strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "application/merge-patch+json"))
+	}
+	return false
+}
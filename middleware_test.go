@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveValidated serves the request with the API behind request and response validation, as an admin unless the
+// request has an Authorization header.
+func serveValidated(t *testing.T, service *receipts.Service, req *http.Request) *httptest.ResponseRecorder {
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		t.Fatalf("Error loading spec: %v", err)
+	}
+	validator, err := validation.New(swagger)
+	if err != nil {
+		t.Fatalf("Error creating validator: %v", err)
+	}
+
+	api, err := NewAPI(service, APIOptions{AdminToken: testAdminToken, Validator: validator, ValidateResponses: true})
+	if err != nil {
+		t.Fatalf("Error creating API: %v", err)
+	}
+	if req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	}
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, req)
+	return rec
+}
+
+// jsonRequest builds a request with a JSON body.
+func jsonRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// asPrincipal sends the request under the principal's X-Principal-Id.
+func asPrincipal(req *http.Request, principal string) *http.Request {
+	req.Header.Set("X-Principal-Id", principal)
+	return req
+}
+
+// correctionRequest creates a request correcting a receipt, with a JSON body for PUT and a merge patch for PATCH.
+func correctionRequest(method, target, etag, body string) *http.Request {
+	req := jsonRequest(method, target, body)
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+	req.Header.Set("If-Match", etag)
+	return req
+}
+
+// textRequest creates a POST request with a plain text body.
+func textRequest(target, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/plain")
+	return req
+}
+
+// emailRequest creates a POST request submitting an email message.
+func emailRequest(message string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/receipts/process/email", bytes.NewBufferString(message))
+	req.Header.Set("Content-Type", "message/rfc822")
+	return req
+}
+
+// imageRequest creates a PUT request attaching an image.
+func imageRequest(target string, content []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, target, bytes.NewReader(content))
+	req.Header.Set("Content-Type", "image/png")
+	return req
+}
+
+// pngImage returns a small PNG image.
+func pngImage(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("Error encoding image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// uploadRequest creates a multipart request uploading a file as the "file" part, with the other fields.
+func uploadRequest(target, file string, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "receipts.csv")
+	part.Write([]byte(file))
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// TestValidationRejectsInvalidRequests tests that requests violating the spec never reach the handler.
+func TestValidationRejectsInvalidRequests(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	body := `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "25:00",
+	          "items": [{"shortDescription": "Pepsi", "price": "1.5"}], "total": "1.00"}`
+
+	rec := serveValidated(t, service, jsonRequest(http.MethodPost, "/receipts/process", body))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, service.Storage.Receipts)
+
+	var response problem.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, problem.CodeValidationFailed, response.Code)
+	assert.Equal(t, "The request does not match the API contract", response.Detail)
+	fields := make([]string, 0, len(response.Errors))
+	for _, field := range response.Errors {
+		fields = append(fields, field.Field)
+	}
+	assert.ElementsMatch(t, []string{"/purchaseTime", "/items/0/price"}, fields)
+}
+
+// TestResponsesMatchSpec runs the API flows with response validation, so any drift between the handlers and the
+// spec fails with an Internal Server Error (500).
+func TestResponsesMatchSpec(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	body := `{"retailer": "Target", "purchaseDate": "2022-01-01", "purchaseTime": "13:01", "timezone": "America/Chicago",
+	          "items": [{"shortDescription": "Mountain Dew 12PK", "price": "6.49"}], "total": "6.49"}`
+
+	rec := serveValidated(t, service, jsonRequest(http.MethodPost, "/receipts/process", body))
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var processed server.ProcessedReceipt
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &processed))
+
+	// Submitting it again flags it for review
+	rec = serveValidated(t, service, jsonRequest(http.MethodPost, "/receipts/process", body))
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var duplicate server.ProcessedReceipt
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &duplicate))
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		expected int
+	}{
+		{"record", httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id, nil), http.StatusOK},
+		{"exchange rate", jsonRequest(http.MethodPost, "/receipts/process", strings.Replace(body, `"total"`, `"exchangeRate": "0.01", "total"`, 1)), http.StatusBadRequest},
+		{"points", httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/points", nil), http.StatusOK},
+		{"pending points", httptest.NewRequest(http.MethodGet, "/receipts/"+duplicate.Id+"/points", nil), http.StatusAccepted},
+		{"not found", httptest.NewRequest(http.MethodGet, "/receipts/"+uuid.New().String(), nil), http.StatusNotFound},
+		{"review queue", httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil), http.StatusOK},
+		{"missing reason", jsonRequest(http.MethodPost, "/admin/receipts/"+duplicate.Id+"/reject", `{}`), http.StatusBadRequest},
+		{"reject", jsonRequest(http.MethodPost, "/admin/receipts/"+duplicate.Id+"/reject", `{"reason": "Duplicate"}`), http.StatusOK},
+		{"rejected points", httptest.NewRequest(http.MethodGet, "/receipts/"+duplicate.Id+"/points", nil), http.StatusConflict},
+		{"not pending", httptest.NewRequest(http.MethodPost, "/admin/receipts/"+processed.Id+"/approve", nil), http.StatusConflict},
+		{"breakdown", httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/breakdown", nil), http.StatusOK},
+		{"receipt page", asPrincipal(httptest.NewRequest(http.MethodGet, "/receipts?limit=1", nil), "user-1"), http.StatusOK},
+		{"anonymous receipt page", httptest.NewRequest(http.MethodGet, "/receipts?limit=1", nil), http.StatusBadRequest},
+		{"invalid ID", httptest.NewRequest(http.MethodGet, "/receipts/not-an-id", nil), http.StatusBadRequest},
+		{"ledger", httptest.NewRequest(http.MethodGet, "/admin/ledger", nil), http.StatusOK},
+		{"erasure", httptest.NewRequest(http.MethodDelete, "/admin/principals/user-1/receipts", nil), http.StatusOK},
+		{"purges", httptest.NewRequest(http.MethodGet, "/admin/purges", nil), http.StatusOK},
+		{"import", uploadRequest("/receipts/import", "receipt,store,purchaseDate,purchaseTime,total,shortDescription,price\n"+
+			"1,Walgreens,2022-01-02,08:13,1.25,Pepsi,1.25\n1,Walgreens,2022-01-02,08:13,1.00,Dasani,1.40\n",
+			map[string]string{"mapping": "retailer=store"}), http.StatusOK},
+		{"text", textRequest("/receipts/process/text", "Corner Shop\n2022-01-03 10:15\nTea 2.50\nTOTAL 2.50\n"), http.StatusOK},
+		{"unreadable text", textRequest("/receipts/process/text", "Thank you!"), http.StatusUnprocessableEntity},
+		{"email", emailRequest("From: Corner Shop <shop@example.com>\r\nDate: Mon, 03 Jan 2022 10:15:00 +0000\r\n\r\nTea 2.50\r\nTOTAL 2.50\r\n"), http.StatusOK},
+		{"invalid email", emailRequest("Tea 2.50"), http.StatusBadRequest},
+		{"attach image", imageRequest("/receipts/"+processed.Id+"/image", pngImage(t)), http.StatusOK},
+		{"image", httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/image", nil), http.StatusOK},
+		{"missing image", httptest.NewRequest(http.MethodGet, "/receipts/"+duplicate.Id+"/image", nil), http.StatusNotFound},
+		{"unsupported image", imageRequest("/receipts/"+processed.Id+"/image", []byte("GIF87")), http.StatusUnsupportedMediaType},
+		{"unmapped import", uploadRequest("/receipts/import", "receipt\n", nil), http.StatusBadRequest},
+		{"correction without If-Match", jsonRequest(http.MethodPut, "/receipts/"+processed.Id, body), http.StatusPreconditionRequired},
+		{"correction", correctionRequest(http.MethodPut, "/receipts/"+processed.Id, `"1"`, body), http.StatusOK},
+		{"stale correction", correctionRequest(http.MethodPut, "/receipts/"+processed.Id, `"1"`, body), http.StatusPreconditionFailed},
+		{"patch", correctionRequest(http.MethodPatch, "/receipts/"+processed.Id, `"2"`, `{"purchaseTime": "2:30 PM"}`), http.StatusOK},
+		{"invalid patch", correctionRequest(http.MethodPatch, "/receipts/"+processed.Id, `"3"`, `{"total": 19}`), http.StatusBadRequest},
+		{"rejected correction", correctionRequest(http.MethodPut, "/receipts/"+duplicate.Id, "*", body), http.StatusConflict},
+		{"versions", httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/versions", nil), http.StatusOK},
+		{"csv export", httptest.NewRequest(http.MethodGet, "/admin/receipts/export?rows=item", nil), http.StatusOK},
+		{"ndjson export", httptest.NewRequest(http.MethodGet, "/admin/receipts/export?format=ndjson", nil), http.StatusOK},
+		{"backwards export", httptest.NewRequest(http.MethodGet, "/admin/receipts/export?from=2022-02-01&to=2022-01-01", nil), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serveValidated(t, service, test.req)
+			assert.Equal(t, test.expected, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+// TestResponseDriftFails tests that a handler response violating the spec is replaced with an error.
+func TestResponseDriftFails(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	receiptID := uuid.New().String()
+	// A record missing its required fingerprint and submission time has drifted from the spec
+	service.Storage.Put(&receipts.Record{ID: receiptID, Receipt: duplicateTestReceipt(), Status: "archived"})
+
+	rec := serveValidated(t, service, httptest.NewRequest(http.MethodGet, "/receipts/"+receiptID, nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "The response does not match the API contract")
+	assert.Contains(t, rec.Body.String(), `"field":"/status"`)
+}
+
+// TestNormalizeDates tests that dates and times in other formats are stored canonical and reported, with numeric
+// dates read in the order of the request's locale.
+func TestNormalizeDates(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	body := `{"retailer": "Target", "purchaseDate": "03/04/2022", "purchaseTime": "2:33 PM",
+	          "items": [{"shortDescription": "Pepsi", "price": "1.50"}], "total": "1.50"}`
+
+	tests := []struct {
+		locale string
+		date   string
+	}{
+		{"", "2022-03-04"},
+		{"en-US", "2022-03-04"},
+		{"en-GB", "2022-04-03"},
+		{"de", "2022-04-03"},
+	}
+	for _, test := range tests {
+		t.Run(test.locale, func(t *testing.T) {
+			req := jsonRequest(http.MethodPost, "/receipts/process", body)
+			req.Header.Set("Content-Language", test.locale)
+			rec := serveValidated(t, service, req)
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var processed server.ProcessedReceipt
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &processed))
+			assert.Equal(t, &[]server.Normalization{
+				{Field: "/purchaseDate", Original: "03/04/2022", Value: test.date},
+				{Field: "/purchaseTime", Original: "2:33 PM", Value: "14:33"},
+			}, processed.Normalized)
+			stored := service.Storage.Receipts[processed.Id].Receipt
+			assert.Equal(t, test.date, stored.PurchaseDate.Format(time.DateOnly))
+			assert.Equal(t, "14:33", stored.PurchaseTime)
+		})
+	}
+
+	// Canonical receipts report nothing, and values that cannot be read are still rejected
+	canonical := `{"retailer": "Target", "purchaseDate": "2022-03-04", "purchaseTime": "14:33",
+	               "items": [{"shortDescription": "Pepsi", "price": "1.50"}], "total": "1.50"}`
+	rec := serveValidated(t, service, jsonRequest(http.MethodPost, "/receipts/process", canonical))
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "normalized")
+
+	invalid := `{"retailer": "Target", "purchaseDate": "13/13/2022", "purchaseTime": "teatime",
+	             "items": [{"shortDescription": "Pepsi", "price": "1.50"}], "total": "1.50"}`
+	rec = serveValidated(t, service, jsonRequest(http.MethodPost, "/receipts/process", invalid))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,223 @@
+// Package normalize rewrites the purchase dates and times clients send in other common formats, such as "03/20/2022"
+// or "2:33 PM", into the canonical forms of the Receipt schema, reporting each value it rewrote.
+package normalize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fetch-app/server"
+	"fmt"
+	"golang.org/x/text/language"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DateLayout is the canonical form of purchase dates.
+	DateLayout = time.DateOnly
+	// TimeLayout is the canonical form of purchase times.
+	TimeLayout = "15:04"
+)
+
+// Order is how a numeric date such as 03/04/2022 is read: as March 4 or as April 3.
+type Order int
+
+const (
+	// MonthFirst reads 03/04/2022 as March 4, as in the United States.
+	MonthFirst Order = iota
+	// DayFirst reads 03/04/2022 as April 3, as in most of the world.
+	DayFirst
+)
+
+// ParseOrder parses "month_first" or "day_first". Empty is MonthFirst.
+func ParseOrder(s string) (Order, error) {
+	switch s {
+	case "", "month_first":
+		return MonthFirst, nil
+	case "day_first":
+		return DayFirst, nil
+	}
+	return 0, fmt.Errorf("unknown date order %q, expected month_first or day_first", s)
+}
+
+// monthFirstRegions are the regions that write the month before the day.
+var monthFirstRegions = map[string]bool{
+	"US": true, "AS": true, "GU": true, "MP": true, "PR": true, "UM": true, "VI": true,
+	"PH": true, "FM": true, "MH": true, "PW": true,
+}
+
+// OrderOf returns the date order of a locale, such as the Content-Language of a request: MonthFirst for "en-US"
+// and DayFirst for "en-GB" or "fr". A language without a region is read in the region it is most used in, so "en"
+// is month first. An empty or invalid locale has the fallback order.
+func OrderOf(locale string, fallback Order) Order {
+	tags, _, err := language.ParseAcceptLanguage(locale)
+	if err != nil || len(tags) == 0 {
+		return fallback
+	}
+	region, _ := tags[0].Region()
+	if monthFirstRegions[region.String()] {
+		return MonthFirst
+	}
+	return DayFirst
+}
+
+var (
+	// monthFirstLayouts are the numeric dates with the month before the day. Go reads "1" and "2" as one or two
+	// digits, so they also match 03/04/2022.
+	monthFirstLayouts = []string{"1/2/2006", "1-2-2006", "1.2.2006", "1/2/06"}
+	// dayFirstLayouts are the numeric dates with the day before the month.
+	dayFirstLayouts = []string{"2/1/2006", "2-1-2006", "2.1.2006", "2/1/06"}
+	// dateLayouts are the dates that read the same in every order: the year first or the month spelled out.
+	dateLayouts = []string{
+		"2006-1-2", "2006/1/2", "2006.1.2", "20060102",
+		"Jan 2, 2006", "Jan 2 2006", "January 2, 2006", "January 2 2006",
+		"2 Jan 2006", "2 January 2006", "02-Jan-2006", "Mon, Jan 2, 2006", "Monday, January 2, 2006",
+		time.RFC3339,
+	}
+	// timeLayouts are the times of day, with the meridiem already upper-cased and stripped of dots.
+	timeLayouts = []string{"15:04:05", "3:04 PM", "3:04PM", "3:04:05 PM", "3:04:05PM", "3 PM", "3PM", "1504", "15.04"}
+
+	// canonicalTime matches the purchase times the Receipt schema accepts.
+	canonicalTime = regexp.MustCompile(`^([01]?\d|2[0-3]):[0-5]\d$`)
+)
+
+// Normalizer rewrites purchase dates and times into their canonical forms. The zero value reads numeric dates month
+// first with the built-in layouts.
+type Normalizer struct {
+	// Order is how numeric dates are read when the request names no locale.
+	Order Order
+	// DateLayouts are Go time layouts tried after the built-in ones, such as "2006年1月2日".
+	DateLayouts []string
+	// TimeLayouts are Go time layouts tried after the built-in ones, such as "15h04".
+	TimeLayouts []string
+}
+
+// ParseLayouts parses a semicolon-separated list of Go time layouts, since layouts may contain commas.
+func ParseLayouts(s string) []string {
+	var layouts []string
+	for _, layout := range strings.Split(s, ";") {
+		if layout = strings.TrimSpace(layout); layout != "" {
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts
+}
+
+// Date returns the canonical form of a purchase date, reading numeric dates in the order and trying the other
+// order only if the date cannot be read in it, as with 03/20/2022 read day first. It reports false if the value is
+// canonical already or cannot be read, in which case the schema rejects it as before.
+func (n Normalizer) Date(value string, order Order) (string, bool) {
+	value = strings.TrimSpace(value)
+	if _, err := time.Parse(DateLayout, value); err == nil {
+		return "", false
+	}
+	preferred, other := monthFirstLayouts, dayFirstLayouts
+	if order == DayFirst {
+		preferred, other = other, preferred
+	}
+	for _, layouts := range [][]string{dateLayouts, preferred, other, n.DateLayouts} {
+		for _, layout := range layouts {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed.Format(DateLayout), true
+			}
+		}
+	}
+	return "", false
+}
+
+// Time returns the canonical 24-hour form of a purchase time, such as "14:33" for "2:33 PM", "2:33 p.m.",
+// "14:33:05" or "1433". Seconds are dropped. It reports false if the value is canonical already or cannot be read.
+func (n Normalizer) Time(value string) (string, bool) {
+	if canonicalTime.MatchString(value) {
+		return "", false
+	}
+	cleaned := strings.ToUpper(strings.Join(strings.Fields(value), " "))
+	cleaned = strings.NewReplacer("A.M.", "AM", "P.M.", "PM", "A.M", "AM", "P.M", "PM").Replace(cleaned)
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, cleaned); err == nil {
+			return parsed.Format(TimeLayout), true
+		}
+	}
+	// Configured layouts see the value as submitted, since they may spell the meridiem or separators otherwise
+	for _, layout := range n.TimeLayouts {
+		if parsed, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return parsed.Format(TimeLayout), true
+		}
+	}
+	return "", false
+}
+
+// Body rewrites the purchaseDate and purchaseTime of a JSON receipt into their canonical forms.
+//
+// Parameters:
+//
+//	body  - The receipt as submitted.
+//	order - How numeric dates are read, usually the OrderOf the request's locale.
+//
+// Returns:
+//
+//	The body with the fields rewritten and what was rewritten, or the body unchanged and no changes if nothing
+//	needed rewriting or it is not a JSON object, which is left for the schema to reject.
+func (n Normalizer) Body(body []byte, order Order) ([]byte, []server.Normalization) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, nil
+	}
+	var changes []server.Normalization
+	rewrite := func(field string, normalize func(string) (string, bool)) {
+		var original string
+		if err := json.Unmarshal(fields[field], &original); err != nil {
+			return
+		}
+		value, ok := normalize(original)
+		if !ok {
+			return
+		}
+		fields[field], _ = json.Marshal(value)
+		changes = append(changes, server.Normalization{Field: "/" + field, Original: original, Value: value})
+	}
+	rewrite("purchaseDate", func(value string) (string, bool) { return n.Date(value, order) })
+	rewrite("purchaseTime", n.Time)
+	if len(changes) == 0 {
+		return body, nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(fields); err != nil {
+		return body, nil
+	}
+	return buf.Bytes(), changes
+}
+
+// Receipt rewrites the purchase time of a receipt into its canonical form. The purchase date of a Receipt is a date
+// already, so readers building one rewrite it with Date first.
+//
+// Returns:
+//
+//	What was rewritten, or no changes if the time is canonical already or cannot be read.
+func (n Normalizer) Receipt(receipt *server.Receipt) []server.Normalization {
+	value, ok := n.Time(receipt.PurchaseTime)
+	if !ok {
+		return nil
+	}
+	change := server.Normalization{Field: "/purchaseTime", Original: receipt.PurchaseTime, Value: value}
+	receipt.PurchaseTime = value
+	return []server.Normalization{change}
+}
+
+// contextKey is the key of the changes in a context.
+type contextKey struct{}
+
+// NewContext returns a context carrying the changes made to a request's body.
+func NewContext(ctx context.Context, changes []server.Normalization) context.Context {
+	return context.WithValue(ctx, contextKey{}, changes)
+}
+
+// FromContext returns the changes made to the body of the request of a context, or nil if there were none.
+func FromContext(ctx context.Context) []server.Normalization {
+	changes, _ := ctx.Value(contextKey{}).([]server.Normalization)
+	return changes
+}
@@ -0,0 +1,125 @@
+package normalize
+
+import (
+	"context"
+	"encoding/json"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		order    Order
+		expected string
+	}{
+		{"03/20/2022", MonthFirst, "2022-03-20"},
+		{"3/4/2022", MonthFirst, "2022-03-04"},
+		{"3/4/2022", DayFirst, "2022-04-03"},
+		{"20.03.2022", DayFirst, "2022-03-20"},
+		// A date that cannot be read in the preferred order is read in the other
+		{"20/03/2022", MonthFirst, "2022-03-20"},
+		{"03/20/22", DayFirst, "2022-03-20"},
+		{"2022/03/20", DayFirst, "2022-03-20"},
+		{"20220320", MonthFirst, "2022-03-20"},
+		{"Mar 20, 2022", DayFirst, "2022-03-20"},
+		{"20 march 2022", MonthFirst, "2022-03-20"},
+		{"2022-03-20T14:33:00-05:00", MonthFirst, "2022-03-20"},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			value, ok := Normalizer{}.Date(test.value, test.order)
+			assert.True(t, ok)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+
+	for _, value := range []string{"2022-03-20", "13/13/2022", "yesterday", ""} {
+		_, ok := Normalizer{}.Date(value, MonthFirst)
+		assert.False(t, ok, value)
+	}
+
+	// Configured layouts are tried after the built-in ones
+	value, ok := Normalizer{DateLayouts: []string{"2006年1月2日"}}.Date("2022年3月20日", MonthFirst)
+	assert.True(t, ok)
+	assert.Equal(t, "2022-03-20", value)
+}
+
+func TestTime(t *testing.T) {
+	tests := map[string]string{
+		"2:33 PM":   "14:33",
+		"2:33pm":    "14:33",
+		"2:33 p.m.": "14:33",
+		"12:05 AM":  "00:05",
+		"12:05 PM":  "12:05",
+		"9 am":      "09:00",
+		"14:33:05":  "14:33",
+		"1433":      "14:33",
+		"14.33":     "14:33",
+	}
+	for original, expected := range tests {
+		t.Run(original, func(t *testing.T) {
+			value, ok := Normalizer{}.Time(original)
+			assert.True(t, ok)
+			assert.Equal(t, expected, value)
+		})
+	}
+
+	for _, value := range []string{"14:33", "2:33", "25:00", "13:00 PM", "teatime"} {
+		_, ok := Normalizer{}.Time(value)
+		assert.False(t, ok, value)
+	}
+
+	value, ok := Normalizer{TimeLayouts: []string{"15h04"}}.Time("14h33")
+	assert.True(t, ok)
+	assert.Equal(t, "14:33", value)
+}
+
+func TestOrderOf(t *testing.T) {
+	assert.Equal(t, MonthFirst, OrderOf("en-US", DayFirst))
+	assert.Equal(t, MonthFirst, OrderOf("en", DayFirst))
+	assert.Equal(t, DayFirst, OrderOf("en-GB", MonthFirst))
+	assert.Equal(t, DayFirst, OrderOf("fr-CA, en;q=0.5", MonthFirst))
+	assert.Equal(t, DayFirst, OrderOf("", DayFirst))
+	assert.Equal(t, MonthFirst, OrderOf("not a locale!", MonthFirst))
+}
+
+func TestParseOrder(t *testing.T) {
+	order, err := ParseOrder("day_first")
+	assert.NoError(t, err)
+	assert.Equal(t, DayFirst, order)
+	order, err = ParseOrder("")
+	assert.NoError(t, err)
+	assert.Equal(t, MonthFirst, order)
+	_, err = ParseOrder("dmy")
+	assert.Error(t, err)
+}
+
+func TestParseLayouts(t *testing.T) {
+	assert.Equal(t, []string{"Jan 2, 2006", "2.1.06"}, ParseLayouts(" Jan 2, 2006 ;; 2.1.06 "))
+	assert.Empty(t, ParseLayouts(""))
+}
+
+func TestBody(t *testing.T) {
+	body := []byte(`{"retailer": "M&M", "purchaseDate": "20/03/2022", "purchaseTime": "14:33", "total": "1.00"}`)
+	rewritten, changes := Normalizer{}.Body(body, DayFirst)
+	assert.Equal(t, []server.Normalization{{Field: "/purchaseDate", Original: "20/03/2022", Value: "2022-03-20"}}, changes)
+
+	var fields map[string]string
+	assert.NoError(t, json.Unmarshal(rewritten, &fields))
+	assert.Equal(t, map[string]string{"retailer": "M&M", "purchaseDate": "2022-03-20", "purchaseTime": "14:33", "total": "1.00"}, fields)
+
+	// Bodies that need no changes or are not receipts are passed through untouched
+	for _, unchanged := range []string{`{"purchaseDate": "2022-03-20"}`, `{"purchaseTime": 1433}`, `[]`, `null`, `not json`} {
+		rewritten, changes := Normalizer{}.Body([]byte(unchanged), MonthFirst)
+		assert.Equal(t, unchanged, string(rewritten))
+		assert.Empty(t, changes)
+	}
+}
+
+func TestContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+	changes := []server.Normalization{{Field: "/purchaseTime", Original: "1433", Value: "14:33"}}
+	assert.Equal(t, changes, FromContext(NewContext(context.Background(), changes)))
+}
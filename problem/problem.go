@@ -0,0 +1,152 @@
+package problem
+
+import (
+	"encoding/json"
+	"fetch-app/validation"
+	"net/http"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Code identifies a kind of problem. Codes are stable, so clients can branch on them instead of on the detail text.
+type Code string
+
+const (
+	// CodeInvalidRequest marks a request the server could not read, such as a body that is not JSON.
+	CodeInvalidRequest Code = "invalid_request"
+	// CodeValidationFailed marks a request that violates the API contract. The field errors say how.
+	CodeValidationFailed Code = "validation_failed"
+	// CodeInvalidTimezone marks a receipt whose time zone, or its inherited one, is unknown.
+	CodeInvalidTimezone Code = "invalid_timezone"
+	// CodeReasonRequired marks a rejection without a reason.
+	CodeReasonRequired Code = "reason_required"
+	// CodeInvalidReceiptID marks a receipt ID that is neither a UUID nor a ULID, so no receipt can have it.
+	CodeInvalidReceiptID Code = "invalid_receipt_id"
+	// CodeUnauthorized marks an admin request without a valid token.
+	CodeUnauthorized Code = "unauthorized"
+	// CodeReceiptNotFound marks a request for a receipt that does not exist.
+	CodeReceiptNotFound Code = "receipt_not_found"
+	// CodeNotFound marks a request for a path the API does not have.
+	CodeNotFound Code = "not_found"
+	// CodeMethodNotAllowed marks a request using a method the path does not support.
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	// CodeDuplicateReceipt marks a receipt rejected by the duplicate policy.
+	CodeDuplicateReceipt Code = "duplicate_receipt"
+	// CodeReceiptRejected marks a request for the points, or a correction, of a receipt rejected on review.
+	CodeReceiptRejected Code = "receipt_rejected"
+	// CodeNotPendingReview marks a decision on a receipt that is not awaiting review.
+	CodeNotPendingReview Code = "not_pending_review"
+	// CodeIdempotencyKeyReused marks a submission reusing an Idempotency-Key that was used for a different receipt.
+	CodeIdempotencyKeyReused Code = "idempotency_key_reused"
+	// CodeReceiptUnreadable marks text or an email from which no valid receipt could be read.
+	CodeReceiptUnreadable Code = "receipt_unreadable"
+	// CodeImageNotFound marks a request for the image of a receipt that has none.
+	CodeImageNotFound Code = "image_not_found"
+	// CodeImageTooLarge marks an image larger than the server accepts.
+	CodeImageTooLarge Code = "image_too_large"
+	// CodeUnsupportedImage marks an upload that is not an image of an accepted type.
+	CodeUnsupportedImage Code = "unsupported_image"
+	// CodeItemsPurged marks a correction of a receipt whose item details were purged.
+	CodeItemsPurged Code = "items_purged"
+	// CodePreconditionFailed marks a correction whose If-Match does not match the receipt's current version.
+	CodePreconditionFailed Code = "precondition_failed"
+	// CodePreconditionRequired marks a correction without an If-Match header.
+	CodePreconditionRequired Code = "precondition_required"
+	// CodeInternal marks an unexpected server failure. Its details are logged rather than returned.
+	CodeInternal Code = "internal_error"
+	// CodeResponseInvalid marks a response that violates the API contract, which is only checked in development.
+	CodeResponseInvalid Code = "response_invalid"
+)
+
+// titles summarizes each kind of problem. A title never changes between occurrences of its problem.
+var titles = map[Code]string{
+	CodeInvalidRequest:       "Invalid request",
+	CodeValidationFailed:     "Validation failed",
+	CodeInvalidTimezone:      "Invalid time zone",
+	CodeReasonRequired:       "Reason required",
+	CodeInvalidReceiptID:     "Invalid receipt ID",
+	CodeUnauthorized:         "Unauthorized",
+	CodeReceiptNotFound:      "Receipt not found",
+	CodeNotFound:             "Not found",
+	CodeMethodNotAllowed:     "Method not allowed",
+	CodeDuplicateReceipt:     "Duplicate receipt",
+	CodeReceiptRejected:      "Receipt rejected",
+	CodeNotPendingReview:     "Receipt not pending review",
+	CodeIdempotencyKeyReused: "Idempotency key reused",
+	CodeReceiptUnreadable:    "Receipt unreadable",
+	CodeImageNotFound:        "Image not found",
+	CodeImageTooLarge:        "Image too large",
+	CodeUnsupportedImage:     "Unsupported image",
+	CodeItemsPurged:          "Items purged",
+	CodePreconditionFailed:   "Precondition failed",
+	CodePreconditionRequired: "Precondition required",
+	CodeInternal:             "Internal server error",
+	CodeResponseInvalid:      "Invalid response",
+}
+
+// Problem is an RFC 7807 problem details object. It is an error, so handlers can return it and leave rendering
+// to the server's error handler.
+type Problem struct {
+	// Type identifies the kind of problem as a URI reference, derived from the code.
+	Type string `json:"type"`
+	// Title summarizes the kind of problem.
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that caused the problem.
+	Instance string `json:"instance,omitempty"`
+	// Code identifies the kind of problem.
+	Code Code `json:"code"`
+	// Errors lists each field violating the API contract.
+	Errors []validation.FieldError `json:"errors,omitempty"`
+	// DuplicateOf lists the earlier receipts a rejected duplicate matches.
+	DuplicateOf []string `json:"duplicateOf,omitempty"`
+}
+
+// New creates a Problem of the kind identified by code.
+//
+// Parameters:
+//
+//	status - The HTTP status code of the response.
+//	code   - The kind of problem.
+//	detail - An explanation of this occurrence, safe to show to clients. May be empty.
+//
+// Returns:
+//
+//	The Problem.
+func New(status int, code Code, detail string) *Problem {
+	title, ok := titles[code]
+	if !ok {
+		title = http.StatusText(status)
+	}
+	return &Problem{
+		Type:   "/problems/" + string(code),
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Error returns the detail of the problem, or its title if it has none.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// Write sends the problem as the response.
+func (p *Problem) Write(w http.ResponseWriter) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,61 @@
+package problem
+
+import (
+	"encoding/json"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCodesMatchSpec tests that every code is documented in the spec, and the spec documents no others.
+func TestCodesMatchSpec(t *testing.T) {
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		t.Fatalf("Error loading spec: %v", err)
+	}
+
+	var documented []Code
+	for _, value := range swagger.Components.Schemas["ProblemCode"].Value.Enum {
+		documented = append(documented, Code(value.(string)))
+	}
+	var codes []Code
+	for code := range titles {
+		codes = append(codes, code)
+	}
+	assert.ElementsMatch(t, documented, codes)
+}
+
+// TestWrite tests that a problem is sent as application/problem+json.
+func TestWrite(t *testing.T) {
+	p := New(http.StatusBadRequest, CodeValidationFailed, "The request does not match the API contract")
+	p.Instance = "/receipts/process"
+	p.Errors = []validation.FieldError{{Field: "/total", Message: `property "total" is missing`}}
+
+	rec := httptest.NewRecorder()
+	assert.NoError(t, p.Write(rec))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "/problems/validation_failed",
+		"title": "Validation failed",
+		"status": 400,
+		"detail": "The request does not match the API contract",
+		"instance": "/receipts/process",
+		"code": "validation_failed",
+		"errors": [{"field": "/total", "message": "property \"total\" is missing"}]
+	}`, rec.Body.String())
+
+	var decoded Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+	assert.Equal(t, *p, decoded)
+}
+
+// TestError tests that a problem reads as its detail, or its title without one.
+func TestError(t *testing.T) {
+	assert.Equal(t, "Receipt with ID abc not found", New(http.StatusNotFound, CodeReceiptNotFound, "Receipt with ID abc not found").Error())
+	assert.Equal(t, "Not found", New(http.StatusNotFound, CodeNotFound, "").Error())
+	assert.Equal(t, "Too Many Requests", New(http.StatusTooManyRequests, Code("rate_limited"), "").Error())
+}
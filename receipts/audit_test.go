@@ -0,0 +1,64 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fetch-app/audit"
+	"fetch-app/retention"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// auditEntries decodes the entries written to an audit log.
+func auditEntries(t *testing.T, buf *bytes.Buffer) []audit.Entry {
+	if _, err := audit.Verify(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Error verifying audit log: %v", err)
+	}
+	entries := make([]audit.Entry, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry audit.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Error decoding audit entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestServiceAudit tests that creating, approving and erasing receipts is recorded with who did it.
+func TestServiceAudit(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	service := NewService(NewStorage())
+	service.Audit = audit.NewLog(&buf)
+	service.ReviewRules.MaxPoints = 10
+
+	// The test receipt earns more than 10 points, so it is held for review
+	receiptID := processAs(t, service, "alice", testReceipt())
+	_, err := service.PostAdminReceiptsIdApprove(ctx, server.PostAdminReceiptsIdApproveRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	_, err = service.DeleteAdminPrincipalsPrincipalReceipts(ctx, server.DeleteAdminPrincipalsPrincipalReceiptsRequestObject{Principal: "alice"})
+	assert.NoError(t, err)
+
+	entries := auditEntries(t, &buf)
+	actions := make([]audit.Action, len(entries))
+	for i, entry := range entries {
+		actions[i] = entry.Action
+		assert.Equal(t, receiptID, entry.ReceiptID)
+	}
+	assert.Equal(t, []audit.Action{
+		audit.ActionReceiptCreated,
+		audit.ActionReceiptApproved,
+		audit.ActionPointsAwarded,
+		audit.ActionReceiptDeleted,
+	}, actions)
+	// Submitters are only known by the hash their erasure is recorded under
+	assert.Equal(t, retention.HashPrincipal(service.PrincipalKey, "alice"), entries[0].Principal)
+	assert.Equal(t, audit.ContentHash(testReceipt()), entries[0].ContentHash)
+	assert.Equal(t, audit.PrincipalAdmin, entries[1].Principal)
+	assert.Equal(t, int64(20), *entries[2].Points)
+	assert.Equal(t, "erasure", entries[3].Detail)
+}
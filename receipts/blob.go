@@ -0,0 +1,270 @@
+package receipts
+
+import (
+	"encoding/json"
+	"errors"
+	"fetch-app/envelope"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// BlobStore holds binary content, such as receipt images, by key. Implementations must be safe for concurrent use.
+type BlobStore interface {
+	// Put stores the content under the key, replacing any stored before.
+	Put(key string, content []byte) error
+	// Get returns the content stored under the key, or an error wrapping fs.ErrNotExist if there is none.
+	Get(key string) ([]byte, error)
+	// Delete removes the content stored under the key. Deleting a key that has no content is not an error.
+	Delete(key string) error
+	// List returns the keys that have content stored under them, in no particular order.
+	List() ([]string, error)
+}
+
+// MemoryBlobStore keeps blobs in memory, so they are lost on restart like the receipts in a Storage without a
+// backend.
+type MemoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryBlobStore creates an empty MemoryBlobStore.
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+// Put stores a copy of the content under the key.
+func (m *MemoryBlobStore) Put(key string, content []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = append([]byte(nil), content...)
+	return nil
+}
+
+// Get returns the content stored under the key.
+func (m *MemoryBlobStore) Get(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	content, exists := m.blobs[key]
+	if !exists {
+		return nil, fmt.Errorf("blob %s: %w", key, fs.ErrNotExist)
+	}
+	return content, nil
+}
+
+// Delete removes the content stored under the key.
+func (m *MemoryBlobStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, key)
+	return nil
+}
+
+// List returns the keys of the blobs in memory.
+func (m *MemoryBlobStore) List() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.blobs))
+	for key := range m.blobs {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FileBlobStore keeps each blob in a file named after its key in a directory on the local filesystem.
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore creates a FileBlobStore storing blobs in dir, which is created if needed.
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating blob directory: %w", err)
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+// Put writes the content to a temporary file and renames it into place, so a crash never leaves a partial blob.
+func (f *FileBlobStore) Put(key string, content []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, content, 0o600); err != nil {
+		return fmt.Errorf("writing blob %s: %w", key, err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return fmt.Errorf("writing blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get reads the file of the key.
+func (f *FileBlobStore) Get(key string) ([]byte, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", key, err)
+	}
+	return content, nil
+}
+
+// Delete removes the file of the key.
+func (f *FileBlobStore) Delete(key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deleting blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns the names of the files in the directory, skipping the temporary files of writes in progress.
+func (f *FileBlobStore) List() ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob directory: %w", err)
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasSuffix(entry.Name(), ".tmp") {
+			keys = append(keys, entry.Name())
+		}
+	}
+	return keys, nil
+}
+
+// path returns the file of a key, refusing keys that would escape the directory.
+func (f *FileBlobStore) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("blob key %q cannot be stored", key)
+	}
+	return filepath.Join(f.dir, key), nil
+}
+
+// SealedBlobStore encrypts blobs in envelopes bound to their key before storing them in another BlobStore, like
+// an EncryptedBackend does with records.
+type SealedBlobStore struct {
+	// Store holds the sealed blobs.
+	Store BlobStore
+	// Keys are the master keys. New blobs are sealed under the active one.
+	Keys *envelope.Keyring
+
+	// mu keeps a rotation from overwriting a blob stored or deleted since the rotation read it.
+	mu sync.Mutex
+}
+
+// Put seals the content under the active master key and stores the envelope.
+func (s *SealedBlobStore) Put(key string, content []byte) error {
+	env, err := s.Keys.Seal(content, []byte(key))
+	if err != nil {
+		return fmt.Errorf("sealing blob %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(key, env)
+}
+
+// Get opens the envelope stored under the key.
+func (s *SealedBlobStore) Get(key string) ([]byte, error) {
+	env, err := s.read(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.Keys.Open(env, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("opening blob %s: %w", key, err)
+	}
+	return content, nil
+}
+
+// Delete removes the envelope stored under the key.
+func (s *SealedBlobStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Store.Delete(key)
+}
+
+// List returns the keys of the sealed blobs.
+func (s *SealedBlobStore) List() ([]string, error) {
+	return s.Store.List()
+}
+
+// Rotate rewraps the data keys of the blobs sealed under a retired master key with the active one, so the retired
+// key can be removed from the key file. The contents are not re-encrypted.
+//
+// Returns:
+//
+//	The number of blobs rewrapped, and the errors of those that could not be.
+func (s *SealedBlobStore) Rotate() (int, error) {
+	keys, err := s.Store.List()
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	var errs []error
+	for _, key := range keys {
+		rewrapped, err := s.rewrap(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if rewrapped {
+			rotated++
+		}
+	}
+	return rotated, errors.Join(errs...)
+}
+
+// rewrap rereads one blob under the lock, so a concurrent put is not overwritten, and rewraps its data key if a
+// retired master key wraps it. It reports whether it did.
+func (s *SealedBlobStore) rewrap(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	env, err := s.read(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if env.KeyID == s.Keys.Active() {
+		return false, nil
+	}
+	if env, err = s.Keys.Rewrap(env); err != nil {
+		return false, fmt.Errorf("rewrapping blob %s: %w", key, err)
+	}
+	return true, s.write(key, env)
+}
+
+// read reads the envelope stored under the key without opening it.
+func (s *SealedBlobStore) read(key string) (envelope.Envelope, error) {
+	data, err := s.Store.Get(key)
+	if err != nil {
+		return envelope.Envelope{}, err
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return envelope.Envelope{}, fmt.Errorf("decoding blob %s: %w", key, err)
+	}
+	return env, nil
+}
+
+// write stores the envelope under the key.
+func (s *SealedBlobStore) write(key string, env envelope.Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("encoding blob %s: %w", key, err)
+	}
+	return s.Store.Put(key, data)
+}
@@ -0,0 +1,322 @@
+package receipts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fetch-app/audit"
+	"fetch-app/calculation"
+	"fetch-app/fraud"
+	"fetch-app/ids"
+	"fetch-app/problem"
+	"fetch-app/review"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// adminKey is the key marking a context as an admin's.
+type adminKey struct{}
+
+// NewAdminContext returns a context marking its request as made by an admin, who may correct receipts and attach and
+// read their images.
+func NewAdminContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey{}, true)
+}
+
+// isAdmin reports whether the request of a context was made by an admin.
+func isAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+	return admin
+}
+
+// requireAdmin refuses a request that was not made by an admin with an Unauthorized (401) problem. X-Principal-Id is
+// sent by the client and proves nothing about who submitted a receipt, so only admins may change one or read its
+// image.
+func requireAdmin(ctx context.Context, what string) error {
+	if !isAdmin(ctx) {
+		return problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, what+" requires the admin token")
+	}
+	return nil
+}
+
+// PutReceiptsId replaces a receipt with a corrected version. See correct for the failures.
+func (s *Service) PutReceiptsId(ctx context.Context, request server.PutReceiptsIdRequestObject) (server.PutReceiptsIdResponseObject, error) {
+	if request.Body == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request body is not a valid receipt")
+	}
+	corrected, err := s.correct(ctx, request.Id, request.Params.IfMatch, func(server.Receipt) (server.Receipt, error) {
+		return *request.Body, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server.PutReceiptsId200JSONResponse{CorrectedJSONResponse: corrected}, nil
+}
+
+// PatchReceiptsId corrects the fields of a receipt named by a JSON merge patch. The patched receipt is checked
+// against the Receipt schema, failing with a Bad Request (400) problem listing each invalid field. See correct for
+// the other failures.
+func (s *Service) PatchReceiptsId(ctx context.Context, request server.PatchReceiptsIdRequestObject) (server.PatchReceiptsIdResponseObject, error) {
+	if request.Body == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request body is not a merge patch")
+	}
+	corrected, err := s.correct(ctx, request.Id, request.Params.IfMatch, func(current server.Receipt) (server.Receipt, error) {
+		return s.applyPatch(current, *request.Body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server.PatchReceiptsId200JSONResponse{CorrectedJSONResponse: corrected}, nil
+}
+
+// GetReceiptsIdVersions lists every version of a receipt, oldest first.
+//
+// Returns:
+//
+//	The versions if the receipt exists, the last being the current one.
+//	If the ID is malformed, it returns a Bad Request (400) problem.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+func (s *Service) GetReceiptsIdVersions(ctx context.Context, request server.GetReceiptsIdVersionsRequestObject) (server.GetReceiptsIdVersionsResponseObject, error) {
+	record, err := s.get(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(server.GetReceiptsIdVersions200JSONResponse, 0, len(record.Versions)+1)
+	for _, version := range record.Versions {
+		versions = append(versions, versionAPI(record, version.Version, version.Receipt, version.Points, version.Correction))
+	}
+	current := versionAPI(record, record.CurrentVersion(), record.Receipt, s.awarded(record), record.Correction)
+	return append(versions, current), nil
+}
+
+// correct replaces a receipt with a corrected version, keeping the one it replaces. Only admins may correct receipts,
+// see requireAdmin. The corrected receipt is
+// checked and scored like a new submission: its fingerprint is checked against the other receipts, and the review
+// rules hold an approved receipt for review again if they would have held the corrected one. The change in the
+// points awarded is recorded as the correction's adjustment.
+//
+// Parameters:
+//
+//	ctx     - The request's context, marked by NewAdminContext for an admin.
+//	id      - The ID of the receipt.
+//	ifMatch - The If-Match header, which must match the ETag of the current version.
+//	revise  - Returns the corrected receipt from the current one.
+//
+// Returns:
+//
+//	The corrected record with the ETag of its new version if successful.
+//	If the ID is malformed, the corrected receipt is invalid or its time zone or currency is, it returns a Bad
+//	Request (400) problem.
+//	If the caller is not an admin, it returns an Unauthorized (401) problem.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+//	If the receipt was rejected on review or its item details were purged, or the corrected receipt duplicates
+//	another and the duplicate policy rejects it, it returns a Conflict (409) problem.
+//	If If-Match does not match the current version, it returns a Precondition Failed (412) problem, and if it is
+//	missing a Precondition Required (428) problem.
+func (s *Service) correct(ctx context.Context, id string, ifMatch *string, revise func(current server.Receipt) (server.Receipt, error)) (server.CorrectedJSONResponse, error) {
+	if err := requireAdmin(ctx, "Correcting a receipt"); err != nil {
+		return server.CorrectedJSONResponse{}, err
+	}
+	if !ids.Valid(id) {
+		return server.CorrectedJSONResponse{}, InvalidID(id)
+	}
+	if ifMatch == nil {
+		return server.CorrectedJSONResponse{}, problem.New(http.StatusPreconditionRequired, problem.CodePreconditionRequired,
+			"Corrections must send the ETag of the version they correct in If-Match")
+	}
+
+	var corrected server.CorrectedJSONResponse
+	var entries []audit.Entry
+	exists, err := s.Storage.Update(id, func(record *Record) error {
+		if !matchETag(*ifMatch, record.ETag()) {
+			return problem.New(http.StatusPreconditionFailed, problem.CodePreconditionFailed,
+				fmt.Sprintf("Receipt with ID %s was changed since version %s, its current version is %s", id, *ifMatch, record.ETag()))
+		}
+		switch {
+		case record.Status == review.StatusRejected:
+			return problem.New(http.StatusConflict, problem.CodeReceiptRejected,
+				fmt.Sprintf("Receipt with ID %s was rejected on review and cannot be corrected", id))
+		case record.ItemsPurgedAt != nil:
+			return problem.New(http.StatusConflict, problem.CodeItemsPurged,
+				fmt.Sprintf("The item details of receipt with ID %s were purged, so it cannot be corrected", id))
+		}
+		// The stored rate was filled in by the server, which fills it in again for the corrected receipt
+		current := record.Receipt
+		current.ExchangeRate = nil
+		receipt, err := revise(current)
+		if err != nil {
+			return err
+		}
+		if err := s.prepare(&receipt); err != nil {
+			return err
+		}
+
+		// Check the corrected receipt against the others, restoring the original's fingerprint if it is refused
+		fingerprint := fraud.Fingerprint(receipt)
+		matches, zeroPoints := record.DuplicateOf, record.ZeroPoints
+		if fingerprint != record.Fingerprint {
+			s.Storage.Fingerprints.Remove(record.Fingerprint, id)
+			var accepted bool
+			matches, accepted = s.Storage.Fingerprints.Register(fingerprint, id, s.DuplicatePolicy)
+			if !accepted {
+				s.Storage.Fingerprints.Register(record.Fingerprint, id, fraud.PolicyFlag)
+				conflict := problem.New(http.StatusConflict, problem.CodeDuplicateReceipt, "The corrected receipt has already been submitted")
+				conflict.DuplicateOf = matches
+				return conflict
+			}
+			zeroPoints = len(matches) > 0 && s.DuplicatePolicy == fraud.PolicyZeroPoints
+		}
+
+		// Keep the version being replaced with the points it was awarded
+		before := s.awarded(record)
+		version := record.CurrentVersion()
+		record.Versions = append(record.Versions, Version{
+			Version:    version,
+			Receipt:    record.Receipt,
+			Points:     before,
+			Correction: record.Correction,
+		})
+		record.Receipt = receipt
+		record.Version = version + 1
+		record.Fingerprint, record.DuplicateOf, record.ZeroPoints = fingerprint, matches, zeroPoints
+		record.PurchasedAt = nil
+		if purchasedAt, err := calculation.PurchaseTimestamp(receipt); err == nil {
+			record.PurchasedAt = &purchasedAt
+		}
+		record.Breakdown = s.Calculator.Breakdown(receipt)
+
+		// A correction must not raise the points past review, so an approved receipt is reviewed again if the
+		// corrected one would have been held
+		if !record.ZeroPoints {
+			flagged := []string(nil)
+			if len(matches) > 0 && s.DuplicatePolicy == fraud.PolicyFlag {
+				flagged = matches
+			}
+			if reasons := s.ReviewRules.Check(receipt, int(s.points(record)), flagged); len(reasons) > 0 {
+				record.ReviewReasons = reasons
+				if record.Status == review.StatusApproved {
+					record.Status = review.StatusPendingReview
+					record.Decision = nil
+				}
+			}
+		}
+
+		after := s.awarded(record)
+		record.Correction = &Correction{At: time.Now().UTC(), Principal: audit.PrincipalAdmin, Adjustment: after - before}
+		corrected = server.CorrectedJSONResponse{
+			Body:    record.API(),
+			Headers: server.CorrectedResponseHeaders{ETag: record.ETag()},
+		}
+		entries = []audit.Entry{{
+			Action:      audit.ActionReceiptCorrected,
+			Principal:   audit.PrincipalAdmin,
+			ReceiptID:   id,
+			ContentHash: audit.ContentHash(receipt),
+			Detail:      fmt.Sprintf("version %d", record.Version),
+		}, {
+			Action:      audit.ActionPointsRecomputed,
+			Principal:   audit.PrincipalAdmin,
+			ReceiptID:   id,
+			ContentHash: audit.ContentHash(receipt),
+			Points:      &after,
+			Detail:      fmt.Sprintf("correction adjusted the points by %+d", after-before),
+		}}
+		return nil
+	})
+	if !exists {
+		return server.CorrectedJSONResponse{}, NotFound(id)
+	}
+	if err != nil {
+		return server.CorrectedJSONResponse{}, err
+	}
+	if err := s.appendAudit(entries...); err != nil {
+		return server.CorrectedJSONResponse{}, err
+	}
+	return corrected, nil
+}
+
+// applyPatch applies a JSON merge patch to a receipt and checks the result against the Receipt schema.
+func (s *Service) applyPatch(receipt server.Receipt, patch server.ReceiptPatch) (server.Receipt, error) {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return server.Receipt{}, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return server.Receipt{}, err
+	}
+	patched := mergePatch(document, map[string]interface{}(patch))
+
+	if s.Validator != nil {
+		var violation *validation.Error
+		err := s.Validator.ValidateSchema("Receipt", patched)
+		if errors.As(err, &violation) {
+			p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "The patched receipt does not match the API contract")
+			p.Errors = violation.Fields
+			return server.Receipt{}, p
+		}
+		if err != nil {
+			return server.Receipt{}, err
+		}
+	}
+	if data, err = json.Marshal(patched); err != nil {
+		return server.Receipt{}, err
+	}
+	var corrected server.Receipt
+	if err := json.Unmarshal(data, &corrected); err != nil {
+		return server.Receipt{}, problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "The patched receipt is not a valid receipt")
+	}
+	return corrected, nil
+}
+
+// mergePatch applies a JSON merge patch to a decoded JSON document as RFC 7396 describes: members of an object
+// patch are merged into the document recursively and removed when null, and any other patch replaces the document.
+func mergePatch(document, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		object = make(map[string]interface{})
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = mergePatch(object[name], value)
+	}
+	return object
+}
+
+// matchETag reports whether an If-Match header lists the ETag or is "*". If-Match compares ETags strongly, so a
+// weak one never matches.
+func matchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// versionAPI converts a version of a record's receipt into the model served by the API.
+func versionAPI(record *Record, version int, receipt server.Receipt, points int64, correction *Correction) server.ReceiptVersion {
+	v := server.ReceiptVersion{
+		Version:   version,
+		Receipt:   receipt,
+		CreatedAt: record.SubmittedAt,
+		Points:    points,
+	}
+	if correction != nil {
+		adjustment := correction.Adjustment
+		v.CreatedAt, v.Adjustment = correction.At, &adjustment
+	}
+	return v
+}
@@ -0,0 +1,251 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"fetch-app/audit"
+	"fetch-app/fraud"
+	"fetch-app/problem"
+	"fetch-app/retention"
+	"fetch-app/review"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// roundReceipt returns the test receipt with the pizza's price corrected to make a round total, earning 95 points.
+func roundReceipt() server.Receipt {
+	receipt := testReceipt()
+	receipt.Items[1].Price = "12.51"
+	receipt.Total = "19.00"
+	return receipt
+}
+
+// put corrects a receipt with a replacement as an admin, sending the ETag in If-Match.
+func put(service *Service, receiptID, etag string, receipt server.Receipt) (server.CorrectedJSONResponse, error) {
+	request := server.PutReceiptsIdRequestObject{
+		Id:     receiptID,
+		Params: server.PutReceiptsIdParams{IfMatch: &etag},
+		Body:   &receipt,
+	}
+	response, err := service.PutReceiptsId(NewAdminContext(context.Background()), request)
+	if err != nil {
+		return server.CorrectedJSONResponse{}, err
+	}
+	return response.(server.PutReceiptsId200JSONResponse).CorrectedJSONResponse, nil
+}
+
+// TestCorrect tests that a correction keeps the earlier version and adjusts the points by the difference.
+func TestCorrect(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	service := NewService(NewStorage())
+	service.Audit = audit.NewLog(&buf)
+	receiptID := processAs(t, service, "alice", testReceipt())
+
+	got, err := service.GetReceiptsId(ctx, server.GetReceiptsIdRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	record := got.(server.GetReceiptsId200JSONResponse)
+	assert.Equal(t, `"1"`, record.Headers.ETag)
+	assert.Equal(t, 1, record.Body.Version)
+
+	// A round total earns 75 more points
+	receipt := roundReceipt()
+	corrected, err := put(service, receiptID, record.Headers.ETag, receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, `"2"`, corrected.Headers.ETag)
+	assert.Equal(t, 2, corrected.Body.Version)
+	assert.Equal(t, "19.00", corrected.Body.Receipt.Total)
+	assert.Equal(t, fraud.Fingerprint(receipt), corrected.Body.Fingerprint)
+	points, err := service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetReceiptsIdPoints200JSONResponse{Points: 95}, points)
+
+	// The version corrected is kept
+	response, err := service.GetReceiptsIdVersions(ctx, server.GetReceiptsIdVersionsRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	versions := response.(server.GetReceiptsIdVersions200JSONResponse)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, "18.74", versions[0].Receipt.Total)
+		assert.Equal(t, int64(20), versions[0].Points)
+		assert.Nil(t, versions[0].Adjustment)
+		assert.Equal(t, 2, versions[1].Version)
+		assert.Equal(t, int64(95), versions[1].Points)
+		assert.Equal(t, int64(75), *versions[1].Adjustment)
+	}
+
+	ledger, err := service.GetAdminLedger(ctx, server.GetAdminLedgerRequestObject{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(95), ledger.(server.GetAdminLedger200JSONResponse).Points)
+	assert.Equal(t, server.LedgerAdjustments{Corrections: 1, Points: 75}, ledger.(server.GetAdminLedger200JSONResponse).Adjustments)
+
+	entries := auditEntries(t, &buf)
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, audit.ActionReceiptCorrected, entries[2].Action)
+		assert.Equal(t, audit.ContentHash(corrected.Body.Receipt), entries[2].ContentHash)
+		assert.Equal(t, audit.ActionPointsRecomputed, entries[3].Action)
+		assert.Equal(t, int64(95), *entries[3].Points)
+		assert.Equal(t, "correction adjusted the points by +75", entries[3].Detail)
+	}
+
+	// The first version's ETag is stale now
+	_, err = put(service, receiptID, `"1"`, testReceipt())
+	assertProblem(t, err, http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	_, err = put(service, receiptID, `W/"2"`, testReceipt())
+	assertProblem(t, err, http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	_, err = put(service, receiptID, `"7", "2"`, testReceipt())
+	assert.NoError(t, err)
+}
+
+func TestCorrectProblems(t *testing.T) {
+	ctx := NewAdminContext(context.Background())
+	service := NewService(NewStorage())
+	receiptID := processAs(t, service, "alice", testReceipt())
+	receipt := testReceipt()
+
+	_, err := service.PutReceiptsId(ctx, server.PutReceiptsIdRequestObject{Id: receiptID, Body: &receipt})
+	assertProblem(t, err, http.StatusPreconditionRequired, problem.CodePreconditionRequired)
+	_, err = put(service, "not-an-id", "*", receipt)
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidReceiptID)
+	_, err = put(service, "0f8fad5b-d9cb-469f-a165-70867728950e", "*", receipt)
+	assertProblem(t, err, http.StatusNotFound, problem.CodeReceiptNotFound)
+
+	zone := "Mars/Olympus_Mons"
+	receipt.Timezone = &zone
+	_, err = put(service, receiptID, "*", receipt)
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidTimezone)
+	record, _ := service.Storage.Get(receiptID)
+	assert.Equal(t, 1, record.CurrentVersion())
+
+	// Rejected receipts and receipts whose items were purged cannot be corrected
+	setStatus := func(status review.Status) {
+		_, err := service.Storage.Update(receiptID, func(record *Record) error {
+			record.Status = status
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	setStatus(review.StatusRejected)
+	_, err = put(service, receiptID, "*", testReceipt())
+	assertProblem(t, err, http.StatusConflict, problem.CodeReceiptRejected)
+	setStatus(review.StatusApproved)
+	service.Retention = retention.Policy{ItemDetails: time.Hour}
+	service.Sweep(record.SubmittedAt.Add(time.Hour))
+	_, err = put(service, receiptID, "*", testReceipt())
+	assertProblem(t, err, http.StatusConflict, problem.CodeItemsPurged)
+}
+
+// TestCorrectAdmin tests that only admins can correct receipts, whoever submitted them.
+func TestCorrectAdmin(t *testing.T) {
+	service := NewService(NewStorage())
+	receiptID := processAs(t, service, "bob", testReceipt())
+	receipt := testReceipt()
+	etag := "*"
+
+	_, err := service.PutReceiptsId(context.Background(), server.PutReceiptsIdRequestObject{
+		Id:     receiptID,
+		Params: server.PutReceiptsIdParams{IfMatch: &etag},
+		Body:   &receipt,
+	})
+	assertProblem(t, err, http.StatusUnauthorized, problem.CodeUnauthorized)
+	patch := server.ReceiptPatch{"total": "18.75"}
+	_, err = service.PatchReceiptsId(context.Background(), server.PatchReceiptsIdRequestObject{
+		Id:     receiptID,
+		Params: server.PatchReceiptsIdParams{IfMatch: &etag},
+		Body:   &patch,
+	})
+	assertProblem(t, err, http.StatusUnauthorized, problem.CodeUnauthorized)
+
+	_, err = put(service, receiptID, etag, receipt)
+	assert.NoError(t, err)
+}
+
+// TestCorrectChecks tests that corrected receipts are checked for duplicates and reviewed like new submissions.
+func TestCorrectChecks(t *testing.T) {
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+	service.ReviewRules.MaxPoints = 50
+	other := testReceipt()
+	other.Total = "18.75"
+	otherID := processAs(t, service, "alice", other)
+	receiptID := processAs(t, service, "alice", testReceipt())
+
+	// Correcting a receipt into a duplicate of another is refused, and the original is still indexed
+	_, err := put(service, receiptID, "*", other)
+	p := assertProblem(t, err, http.StatusConflict, problem.CodeDuplicateReceipt)
+	assert.Equal(t, []string{otherID}, p.DuplicateOf)
+	assert.Equal(t, []string{receiptID}, service.Storage.Fingerprints.Lookup(fraud.Fingerprint(testReceipt())))
+
+	// A correction earning more than the review threshold is held for review again, awarding nothing meanwhile
+	corrected, err := put(service, receiptID, "*", roundReceipt())
+	assert.NoError(t, err)
+	assert.Equal(t, server.PendingReview, corrected.Body.Status)
+	assert.NotEmpty(t, corrected.Body.ReviewReasons)
+	record, _ := service.Storage.Get(receiptID)
+	assert.Equal(t, int64(-20), record.Correction.Adjustment)
+}
+
+func TestPatch(t *testing.T) {
+	ctx := NewAdminContext(context.Background())
+	service := NewService(NewStorage())
+	swagger, err := server.GetSwagger()
+	assert.NoError(t, err)
+	service.Validator, err = validation.New(swagger)
+	assert.NoError(t, err)
+	receiptID := processAs(t, service, "alice", testReceipt())
+
+	etag := `"1"`
+	patch := server.ReceiptPatch{"total": "18.75", "purchaseTime": "14:30", "timezone": nil}
+	response, err := service.PatchReceiptsId(ctx, server.PatchReceiptsIdRequestObject{
+		Id:     receiptID,
+		Params: server.PatchReceiptsIdParams{IfMatch: &etag},
+		Body:   &patch,
+	})
+	assert.NoError(t, err)
+	corrected := response.(server.PatchReceiptsId200JSONResponse)
+	expected := testReceipt()
+	expected.Total, expected.PurchaseTime = "18.75", "14:30"
+	assert.Equal(t, expected, corrected.Body.Receipt)
+
+	// The patched receipt must still be a valid one
+	etag = corrected.Headers.ETag
+	invalid := server.ReceiptPatch{"total": "nineteen", "items": nil}
+	_, err = service.PatchReceiptsId(ctx, server.PatchReceiptsIdRequestObject{
+		Id:     receiptID,
+		Params: server.PatchReceiptsIdParams{IfMatch: &etag},
+		Body:   &invalid,
+	})
+	p := assertProblem(t, err, http.StatusBadRequest, problem.CodeValidationFailed)
+	fields := make([]string, 0, len(p.Errors))
+	for _, field := range p.Errors {
+		fields = append(fields, field.Field)
+	}
+	assert.ElementsMatch(t, []string{"/total", "/items"}, fields)
+}
+
+func TestMergePatch(t *testing.T) {
+	document := map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e", "f": "g"}, "h": []interface{}{"i"}}
+	patch := map[string]interface{}{"a": "z", "c": map[string]interface{}{"f": nil}, "h": []interface{}{"j"}, "k": "l"}
+	assert.Equal(t, map[string]interface{}{"a": "z", "c": map[string]interface{}{"d": "e"}, "h": []interface{}{"j"}, "k": "l"},
+		mergePatch(document, patch))
+	assert.Equal(t, "x", mergePatch(document, "x"))
+}
+
+// TestSweepCorrectedVersions tests that purging the item details purges them from the earlier versions too.
+func TestSweepCorrectedVersions(t *testing.T) {
+	service := NewService(NewStorage())
+	service.Retention = retention.Policy{ItemDetails: time.Hour}
+	receiptID := processAs(t, service, "alice", testReceipt())
+	_, err := put(service, receiptID, `"1"`, testReceipt())
+	assert.NoError(t, err)
+
+	record, _ := service.Storage.Get(receiptID)
+	service.Sweep(record.SubmittedAt.Add(time.Hour))
+	record, _ = service.Storage.Get(receiptID)
+	assert.Equal(t, retention.Redacted, record.Versions[0].Receipt.Items[0].ShortDescription)
+	assert.Equal(t, "6.49", record.Versions[0].Receipt.Items[0].Price)
+}
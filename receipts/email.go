@@ -0,0 +1,81 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"fetch-app/email"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxEmailSize is the largest email message accepted, in bytes.
+const MaxEmailSize = 10 << 20
+
+// PostReceiptsProcessEmail reads a receipt from an email message and processes it like a submitted one. See
+// ProcessEmail for how the message is read.
+//
+// Returns:
+//
+//	The generated receipt ID, the receipt as read, the confidence in each of its fields, and the template it was
+//	read with.
+//	If the body is missing, too large or not a message with text, or the time zone is unknown, it returns a Bad
+//	Request (400) problem.
+//	If the receipt duplicates an earlier one and the duplicate policy rejects it, it returns a Conflict (409)
+//	problem.
+//	If a field cannot be found in the message, or the receipt read violates the Receipt schema, it returns an
+//	Unprocessable Entity (422) problem listing the fields at fault.
+func (s *Service) PostReceiptsProcessEmail(ctx context.Context, request server.PostReceiptsProcessEmailRequestObject) (server.PostReceiptsProcessEmailResponseObject, error) {
+	if request.Body == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request has no message")
+	}
+	principal := ""
+	if request.Params.XPrincipalId != nil {
+		principal = *request.Params.XPrincipalId
+	}
+	processed, err := s.ProcessEmail(request.Body, principal)
+	if err != nil {
+		return nil, err
+	}
+	return server.PostReceiptsProcessEmail200JSONResponse(processed), nil
+}
+
+// ProcessEmail reads the receipt from an RFC 822 message with the Email extractor and processes it like a
+// submitted one: with the template of the retailer that sent it, or like a text receipt otherwise.
+//
+// Parameters:
+//
+//	r         - The message, at most MaxEmailSize bytes.
+//	principal - Who the receipt is submitted by, or empty.
+//
+// Returns:
+//
+//	The generated receipt ID, the receipt as read, and the confidence in each of its fields, or the problem of
+//	PostReceiptsProcessEmail.
+func (s *Service) ProcessEmail(r io.Reader, principal string) (server.EmailReceipt, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxEmailSize+1))
+	if err != nil {
+		return server.EmailReceipt{}, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The message could not be read")
+	}
+	if len(data) > MaxEmailSize {
+		return server.EmailReceipt{}, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("The message is larger than %d bytes", MaxEmailSize))
+	}
+	message, err := email.Read(bytes.NewReader(data))
+	if err != nil {
+		return server.EmailReceipt{}, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid message: "+err.Error())
+	}
+
+	extraction, err := s.Email.Extract(message, s.Normalizer.Order)
+	id, err := s.processRead(extraction.Result, err, "message", principal)
+	if err != nil {
+		return server.EmailReceipt{}, err
+	}
+	processed := server.EmailReceipt{Id: id, Receipt: extraction.Receipt, Confidence: fieldConfidence(extraction.Confidence)}
+	if extraction.Template != "" {
+		processed.Template = &extraction.Template
+	}
+	return processed, nil
+}
@@ -0,0 +1,46 @@
+package receipts
+
+import (
+	"fetch-app/email"
+	"fetch-app/problem"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestProcessEmail tests that a receipt read from an email is stored and scored like a submitted one.
+func TestProcessEmail(t *testing.T) {
+	service := NewService(NewStorage())
+	templates, err := email.LoadTemplates("../email/testdata/templates.json")
+	assert.NoError(t, err)
+	service.Email = email.Extractor{Templates: templates}
+	message, err := os.Open("../email/testdata/target.eml")
+	assert.NoError(t, err)
+	defer message.Close()
+
+	processed, err := service.ProcessEmail(message, "user-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, processed.Template) {
+		assert.Equal(t, "Target", *processed.Template)
+	}
+	record, exists := service.Storage.Get(processed.Id)
+	if assert.True(t, exists) {
+		assert.Equal(t, "user-1", record.Principal)
+		assert.Equal(t, "Target", record.Receipt.Retailer)
+		assert.Equal(t, "18.74", record.Receipt.Total)
+	}
+}
+
+// TestProcessEmailInvalid tests that messages without text or without a receipt are refused.
+func TestProcessEmailInvalid(t *testing.T) {
+	service := NewService(NewStorage())
+
+	_, err := service.ProcessEmail(strings.NewReader("not a message"), "")
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidRequest)
+
+	_, err = service.ProcessEmail(strings.NewReader("From: Corner Market <a@example.com>\r\n\r\nThanks for shopping!\r\n"), "")
+	assertProblem(t, err, http.StatusUnprocessableEntity, problem.CodeReceiptUnreadable)
+	assert.Empty(t, service.Storage.Receipts)
+}
@@ -0,0 +1,331 @@
+package receipts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fetch-app/envelope"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SealedRecord is a record as an EncryptedBackend stores it: the whole record sealed in an envelope bound to its ID.
+type SealedRecord struct {
+	ID string `json:"id"`
+	envelope.Envelope
+}
+
+// stateFile is the file of the sealed storage state. It has no .json suffix, so it is never read as a record.
+const stateFile = "state.sealed"
+
+// EncryptedBackend persists each record as a file in a directory, encrypted at rest with envelope encryption.
+// Each record has its own data key, wrapped by the active master key of the keyring. The storage state is sealed
+// the same way in a file of its own.
+type EncryptedBackend struct {
+	dir  string
+	keys *envelope.Keyring
+
+	// Images are the receipt images, sealed under the same master keys, which are rotated along with the records.
+	// Nil if the images are not sealed.
+	Images *SealedBlobStore
+
+	// mu keeps a rotation from overwriting a record saved since the rotation read it.
+	mu sync.Mutex
+}
+
+// NewEncryptedBackend creates an EncryptedBackend storing records in dir, which is created if needed.
+//
+// Parameters:
+//
+//	dir  - The directory holding the records.
+//	keys - The master keys. New records are sealed under the active one.
+//
+// Returns:
+//
+//	The EncryptedBackend, or an error if the directory cannot be created.
+func NewEncryptedBackend(dir string, keys *envelope.Keyring) (*EncryptedBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+	return &EncryptedBackend{dir: dir, keys: keys}, nil
+}
+
+// Save seals the record under the active master key and writes it, replacing any earlier version.
+func (b *EncryptedBackend) Save(record *Record) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding record %s: %w", record.ID, err)
+	}
+	env, err := b.keys.Seal(payload, []byte(record.ID))
+	if err != nil {
+		return fmt.Errorf("sealing record %s: %w", record.ID, err)
+	}
+	sealed := SealedRecord{ID: record.ID, Envelope: env}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.write(sealed)
+}
+
+// Delete removes the file of the record.
+func (b *EncryptedBackend) Delete(id string) error {
+	path, err := b.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deleting record %s: %w", id, err)
+	}
+	return nil
+}
+
+// Load decrypts every stored record. It fails if any record cannot be opened, such as one sealed under a master
+// key that was removed from the key file before it was rotated.
+func (b *EncryptedBackend) Load() ([]*Record, error) {
+	sealed, err := b.readAll()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(sealed))
+	for _, s := range sealed {
+		payload, err := b.keys.Open(s.Envelope, []byte(s.ID))
+		if err != nil {
+			return nil, fmt.Errorf("opening record %s: %w", s.ID, err)
+		}
+		record := &Record{}
+		if err := json.Unmarshal(payload, record); err != nil {
+			return nil, fmt.Errorf("decoding record %s: %w", s.ID, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// SaveState seals the state under the active master key and writes it, replacing any earlier one.
+func (b *EncryptedBackend) SaveState(state *State) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	env, err := b.keys.Seal(payload, []byte(stateFile))
+	if err != nil {
+		return fmt.Errorf("sealing state: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writeState(env)
+}
+
+// LoadState decrypts the stored state, or returns nil if none was saved yet.
+func (b *EncryptedBackend) LoadState() (*State, error) {
+	env, err := b.readState()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	payload, err := b.keys.Open(env, []byte(stateFile))
+	if err != nil {
+		return nil, fmt.Errorf("opening state: %w", err)
+	}
+	state := &State{}
+	if err := json.Unmarshal(payload, state); err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	return state, nil
+}
+
+// Rotate rewraps the data keys of the records and the state sealed under a retired master key with the active one,
+// so the retired key can be removed from the key file. The payloads are not re-encrypted.
+//
+// Returns:
+//
+//	The number of records rewrapped, and the errors of those, or of the state, that could not be.
+func (b *EncryptedBackend) Rotate() (int, error) {
+	sealed, err := b.readAll()
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	var errs []error
+	for _, s := range sealed {
+		if s.KeyID == b.keys.Active() {
+			continue
+		}
+		if err := b.rewrap(s.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rotated++
+	}
+	errs = append(errs, b.rewrapState())
+	return rotated, errors.Join(errs...)
+}
+
+// RunRotation rotates the records and the images right away and then every interval until the context is
+// cancelled, so what was sealed before a new master key was added moves to it in the background.
+func (b *EncryptedBackend) RunRotation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		rotated, err := b.Rotate()
+		if err != nil {
+			log.Printf("Rotating master keys: %v", err)
+		}
+		images := 0
+		if b.Images != nil {
+			if images, err = b.Images.Rotate(); err != nil {
+				log.Printf("Rotating master keys of images: %v", err)
+			}
+		}
+		if rotated+images > 0 {
+			log.Printf("Rewrapped %d records and %d images under master key %s", rotated, images, b.keys.Active())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rewrap rereads one record under the lock, so a concurrent save is not overwritten, and rewraps its data key.
+func (b *EncryptedBackend) rewrap(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sealed, err := b.read(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if sealed.Envelope, err = b.keys.Rewrap(sealed.Envelope); err != nil {
+		return fmt.Errorf("rewrapping record %s: %w", id, err)
+	}
+	return b.write(sealed)
+}
+
+// rewrapState rereads the state under the lock and rewraps its data key if a retired master key wraps it.
+func (b *EncryptedBackend) rewrapState() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	env, err := b.readState()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if env.KeyID == b.keys.Active() {
+		return nil
+	}
+	if env, err = b.keys.Rewrap(env); err != nil {
+		return fmt.Errorf("rewrapping state: %w", err)
+	}
+	return b.writeState(env)
+}
+
+// readAll reads every stored record without opening it.
+func (b *EncryptedBackend) readAll() ([]SealedRecord, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading data directory: %w", err)
+	}
+	sealed := make([]SealedRecord, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		s, err := b.read(id)
+		if err != nil {
+			return nil, err
+		}
+		sealed = append(sealed, s)
+	}
+	return sealed, nil
+}
+
+// read reads one stored record without opening it.
+func (b *EncryptedBackend) read(id string) (SealedRecord, error) {
+	path, err := b.path(id)
+	if err != nil {
+		return SealedRecord{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SealedRecord{}, err
+	}
+	var sealed SealedRecord
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return SealedRecord{}, fmt.Errorf("decoding sealed record %s: %w", id, err)
+	}
+	return sealed, nil
+}
+
+// write replaces the file of a record atomically, so a crash never leaves a partly written record.
+func (b *EncryptedBackend) write(sealed SealedRecord) error {
+	path, err := b.path(sealed.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("encoding sealed record %s: %w", sealed.ID, err)
+	}
+	if err := replaceFile(path, data); err != nil {
+		return fmt.Errorf("writing record %s: %w", sealed.ID, err)
+	}
+	return nil
+}
+
+// readState reads the stored state without opening it.
+func (b *EncryptedBackend) readState() (envelope.Envelope, error) {
+	data, err := os.ReadFile(filepath.Join(b.dir, stateFile))
+	if err != nil {
+		return envelope.Envelope{}, err
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return envelope.Envelope{}, fmt.Errorf("decoding sealed state: %w", err)
+	}
+	return env, nil
+}
+
+// writeState replaces the file of the state atomically.
+func (b *EncryptedBackend) writeState(env envelope.Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("encoding sealed state: %w", err)
+	}
+	if err := replaceFile(filepath.Join(b.dir, stateFile), data); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+// replaceFile writes data to a temporary file and renames it over path, so a crash never leaves a partly written
+// file.
+func replaceFile(path string, data []byte) error {
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// path returns the file of a record, refusing IDs that would escape the directory.
+func (b *EncryptedBackend) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("record ID %q cannot be stored", id)
+	}
+	return filepath.Join(b.dir, id+".json"), nil
+}
@@ -0,0 +1,127 @@
+package receipts
+
+import (
+	"bytes"
+	"fetch-app/envelope"
+	"fetch-app/fraud"
+	"fetch-app/retention"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testKeys returns a keyring with a master key for each ID, the last one active. Key i is filled with the byte i+1.
+func testKeys(t *testing.T, ids ...string) *envelope.Keyring {
+	keys := make([][]byte, len(ids))
+	for i := range ids {
+		keys[i] = bytes.Repeat([]byte{byte(i + 1)}, envelope.KeySize)
+	}
+	keyring, err := envelope.NewKeyring(ids, keys)
+	if err != nil {
+		t.Fatalf("Error creating keyring: %v", err)
+	}
+	return keyring
+}
+
+// newEncryptedService returns a service whose storage persists to an encrypted backend in dir.
+func newEncryptedService(t *testing.T, dir string, keys *envelope.Keyring) (*Service, *EncryptedBackend) {
+	backend, err := NewEncryptedBackend(dir, keys)
+	if err != nil {
+		t.Fatalf("Error creating backend: %v", err)
+	}
+	storage := NewStorage()
+	storage.Backend = backend
+	if err := storage.Restore(); err != nil {
+		t.Fatalf("Error restoring storage: %v", err)
+	}
+	service := NewService(storage)
+	service.DuplicatePolicy = fraud.PolicyReject
+	return service, backend
+}
+
+// TestEncryptedBackend tests that records survive a restart without their contents being readable on disk.
+func TestEncryptedBackend(t *testing.T) {
+	dir := t.TempDir()
+	keys := testKeys(t, "k1")
+	service, _ := newEncryptedService(t, dir, keys)
+	receiptID := process(t, service, testReceipt())
+
+	data, err := os.ReadFile(filepath.Join(dir, receiptID+".json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "Target")
+	assert.NotContains(t, string(data), "18.74")
+	assert.NotContains(t, string(data), `"clear"`)
+
+	// A restarted service restores the record and its fingerprint
+	restarted, _ := newEncryptedService(t, dir, keys)
+	record, exists := restarted.Storage.Get(receiptID)
+	assert.True(t, exists)
+	assert.Equal(t, testReceipt().Items, record.Receipt.Items)
+	assert.Equal(t, []string{receiptID}, restarted.Storage.Fingerprints.Lookup(record.Fingerprint))
+
+	// Removed records are deleted from disk
+	_, _, err = restarted.Storage.Remove(func(record *Record) bool { return true }, func(record *Record) int64 { return 0 })
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, receiptID+".json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// TestEncryptedBackendState tests that the ledger, purge log and idempotency keys survive a restart encrypted.
+func TestEncryptedBackendState(t *testing.T) {
+	dir := t.TempDir()
+	keys := testKeys(t, "k1")
+	service, _ := newEncryptedService(t, dir, keys)
+	key := "retry-1"
+	receipt := testReceipt()
+	response, err := service.processOnce(receipt, "alice", &key)
+	assert.NoError(t, err)
+	keptID := response.(server.PostReceiptsProcess200JSONResponse).Id
+	removedID := process(t, service, roundReceipt())
+
+	removed, points, err := service.Storage.Remove(func(record *Record) bool {
+		return record.ID == removedID
+	}, func(record *Record) int64 { return 20 })
+	assert.NoError(t, err)
+	purge := retention.Purge{At: time.Now().UTC(), Reason: retention.ReasonExpired, ReceiptIDs: removed, Points: points}
+	assert.NoError(t, service.Storage.Audit(purge))
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), key)
+	assert.NotContains(t, string(data), removedID)
+
+	restarted, _ := newEncryptedService(t, dir, keys)
+	assert.Equal(t, retention.Ledger{Receipts: 1, Points: 20}, restarted.Storage.Anonymized)
+	assert.Len(t, restarted.Storage.AuditLog(), 1)
+	assert.Equal(t, removed, restarted.Storage.AuditLog()[0].ReceiptIDs)
+	submission, exists := restarted.Storage.Submission(key)
+	assert.True(t, exists)
+	assert.Equal(t, keptID, submission.ReceiptID)
+}
+
+// TestEncryptedBackendRotate tests that records move to a new master key, after which the old one can be dropped.
+func TestEncryptedBackendRotate(t *testing.T) {
+	dir := t.TempDir()
+	service, _ := newEncryptedService(t, dir, testKeys(t, "k1"))
+	receiptID := process(t, service, testReceipt())
+	assert.NoError(t, service.Storage.Audit(retention.Purge{Reason: retention.ReasonExpired, ReceiptIDs: []string{}}))
+
+	_, backend := newEncryptedService(t, dir, testKeys(t, "k1", "k2"))
+	rotated, err := backend.Rotate()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rotated)
+	rotated, err = backend.Rotate()
+	assert.NoError(t, err)
+	assert.Zero(t, rotated)
+
+	// Only the new key is needed from now on
+	k2, err := envelope.NewKeyring([]string{"k2"}, [][]byte{bytes.Repeat([]byte{2}, envelope.KeySize)})
+	assert.NoError(t, err)
+	restarted, _ := newEncryptedService(t, dir, k2)
+	_, exists := restarted.Storage.Get(receiptID)
+	assert.True(t, exists)
+	assert.Len(t, restarted.Storage.AuditLog(), 1)
+}
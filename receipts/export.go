@@ -0,0 +1,174 @@
+package receipts
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fetch-app/currency"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ExportedReceipt is a receipt record as exported, with the points it was awarded.
+type ExportedReceipt struct {
+	server.ReceiptRecord
+	Points int64 `json:"points"`
+}
+
+// receiptColumns are the CSV columns describing a receipt, which item rows repeat.
+var receiptColumns = []string{
+	"id", "principal", "status", "retailer", "purchaseDate", "purchaseTime",
+	"total", "subtotal", "tax", "tip", "currency", "exchangeRate", "points", "submittedAt",
+}
+
+// itemColumns are the CSV columns an item row adds after the receipt columns.
+var itemColumns = []string{"item", "shortDescription", "price"}
+
+// GetAdminReceiptsExport streams the stored receipts matching the filters as CSV or NDJSON, in ID order.
+// The records are read one at a time while the response is written, so the export is never held in memory.
+//
+// Returns:
+//
+//	The export (200), whose body is written as it is read.
+//	If the date range ends before it starts, or the format or rows are unknown, it returns a Bad Request (400)
+//	problem.
+func (s *Service) GetAdminReceiptsExport(ctx context.Context, request server.GetAdminReceiptsExportRequestObject) (server.GetAdminReceiptsExportResponseObject, error) {
+	params := request.Params
+	if params.From != nil && params.To != nil && params.To.Before(params.From.Time) {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+			fmt.Sprintf("The export ends on %s, before it starts on %s", params.To.String(), params.From.String()))
+	}
+	format := server.Csv
+	if params.Format != nil {
+		format = *params.Format
+	}
+	rows := server.ExportRowsReceipt
+	if params.Rows != nil {
+		rows = *params.Rows
+	}
+	if format != server.Csv && format != server.Ndjson {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("Unknown export format %q", format))
+	}
+	if rows != server.ExportRowsReceipt && rows != server.ExportRowsItem {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, fmt.Sprintf("Unknown export rows %q", rows))
+	}
+
+	match := func(record *Record) bool {
+		purchased := record.Receipt.PurchaseDate.Time
+		return (params.From == nil || !purchased.Before(params.From.Time)) &&
+			(params.To == nil || !purchased.After(params.To.Time)) &&
+			(params.Principal == nil || record.Principal == *params.Principal)
+	}
+
+	// The writer stops with an error once the response is closed, such as when the client goes away
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(s.Export(writer, format, rows, match))
+	}()
+	if format == server.Ndjson {
+		return server.GetAdminReceiptsExport200ApplicationxNdjsonResponse{Body: reader}, nil
+	}
+	return server.GetAdminReceiptsExport200TextcsvResponse{Body: reader}, nil
+}
+
+// Export writes the stored records matching match to w, in ID order, reading them from the store one at a time.
+// The IDs are those stored when it starts: records stored later are left out, and records removed before they are
+// reached are skipped.
+//
+// Parameters:
+//
+//	w      - Where the export is written.
+//	format - CSV with a header row, or NDJSON with an ExportedReceipt per line.
+//	rows   - Whether a CSV export has a row per receipt or a row per item. NDJSON ignores it.
+//	match  - Selects the records to export.
+//
+// Returns:
+//
+//	An error if writing failed, in which case the export is incomplete.
+func (s *Service) Export(w io.Writer, format server.ExportFormat, rows server.ExportRows, match func(record *Record) bool) error {
+	var write func(record *Record) error
+	var flush func() error
+	switch format {
+	case server.Ndjson:
+		encoder := json.NewEncoder(w)
+		write = func(record *Record) error {
+			return encoder.Encode(ExportedReceipt{ReceiptRecord: record.API(), Points: s.awarded(record)})
+		}
+		flush = func() error { return nil }
+	default:
+		csvWriter := csv.NewWriter(w)
+		header := receiptColumns
+		if rows == server.ExportRowsItem {
+			header = append(append([]string{}, receiptColumns...), itemColumns...)
+		}
+		if err := csvWriter.Write(header); err != nil {
+			return err
+		}
+		write = func(record *Record) error {
+			columns := s.csvColumns(record)
+			if rows != server.ExportRowsItem {
+				return csvWriter.Write(columns)
+			}
+			for i, item := range record.Receipt.Items {
+				row := append(append([]string{}, columns...), strconv.Itoa(i+1), item.ShortDescription, item.Price)
+				if err := csvWriter.Write(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	}
+
+	for i, id := range s.Storage.IDs() {
+		record, exists := s.Storage.Get(id)
+		if exists && match(record) {
+			if err := write(record); err != nil {
+				return err
+			}
+		}
+		// Send each page of records on, so the client receives the export as it is read
+		if (i+1)%MaxPageSize == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// csvColumns returns the receipt columns of a record's CSV rows.
+func (s *Service) csvColumns(record *Record) []string {
+	return []string{
+		record.ID,
+		record.Principal,
+		string(record.Status),
+		record.Receipt.Retailer,
+		record.Receipt.PurchaseDate.String(),
+		record.Receipt.PurchaseTime,
+		record.Receipt.Total,
+		optional(record.Receipt.Subtotal),
+		optional(record.Receipt.Tax),
+		optional(record.Receipt.Tip),
+		currency.Code(record.Receipt),
+		optional(record.Receipt.ExchangeRate),
+		strconv.FormatInt(s.awarded(record), 10),
+		record.SubmittedAt.Format(time.RFC3339),
+	}
+}
+
+// optional returns the value of an optional field, or an empty column if it is missing.
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,89 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// export runs an export through the service and returns the body.
+func export(t *testing.T, service *Service, params server.GetAdminReceiptsExportParams) string {
+	response, err := service.GetAdminReceiptsExport(context.Background(), server.GetAdminReceiptsExportRequestObject{Params: params})
+	if err != nil {
+		t.Fatalf("Error exporting receipts: %v", err)
+	}
+	var body io.Reader
+	switch response := response.(type) {
+	case server.GetAdminReceiptsExport200TextcsvResponse:
+		body = response.Body
+	case server.GetAdminReceiptsExport200ApplicationxNdjsonResponse:
+		body = response.Body
+	}
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+// TestExport tests exporting receipts as CSV and NDJSON with their points.
+func TestExport(t *testing.T) {
+	service := NewService(NewStorage())
+	receipt := testReceipt()
+	subtotal := "18.74"
+	receipt.Subtotal = &subtotal
+	alice := processAs(t, service, "alice", receipt)
+	later := testReceipt()
+	later.PurchaseDate = types.Date{Time: time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)}
+	bob := processAs(t, service, "bob", later)
+
+	rows := strings.Split(strings.TrimSpace(export(t, service, server.GetAdminReceiptsExportParams{})), "\n")
+	assert.Equal(t, "id,principal,status,retailer,purchaseDate,purchaseTime,total,subtotal,tax,tip,currency,exchangeRate,points,submittedAt", rows[0])
+	assert.Len(t, rows, 3)
+	assert.Contains(t, strings.Join(rows, "\n"), alice+",alice,approved,Target,2022-01-01,13:01,18.74,18.74,,,USD,,20,")
+
+	// A row per item repeats the receipt
+	itemRows := server.ExportRowsItem
+	principal := "alice"
+	rows = strings.Split(strings.TrimSpace(export(t, service, server.GetAdminReceiptsExportParams{Rows: &itemRows, Principal: &principal})), "\n")
+	assert.Len(t, rows, 3)
+	assert.True(t, strings.HasSuffix(rows[1], ",1,Mountain Dew 12PK,6.49"))
+	assert.True(t, strings.HasSuffix(rows[2], ",2,Emils Cheese Pizza,12.25"))
+
+	// NDJSON has a record per line, filtered by purchase date
+	ndjson := server.Ndjson
+	from := types.Date{Time: time.Date(2022, time.February, 1, 0, 0, 0, 0, time.UTC)}
+	lines := strings.Split(strings.TrimSpace(export(t, service, server.GetAdminReceiptsExportParams{Format: &ndjson, From: &from})), "\n")
+	assert.Len(t, lines, 1)
+	var exported ExportedReceipt
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Equal(t, bob, exported.Id)
+	assert.Equal(t, int64(service.Calculator.CalculatePoints(later)), exported.Points)
+}
+
+// TestExportPages tests that an export flushed several times lists every receipt once.
+func TestExportPages(t *testing.T) {
+	service := NewService(NewStorage())
+	for i := 0; i < MaxPageSize+5; i++ {
+		processAs(t, service, "alice", testReceipt())
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, service.Export(&buf, server.Csv, server.ExportRowsReceipt, func(record *Record) bool { return true }))
+	assert.Equal(t, MaxPageSize+6, strings.Count(buf.String(), "\n"))
+}
+
+// TestExportInvalidRange tests that a date range ending before it starts is rejected.
+func TestExportInvalidRange(t *testing.T) {
+	from := types.Date{Time: time.Date(2022, time.February, 1, 0, 0, 0, 0, time.UTC)}
+	to := types.Date{Time: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	_, err := NewService(NewStorage()).GetAdminReceiptsExport(context.Background(),
+		server.GetAdminReceiptsExportRequestObject{Params: server.GetAdminReceiptsExportParams{From: &from, To: &to}})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidRequest)
+}
@@ -0,0 +1,155 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fetch-app/audit"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxImageSize is the largest image that can be attached unless the service says otherwise, in bytes.
+const DefaultMaxImageSize = 10 << 20
+
+// imageTypes are the content types of the images that can be attached, as http.DetectContentType names them.
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// PutReceiptsIdImage attaches an image to a receipt, replacing any attached before. The content type is sniffed
+// from the image rather than taken from the request, so what is served back is what was actually stored. Only admins
+// may attach images, see requireAdmin.
+//
+// Returns:
+//
+//	The description of the attached image.
+//	If the ID is malformed, it returns a Bad Request (400) problem.
+//	If the caller is not an admin, it returns an Unauthorized (401) problem.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+//	If the image is larger than MaxImageSize, it returns a Request Entity Too Large (413) problem.
+//	If the body is not a JPEG, PNG, GIF or WebP image, it returns an Unsupported Media Type (415) problem.
+func (s *Service) PutReceiptsIdImage(ctx context.Context, request server.PutReceiptsIdImageRequestObject) (server.PutReceiptsIdImageResponseObject, error) {
+	if err := requireAdmin(ctx, "Attaching an image"); err != nil {
+		return nil, err
+	}
+	if _, err := s.get(request.Id); err != nil {
+		return nil, err
+	}
+	if s.Images == nil {
+		return nil, errors.New("no image store is configured")
+	}
+	maxSize := s.MaxImageSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxImageSize
+	}
+	if request.Body == nil {
+		return nil, problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedImage, "The request has no image")
+	}
+	content, err := io.ReadAll(io.LimitReader(request.Body, maxSize+1))
+	if err != nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The image could not be read")
+	}
+	if int64(len(content)) > maxSize {
+		return nil, problem.New(http.StatusRequestEntityTooLarge, problem.CodeImageTooLarge,
+			fmt.Sprintf("The image is larger than %d bytes", maxSize))
+	}
+	contentType := http.DetectContentType(content)
+	if !imageTypes[contentType] {
+		return nil, problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedImage,
+			fmt.Sprintf("The upload is %s, not a JPEG, PNG, GIF or WebP image", contentType))
+	}
+
+	sum := sha256.Sum256(content)
+	image := &Image{
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		UploadedAt:  time.Now().UTC(),
+	}
+	if err := s.Images.Put(request.Id, content); err != nil {
+		return nil, err
+	}
+	exists, err := s.Storage.Update(request.Id, func(record *Record) error {
+		record.Image = image
+		return nil
+	})
+	if !exists {
+		// The receipt was removed while the image was being stored
+		if err := s.Images.Delete(request.Id); err != nil {
+			log.Printf("Deleting image of removed receipt %s: %v", request.Id, err)
+		}
+		return nil, NotFound(request.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.appendAudit(audit.Entry{
+		Action:      audit.ActionImageAttached,
+		Principal:   audit.PrincipalAdmin,
+		ReceiptID:   request.Id,
+		ContentHash: audit.ContentHash(image),
+	}); err != nil {
+		return nil, err
+	}
+	return server.PutReceiptsIdImage200JSONResponse(image.API()), nil
+}
+
+// GetReceiptsIdImage returns the image attached to a receipt, with the content type sniffed when it was attached.
+// Only admins may read images, see requireAdmin.
+//
+// Returns:
+//
+//	The image.
+//	If the ID is malformed, it returns a Bad Request (400) problem.
+//	If the caller is not an admin, it returns an Unauthorized (401) problem.
+//	If the receipt does not exist or has no image, it returns a Not Found (404) problem.
+func (s *Service) GetReceiptsIdImage(ctx context.Context, request server.GetReceiptsIdImageRequestObject) (server.GetReceiptsIdImageResponseObject, error) {
+	if err := requireAdmin(ctx, "Reading an image"); err != nil {
+		return nil, err
+	}
+	record, err := s.get(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	image := record.Image
+	if image == nil || s.Images == nil {
+		return nil, problem.New(http.StatusNotFound, problem.CodeImageNotFound, fmt.Sprintf("Receipt with ID %s has no image", request.Id))
+	}
+	content, err := s.Images.Get(request.Id)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Image of receipt %s is missing from the image store", request.Id)
+		return nil, problem.New(http.StatusNotFound, problem.CodeImageNotFound, fmt.Sprintf("Receipt with ID %s has no image", request.Id))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return server.GetReceiptsIdImage200ImageResponse{
+		Body:          bytes.NewReader(content),
+		ContentType:   image.ContentType,
+		ContentLength: int64(len(content)),
+	}, nil
+}
+
+// deleteImages removes the images of receipts from the image store, logging the failures.
+func (s *Service) deleteImages(ids []string) {
+	if s.Images == nil {
+		return
+	}
+	for _, id := range ids {
+		if err := s.Images.Delete(id); err != nil {
+			log.Printf("Deleting image of receipt %s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"fetch-app/envelope"
+	"fetch-app/problem"
+	"fetch-app/retention"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testImage returns a small PNG image.
+func testImage(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("Error encoding image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// attach attaches content to a receipt as an admin, declared as a JPEG whatever it is.
+func attach(service *Service, receiptID string, content []byte) (server.PutReceiptsIdImageResponseObject, error) {
+	return service.PutReceiptsIdImage(NewAdminContext(context.Background()), server.PutReceiptsIdImageRequestObject{
+		Id:          receiptID,
+		ContentType: "image/jpeg",
+		Body:        bytes.NewReader(content),
+	})
+}
+
+// TestImage tests attaching an image and reading it back with the content type sniffed from it.
+func TestImage(t *testing.T) {
+	ctx := NewAdminContext(context.Background())
+	service := NewService(NewStorage())
+	receiptID := process(t, service, testReceipt())
+	content := testImage(t)
+
+	_, err := service.GetReceiptsIdImage(ctx, server.GetReceiptsIdImageRequestObject{Id: receiptID})
+	assertProblem(t, err, http.StatusNotFound, problem.CodeImageNotFound)
+
+	response, err := attach(service, receiptID, content)
+	assert.NoError(t, err)
+	attached := response.(server.PutReceiptsIdImage200JSONResponse)
+	assert.Equal(t, "image/png", attached.ContentType)
+	assert.Equal(t, int64(len(content)), attached.Size)
+	assert.Len(t, attached.Sha256, 64)
+
+	record, err := service.GetReceiptsId(ctx, server.GetReceiptsIdRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Equal(t, server.ReceiptImage(attached), *record.(server.GetReceiptsId200JSONResponse).Body.Image)
+
+	image, err := service.GetReceiptsIdImage(ctx, server.GetReceiptsIdImageRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	served := image.(server.GetReceiptsIdImage200ImageResponse)
+	assert.Equal(t, "image/png", served.ContentType)
+	body, err := io.ReadAll(served.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, content, body)
+}
+
+// TestImageRefused tests that only admins handle images, and the size limit, content sniffing and unknown receipts.
+func TestImageRefused(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.MaxImageSize = 64
+	receiptID := process(t, service, testReceipt())
+
+	_, err := service.PutReceiptsIdImage(ctx, server.PutReceiptsIdImageRequestObject{
+		Id:          receiptID,
+		ContentType: "image/png",
+		Body:        bytes.NewReader(testImage(t)),
+	})
+	assertProblem(t, err, http.StatusUnauthorized, problem.CodeUnauthorized)
+	_, err = service.GetReceiptsIdImage(ctx, server.GetReceiptsIdImageRequestObject{Id: receiptID})
+	assertProblem(t, err, http.StatusUnauthorized, problem.CodeUnauthorized)
+
+	_, err = attach(service, receiptID, append(testImage(t), make([]byte, 64)...))
+	assertProblem(t, err, http.StatusRequestEntityTooLarge, problem.CodeImageTooLarge)
+	_, err = attach(service, receiptID, []byte("<html><body>not an image</body></html>"))
+	assertProblem(t, err, http.StatusUnsupportedMediaType, problem.CodeUnsupportedImage)
+	_, err = attach(service, "2b2d8024-acb6-4eaa-9ed4-dcae58dd0331", testImage(t))
+	assertProblem(t, err, http.StatusNotFound, problem.CodeReceiptNotFound)
+
+	record, _ := service.Storage.Get(receiptID)
+	assert.Nil(t, record.Image)
+}
+
+// TestImagePurged tests that purging the item details or the record deletes the image too.
+func TestImagePurged(t *testing.T) {
+	service := NewService(NewStorage())
+	service.Retention = retention.Policy{ItemDetails: 24 * time.Hour, Records: 48 * time.Hour}
+	receiptID := process(t, service, testReceipt())
+	record, _ := service.Storage.Get(receiptID)
+	_, err := attach(service, receiptID, testImage(t))
+	assert.NoError(t, err)
+
+	service.Sweep(record.SubmittedAt.Add(24 * time.Hour))
+	record, _ = service.Storage.Get(receiptID)
+	assert.Nil(t, record.Image)
+	_, err = service.Images.Get(receiptID)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// An image attached after the purge goes with the record
+	_, err = attach(service, receiptID, testImage(t))
+	assert.NoError(t, err)
+	service.Sweep(record.SubmittedAt.Add(48 * time.Hour))
+	_, err = service.Images.Get(receiptID)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// TestSealedBlobStore tests that images are stored encrypted on disk and can only be read under their own key.
+func TestSealedBlobStore(t *testing.T) {
+	dir := t.TempDir()
+	files, err := NewFileBlobStore(dir)
+	assert.NoError(t, err)
+	store := &SealedBlobStore{Store: files, Keys: testKeys(t, "k1")}
+	content := testImage(t)
+
+	assert.NoError(t, store.Put("a", content))
+	onDisk, err := os.ReadFile(filepath.Join(dir, "a"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(onDisk), "PNG")
+	read, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, content, read)
+
+	// A blob moved to another key does not open
+	assert.NoError(t, os.Rename(filepath.Join(dir, "a"), filepath.Join(dir, "b")))
+	_, err = store.Get("b")
+	assert.Error(t, err)
+
+	assert.NoError(t, store.Delete("b"))
+	assert.NoError(t, store.Delete("b"))
+	_, err = store.Get("b")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Error(t, files.Put("../escape", content))
+}
+
+// TestSealedBlobStoreRotate tests that images move to a new master key, after which the old one can be dropped.
+func TestSealedBlobStoreRotate(t *testing.T) {
+	files, err := NewFileBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	content := testImage(t)
+	assert.NoError(t, (&SealedBlobStore{Store: files, Keys: testKeys(t, "k1")}).Put("a", content))
+
+	store := &SealedBlobStore{Store: files, Keys: testKeys(t, "k1", "k2")}
+	rotated, err := store.Rotate()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rotated)
+	rotated, err = store.Rotate()
+	assert.NoError(t, err)
+	assert.Zero(t, rotated)
+
+	// Only the new key is needed from now on
+	k2, err := envelope.NewKeyring([]string{"k2"}, [][]byte{bytes.Repeat([]byte{2}, envelope.KeySize)})
+	assert.NoError(t, err)
+	read, err := (&SealedBlobStore{Store: files, Keys: k2}).Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, content, read)
+}
@@ -0,0 +1,124 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fetch-app/importer"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// MaxImportSize is the largest CSV file an import accepts, in bytes.
+const MaxImportSize = 10 << 20
+
+// PostReceiptsImport imports the receipts in an uploaded CSV file. The file is read from the "file" part, and the
+// optional "mapping" part maps fields to headers. See Import for how the file is read.
+//
+// Returns:
+//
+//	The report of the receipts imported and the rows left out.
+//	If the upload has no file, the file is too large or not CSV, its header lacks a mapped column, or the mapping
+//	is invalid, it returns a Bad Request (400) problem and imports nothing.
+func (s *Service) PostReceiptsImport(ctx context.Context, request server.PostReceiptsImportRequestObject) (server.PostReceiptsImportResponseObject, error) {
+	if request.Body == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request has no file")
+	}
+	var file []byte
+	mapping := importer.DefaultMapping()
+	for {
+		part, err := request.Body.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request is not a valid upload")
+		}
+		switch part.FormName() {
+		case "file":
+			if file, err = io.ReadAll(io.LimitReader(part, MaxImportSize+1)); err != nil {
+				return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The file could not be read")
+			}
+			if len(file) > MaxImportSize {
+				return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest,
+					fmt.Sprintf("The file is larger than %d bytes", MaxImportSize))
+			}
+		case "mapping":
+			value, err := io.ReadAll(io.LimitReader(part, 64<<10))
+			if err != nil {
+				return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The mapping could not be read")
+			}
+			if mapping, err = importer.ParseMapping(string(value)); err != nil {
+				return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "Invalid mapping: "+err.Error())
+			}
+		}
+	}
+	if file == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request has no file")
+	}
+
+	principal := ""
+	if request.Params.XPrincipalId != nil {
+		principal = *request.Params.XPrincipalId
+	}
+	report, err := s.Import(bytes.NewReader(file), mapping, principal)
+	if err != nil {
+		return nil, err
+	}
+	return server.PostReceiptsImport200JSONResponse(report), nil
+}
+
+// Import reads the receipts from a CSV file, where each row is an item and the rows of a receipt share a receipt
+// key, and processes each like a submitted receipt. A receipt with an invalid row, one that processing refuses,
+// such as a rejected duplicate, or one that fails to be stored is left out and reported on its rows; the others
+// are stored. Once the file is read, every row is accounted for in the report, since earlier receipts may already
+// be stored when a later one fails.
+//
+// Parameters:
+//
+//	r         - The CSV file, with a header row.
+//	mapping   - Maps fields to the headers of their columns.
+//	principal - Who the receipts are submitted by, or empty.
+//
+// Returns:
+//
+//	The report of the receipts imported and the rows left out, in row order.
+//	If the file is not CSV or its header lacks a mapped column, it returns a Bad Request (400) problem and imports
+//	nothing.
+func (s *Service) Import(r io.Reader, mapping importer.Mapping, principal string) (server.ImportReport, error) {
+	reader := importer.Reader{Mapping: mapping, Validator: s.Validator, Normalizer: s.Normalizer}
+	groups, rowErrors, err := reader.Read(r)
+	if err != nil {
+		return server.ImportReport{}, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The file cannot be imported: "+err.Error())
+	}
+
+	report := server.ImportReport{Imported: make([]server.ImportedReceipt, 0, len(groups)), Errors: rowErrors}
+	for _, group := range groups {
+		response, err := s.process(group.Receipt, principal)
+		if err != nil {
+			var p *problem.Problem
+			message := "The receipt could not be stored"
+			if errors.As(err, &p) && p.Code != problem.CodeInternal {
+				message = p.Detail
+			} else {
+				log.Printf("Importing receipt %s: %v", group.Key, err)
+			}
+			report.Errors = append(report.Errors, server.RowError{Row: group.Rows[0], Receipt: group.Key, Message: message})
+			continue
+		}
+		processed := response.(server.PostReceiptsProcess200JSONResponse)
+		report.Imported = append(report.Imported, server.ImportedReceipt{Receipt: group.Key, Id: processed.Id, Rows: group.Rows})
+	}
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Row < report.Errors[j].Row
+	})
+	if report.Errors == nil {
+		report.Errors = make([]server.RowError, 0)
+	}
+	return report, nil
+}
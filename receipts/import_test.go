@@ -0,0 +1,71 @@
+package receipts
+
+import (
+	"fetch-app/fraud"
+	"fetch-app/importer"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestImport tests that imported receipts are processed like submitted ones, and that refused ones are reported.
+func TestImport(t *testing.T) {
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+	file := "receipt,retailer,purchaseDate,purchaseTime,total,shortDescription,price\n" +
+		"1,Target,2022-01-01,13:01,18.74,Mountain Dew 12PK,6.49\n" +
+		"1,Target,2022-01-01,13:01,18.74,Emils Cheese Pizza,12.25\n" +
+		"2,Target,2022-01-01,13:01,18.74,Mountain Dew 12PK,6.49\n" +
+		"2,Target,2022-01-01,13:01,18.74,Emils Cheese Pizza,12.25\n"
+
+	report, err := service.Import(strings.NewReader(file), importer.DefaultMapping(), "partner-1")
+	assert.NoError(t, err)
+	if assert.Len(t, report.Imported, 1) {
+		assert.Equal(t, "1", report.Imported[0].Receipt)
+		assert.Equal(t, []int{2, 3}, report.Imported[0].Rows)
+		record, exists := service.Storage.Get(report.Imported[0].Id)
+		assert.True(t, exists)
+		assert.Equal(t, "partner-1", record.Principal)
+		assert.Equal(t, testReceipt().Items, record.Receipt.Items)
+	}
+
+	// The second receipt duplicates the first, which the policy rejects
+	assert.Equal(t, []server.RowError{{Row: 4, Receipt: "2", Message: "Receipt has already been submitted"}}, report.Errors)
+
+	_, err = service.Import(strings.NewReader("retailer\n"), importer.DefaultMapping(), "")
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidRequest)
+}
+
+// flakyBackend persists the first record and fails to persist any other.
+type flakyBackend struct {
+	brokenBackend
+	saved bool
+}
+
+func (b *flakyBackend) Save(record *Record) error {
+	if b.saved {
+		return b.brokenBackend.Save(record)
+	}
+	b.saved = true
+	return nil
+}
+
+// TestImportFailure tests that a receipt that fails to be stored is reported on its rows, along with those stored
+// before it.
+func TestImportFailure(t *testing.T) {
+	service := NewService(NewStorage())
+	service.Storage.Backend = &flakyBackend{}
+	file := "receipt,retailer,purchaseDate,purchaseTime,total,shortDescription,price\n" +
+		"1,Target,2022-01-01,13:01,6.49,Mountain Dew 12PK,6.49\n" +
+		"2,Walgreens,2022-01-01,13:01,12.25,Emils Cheese Pizza,12.25\n"
+
+	report, err := service.Import(strings.NewReader(file), importer.DefaultMapping(), "")
+	assert.NoError(t, err)
+	if assert.Len(t, report.Imported, 1) {
+		assert.Equal(t, "1", report.Imported[0].Receipt)
+	}
+	assert.Equal(t, []server.RowError{{Row: 3, Receipt: "2", Message: "The receipt could not be stored"}}, report.Errors)
+}
@@ -0,0 +1,226 @@
+package receipts
+
+import (
+	"context"
+	"fetch-app/audit"
+	"fetch-app/retention"
+	"fetch-app/review"
+	"fetch-app/server"
+	"log"
+	"time"
+)
+
+// Sweep purges the receipt data the retention policy no longer allows keeping: it removes the records that
+// expired, then redacts the item details that outlived their retention, freezing the score first so the points
+// survive. Each purge is recorded in the purge log, and each receipt it touched in the audit log.
+//
+// Parameters:
+//
+//	now - The time the retention periods are measured up to.
+//
+// Returns:
+//
+//	The audit records of the purges, which is empty if nothing was due.
+func (s *Service) Sweep(now time.Time) []retention.Purge {
+	purges := make([]retention.Purge, 0)
+
+	expired, points, err := s.remove(func(record *Record) bool {
+		return s.Retention.Expire(record.SubmittedAt, now)
+	}, audit.PrincipalSystem, retention.ReasonExpired)
+	if err != nil {
+		log.Printf("Removing expired receipts: %v", err)
+	}
+	if len(expired) > 0 {
+		purges = append(purges, retention.Purge{At: now, Reason: retention.ReasonExpired, ReceiptIDs: expired, Points: points})
+	}
+
+	// What each purge awarded and deleted only counts once its record was persisted
+	awarded := make(map[string]int64)
+	changes := make(map[string][]audit.Entry)
+	withImage := make(map[string]bool)
+	redacted, err := s.Storage.UpdateWhere(func(record *Record) bool {
+		return record.ItemsPurgedAt == nil && s.Retention.PurgeItems(record.SubmittedAt, now)
+	}, func(record *Record) {
+		if record.Breakdown == nil {
+			record.Breakdown = s.Calculator.Breakdown(record.Receipt)
+		}
+		points := s.awarded(record)
+		awarded[record.ID] = points
+		changes[record.ID] = []audit.Entry{{
+			Action:      audit.ActionPointsRecomputed,
+			Principal:   audit.PrincipalSystem,
+			ReceiptID:   record.ID,
+			ContentHash: audit.ContentHash(record.Breakdown),
+			Points:      &points,
+			Detail:      string(retention.ReasonItemDetails),
+		}}
+
+		// Keep the prices, which are needed to check the total, and drop what was bought, in every version
+		record.Receipt.Items = redactItems(record.Receipt.Items)
+		for i := range record.Versions {
+			record.Versions[i].Receipt.Items = redactItems(record.Versions[i].Receipt.Items)
+		}
+		purgedAt := now
+		record.ItemsPurgedAt = &purgedAt
+
+		// The image shows what was bought too
+		if record.Image != nil {
+			withImage[record.ID] = true
+			changes[record.ID] = append(changes[record.ID], audit.Entry{
+				Action:      audit.ActionImageDeleted,
+				Principal:   audit.PrincipalSystem,
+				ReceiptID:   record.ID,
+				ContentHash: audit.ContentHash(record.Image),
+				Detail:      string(retention.ReasonItemDetails),
+			})
+			record.Image = nil
+		}
+	})
+	if err != nil {
+		log.Printf("Persisting purged item details: %v", err)
+	}
+	points = 0
+	var entries []audit.Entry
+	var images []string
+	for _, id := range redacted {
+		points += awarded[id]
+		entries = append(entries, changes[id]...)
+		if withImage[id] {
+			images = append(images, id)
+		}
+	}
+	s.appendAudit(entries...)
+	s.deleteImages(images)
+	if len(redacted) > 0 {
+		purges = append(purges, retention.Purge{At: now, Reason: retention.ReasonItemDetails, ReceiptIDs: redacted, Points: points})
+	}
+
+	for _, purge := range purges {
+		if err := s.Storage.Audit(purge); err != nil {
+			log.Printf("Persisting purge log: %v", err)
+		}
+	}
+	return purges
+}
+
+// redactItems returns the items with only their prices.
+func redactItems(items []server.Item) []server.Item {
+	redacted := make([]server.Item, len(items))
+	for i, item := range items {
+		redacted[i] = server.Item{ShortDescription: retention.Redacted, Price: item.Price}
+	}
+	return redacted
+}
+
+// RunSweeper sweeps the store every interval until the context is cancelled.
+func (s *Service) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, purge := range s.Sweep(time.Now().UTC()) {
+				log.Printf("Purged %s of %d receipts", purge.Reason, len(purge.ReceiptIDs))
+			}
+		}
+	}
+}
+
+// DeleteAdminPrincipalsPrincipalReceipts erases every receipt submitted by a principal. The points they were
+// awarded move to the anonymized ledger totals, and the erasure is audited under a hash of the principal, even if
+// the principal had no receipts.
+//
+// Returns:
+//
+//	The audit record of the erasure.
+func (s *Service) DeleteAdminPrincipalsPrincipalReceipts(ctx context.Context, request server.DeleteAdminPrincipalsPrincipalReceiptsRequestObject) (server.DeleteAdminPrincipalsPrincipalReceiptsResponseObject, error) {
+	erased, points, err := s.remove(func(record *Record) bool {
+		return record.Principal == request.Principal
+	}, audit.PrincipalAdmin, retention.ReasonErasure)
+
+	purge := retention.Purge{
+		At:         time.Now().UTC(),
+		Reason:     retention.ReasonErasure,
+		Principal:  retention.HashPrincipal(s.PrincipalKey, request.Principal),
+		ReceiptIDs: erased,
+		Points:     points,
+	}
+	if auditErr := s.Storage.Audit(purge); auditErr != nil {
+		log.Printf("Persisting purge log: %v", auditErr)
+	}
+	if err != nil {
+		// The receipts that could not be deleted are kept, so the erasure has to be retried
+		return nil, err
+	}
+	return server.DeleteAdminPrincipalsPrincipalReceipts200JSONResponse(purgeAPI(purge)), nil
+}
+
+// GetAdminLedger totals the receipts and the points awarded to them, including the anonymized totals of the
+// receipts that were purged, and the adjustments the corrections to the stored receipts made.
+func (s *Service) GetAdminLedger(ctx context.Context, request server.GetAdminLedgerRequestObject) (server.GetAdminLedgerResponseObject, error) {
+	stored, anonymized := s.Storage.Totals(s.awarded)
+	corrections, adjusted := s.Storage.Adjustments()
+	return server.GetAdminLedger200JSONResponse{
+		Receipts:    stored.Receipts + anonymized.Receipts,
+		Points:      stored.Points + anonymized.Points,
+		Anonymized:  server.LedgerTotals{Receipts: anonymized.Receipts, Points: anonymized.Points},
+		Adjustments: server.LedgerAdjustments{Corrections: corrections, Points: adjusted},
+	}, nil
+}
+
+// GetAdminPurges lists the purge audit log, oldest first.
+func (s *Service) GetAdminPurges(ctx context.Context, request server.GetAdminPurgesRequestObject) (server.GetAdminPurgesResponseObject, error) {
+	purges := s.Storage.AuditLog()
+	response := make(server.GetAdminPurges200JSONResponse, 0, len(purges))
+	for _, purge := range purges {
+		response = append(response, purgeAPI(purge))
+	}
+	return response, nil
+}
+
+// remove removes the records matching match from the store, recording each deletion in the audit log with the
+// principal and reason. See Storage.Remove for the results.
+func (s *Service) remove(match func(record *Record) bool, principal string, reason retention.Reason) ([]string, int64, error) {
+	var entries []audit.Entry
+	var images []string
+	removed, points, err := s.Storage.Remove(match, func(record *Record) int64 {
+		if record.Image != nil {
+			images = append(images, record.ID)
+		}
+		awarded := s.awarded(record)
+		entries = append(entries, audit.Entry{
+			Action:      audit.ActionReceiptDeleted,
+			Principal:   principal,
+			ReceiptID:   record.ID,
+			ContentHash: audit.ContentHash(record.Receipt),
+			Points:      &awarded,
+			Detail:      string(reason),
+		})
+		return awarded
+	})
+	s.appendAudit(entries...)
+	s.deleteImages(images)
+	return removed, points, err
+}
+
+// awarded returns the points a record has been awarded: none until it is approved, and none for a duplicate under
+// the zero points policy.
+func (s *Service) awarded(record *Record) int64 {
+	if record.Status != review.StatusApproved || record.ZeroPoints {
+		return 0
+	}
+	return s.points(record)
+}
+
+// purgeAPI converts an audit record into the model served by the API.
+func purgeAPI(purge retention.Purge) server.Purge {
+	return server.Purge{
+		At:         purge.At,
+		Reason:     server.PurgeReason(purge.Reason),
+		Principal:  purge.Principal,
+		ReceiptIds: purge.ReceiptIDs,
+		Points:     purge.Points,
+	}
+}
@@ -0,0 +1,107 @@
+package receipts
+
+import (
+	"context"
+	"fetch-app/fraud"
+	"fetch-app/retention"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// processAs submits the receipt on behalf of the principal and returns its ID.
+func processAs(t *testing.T, service *Service, principal string, receipt server.Receipt) string {
+	request := server.PostReceiptsProcessRequestObject{Params: server.PostReceiptsProcessParams{XPrincipalId: &principal}, Body: &receipt}
+	response, err := service.PostReceiptsProcess(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Error processing receipt: %v", err)
+	}
+	return response.(server.PostReceiptsProcess200JSONResponse).Id
+}
+
+// TestSweepItemDetails tests that purging the item details keeps the points and breakdown.
+func TestSweepItemDetails(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.Retention = retention.Policy{ItemDetails: 30 * 24 * time.Hour}
+	receiptID := process(t, service, testReceipt())
+	record, _ := service.Storage.Get(receiptID)
+
+	// Nothing is due before the retention period ends
+	assert.Empty(t, service.Sweep(record.SubmittedAt.AddDate(0, 0, 29)))
+
+	now := record.SubmittedAt.AddDate(0, 0, 30)
+	purges := service.Sweep(now)
+	assert.Equal(t, []retention.Purge{{At: now, Reason: retention.ReasonItemDetails, ReceiptIDs: []string{receiptID}, Points: 20}}, purges)
+	assert.Equal(t, purges, service.Storage.AuditLog())
+	record, _ = service.Storage.Get(receiptID)
+	assert.Equal(t, []server.Item{{ShortDescription: retention.Redacted, Price: "6.49"}, {ShortDescription: retention.Redacted, Price: "12.25"}},
+		record.Receipt.Items)
+	assert.Equal(t, &now, record.ItemsPurgedAt)
+
+	response, err := service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetReceiptsIdPoints200JSONResponse{Points: 20}, response)
+	breakdown, err := service.GetReceiptsIdBreakdown(ctx, server.GetReceiptsIdBreakdownRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), breakdown.(server.GetReceiptsIdBreakdown200JSONResponse).Points)
+
+	// Purged item details are not purged again
+	assert.Empty(t, service.Sweep(now.AddDate(0, 0, 1)))
+}
+
+// TestSweepExpired tests that expired records are removed while the ledger keeps their points.
+func TestSweepExpired(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+	service.Retention = retention.Policy{Records: 365 * 24 * time.Hour}
+	receiptID := process(t, service, testReceipt())
+	record, _ := service.Storage.Get(receiptID)
+
+	purges := service.Sweep(record.SubmittedAt.AddDate(1, 0, 0))
+	assert.Len(t, purges, 1)
+	assert.Equal(t, retention.ReasonExpired, purges[0].Reason)
+	assert.Empty(t, service.Storage.Receipts)
+
+	ledger, err := service.GetAdminLedger(ctx, server.GetAdminLedgerRequestObject{})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetAdminLedger200JSONResponse{Receipts: 1, Points: 20, Anonymized: server.LedgerTotals{Receipts: 1, Points: 20}}, ledger)
+
+	// The removed receipt no longer counts as an original for the duplicate check
+	process(t, service, testReceipt())
+}
+
+// TestErasure tests that erasing a principal removes only their receipts and audits it without naming them.
+func TestErasure(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyZeroPoints
+	erasedID := processAs(t, service, "user-1", testReceipt())
+	keptID := processAs(t, service, "user-2", testReceipt())
+
+	response, err := service.DeleteAdminPrincipalsPrincipalReceipts(ctx, server.DeleteAdminPrincipalsPrincipalReceiptsRequestObject{Principal: "user-1"})
+	assert.NoError(t, err)
+	purge := response.(server.DeleteAdminPrincipalsPrincipalReceipts200JSONResponse)
+	assert.Equal(t, server.Erasure, purge.Reason)
+	assert.Equal(t, []string{erasedID}, purge.ReceiptIds)
+	assert.Equal(t, int64(20), purge.Points)
+	assert.Equal(t, retention.HashPrincipal(service.PrincipalKey, "user-1"), purge.Principal)
+
+	_, exists := service.Storage.Get(erasedID)
+	assert.False(t, exists)
+	kept, exists := service.Storage.Get(keptID)
+	assert.True(t, exists)
+	assert.Equal(t, "user-2", kept.Principal)
+
+	// The kept duplicate still earns nothing, and the erased receipt's points stay in the totals
+	ledger, err := service.GetAdminLedger(ctx, server.GetAdminLedgerRequestObject{})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetAdminLedger200JSONResponse{Receipts: 2, Points: 20, Anonymized: server.LedgerTotals{Receipts: 1, Points: 20}}, ledger)
+
+	purges, err := service.GetAdminPurges(ctx, server.GetAdminPurgesRequestObject{})
+	assert.NoError(t, err)
+	assert.Len(t, purges, 1)
+	assert.NotContains(t, purges.(server.GetAdminPurges200JSONResponse)[0].Principal, "user-1")
+}
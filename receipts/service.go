@@ -0,0 +1,567 @@
+package receipts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fetch-app/audit"
+	"fetch-app/calculation"
+	"fetch-app/currency"
+	"fetch-app/email"
+	"fetch-app/fraud"
+	"fetch-app/ids"
+	"fetch-app/normalize"
+	"fetch-app/problem"
+	"fetch-app/retention"
+	"fetch-app/review"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service implements the receipt API independently of any HTTP framework. It takes the typed request objects
+// generated from the spec and returns the typed response for the outcome, so it can be mounted on Echo or
+// net/http, or called directly.
+//
+// Failures are returned as *problem.Problem errors, which the HTTP adapters render as problem responses.
+type Service struct {
+	// Storage holds the submitted receipts.
+	Storage *Storage
+	// DuplicatePolicy decides what happens to a receipt that was already submitted, possibly by someone else.
+	DuplicatePolicy fraud.Policy
+	// ReviewRules decides which receipts are held for manual review before their points are awarded.
+	ReviewRules review.Rules
+	// Timezones supplies the time zone of receipts that do not carry one.
+	Timezones calculation.TimezoneDefaults
+	// Normalizer rewrites the purchase dates and times of receipts into their canonical forms, however they were
+	// submitted, imported or read.
+	Normalizer normalize.Normalizer
+	// Calculator scores receipts. The zero value applies the original ASCII rules.
+	Calculator calculation.Calculator
+	// Rates supplies the exchange rate of receipts in other currencies than the base one that do not carry one.
+	// Nil scores them in their own currency.
+	Rates *currency.Rates
+	// IDs generates the IDs of new receipts. The zero value generates random UUIDs.
+	IDs ids.Strategy
+	// Retention decides how long raw receipt data is kept. The zero value keeps it forever.
+	Retention retention.Policy
+	// Validator checks imported receipts and receipts read from text against the Receipt schema, like the HTTP API
+	// does for submitted ones. Nil skips the check.
+	Validator *validation.Validator
+	// Images holds the images attached to receipts, keyed by receipt ID. It is required to attach images.
+	Images BlobStore
+	// MaxImageSize is the largest image that can be attached, in bytes. Zero allows DefaultMaxImageSize.
+	MaxImageSize int64
+	// Email reads the receipts from email messages, with the templates of the retailers that send e-receipts.
+	Email email.Extractor
+	// Audit records every change to the receipts in a tamper-evident log. Nil records nothing.
+	Audit *audit.Log
+	// PrincipalKey is the secret the audit log and purge log hash principals with, so their hashes cannot be
+	// reversed by hashing guessed principals.
+	PrincipalKey []byte
+
+	// idempotency serializes submissions carrying an Idempotency-Key, so a key is never assigned two receipts.
+	idempotency sync.Mutex
+}
+
+// NewService creates a Service over the storage with the default configuration.
+func NewService(storage *Storage) *Service {
+	return &Service{
+		Storage:     storage,
+		ReviewRules: review.Rules{MaxPoints: review.DefaultMaxPoints},
+		Images:      NewMemoryBlobStore(),
+	}
+}
+
+const (
+	// DefaultPageSize is how many records a page lists when the request does not say.
+	DefaultPageSize = 20
+	// MaxPageSize is the most records a page lists.
+	MaxPageSize = 100
+)
+
+var _ server.StrictServerInterface = (*Service)(nil)
+
+// PostReceiptsProcess stores a new receipt under a unique ID.
+// A submission with an Idempotency-Key that succeeded before returns the ID it was assigned without storing the
+// receipt again, so clients can safely retry.
+//
+// Returns:
+//
+//	The generated receipt ID if successful, along with the fields the HTTP middleware rewrote into their canonical
+//	form, which it passes in the context.
+//	If the body is missing, the time zone is unknown, or the currency is unknown or does not match the decimals of
+//	the amounts, it returns a Bad Request (400) problem.
+//	If the receipt duplicates an earlier one and the duplicate policy rejects it, it returns a Conflict (409)
+//	problem listing the matching receipt IDs.
+//	If the Idempotency-Key was used for a different receipt, it returns an Unprocessable Entity (422) problem.
+func (s *Service) PostReceiptsProcess(ctx context.Context, request server.PostReceiptsProcessRequestObject) (server.PostReceiptsProcessResponseObject, error) {
+	if request.Body == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request body is not a valid receipt")
+	}
+	principal := ""
+	if request.Params.XPrincipalId != nil {
+		principal = *request.Params.XPrincipalId
+	}
+	response, err := s.processOnce(*request.Body, principal, request.Params.IdempotencyKey)
+	if processed, ok := response.(server.PostReceiptsProcess200JSONResponse); ok {
+		if changes := normalize.FromContext(ctx); len(changes) > 0 {
+			processed.Normalized = &changes
+			response = processed
+		}
+	}
+	return response, err
+}
+
+// processOnce processes a receipt, or returns the ID it was assigned if it was already processed under the
+// idempotency key. A nil key processes the receipt every time.
+func (s *Service) processOnce(receipt server.Receipt, principal string, idempotencyKey *string) (server.PostReceiptsProcessResponseObject, error) {
+	if idempotencyKey == nil {
+		return s.process(receipt, principal)
+	}
+	key := *idempotencyKey
+
+	// Hash the body as submitted, so a retry matches even though processing fills in the time zone
+	body, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(body)
+	bodyHash := hex.EncodeToString(hash[:])
+
+	s.idempotency.Lock()
+	defer s.idempotency.Unlock()
+	if submission, exists := s.Storage.Submission(key); exists {
+		if submission.BodyHash != bodyHash {
+			return nil, problem.New(http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused,
+				fmt.Sprintf("Idempotency-Key %s was already used for a different receipt", key))
+		}
+		return server.PostReceiptsProcess200JSONResponse{Id: submission.ReceiptID}, nil
+	}
+
+	// Only successful submissions are remembered, so a failed one can be retried with the same key
+	response, err := s.process(receipt, principal)
+	if processed, ok := response.(server.PostReceiptsProcess200JSONResponse); ok && err == nil {
+		submission := Submission{BodyHash: bodyHash, ReceiptID: processed.Id, At: time.Now().UTC()}
+		if err := s.Storage.Remember(key, submission); err != nil {
+			// The receipt is stored, so it is still a success; only a retry after a restart would store it again
+			log.Printf("Persisting Idempotency-Key of receipt %s: %v", processed.Id, err)
+		}
+	}
+	return response, err
+}
+
+// process stores a new receipt submitted by the principal under a unique ID. See PostReceiptsProcess for the
+// failures.
+func (s *Service) process(receipt server.Receipt, principal string) (server.PostReceiptsProcessResponseObject, error) {
+	if err := s.prepare(&receipt); err != nil {
+		return nil, err
+	}
+
+	// Generate a unique ID for the receipt and check its fingerprint against earlier submissions
+	receiptID := s.IDs.New()
+	fingerprint := fraud.Fingerprint(receipt)
+	matches, accepted := s.Storage.Fingerprints.Register(fingerprint, receiptID, s.DuplicatePolicy)
+	if !accepted {
+		conflict := problem.New(http.StatusConflict, problem.CodeDuplicateReceipt, "Receipt has already been submitted")
+		conflict.DuplicateOf = matches
+		return nil, conflict
+	}
+
+	// Store the receipt along with the outcome of the duplicate check
+	record := &Record{
+		ID:          receiptID,
+		Receipt:     receipt,
+		SubmittedAt: time.Now().UTC(),
+		Fingerprint: fingerprint,
+		DuplicateOf: matches,
+		Status:      review.StatusApproved,
+		Principal:   principal,
+	}
+	if purchasedAt, err := calculation.PurchaseTimestamp(receipt); err == nil {
+		record.PurchasedAt = &purchasedAt
+	}
+	flagged := []string(nil)
+	if len(matches) > 0 {
+		record.ZeroPoints = s.DuplicatePolicy == fraud.PolicyZeroPoints
+		if s.DuplicatePolicy == fraud.PolicyFlag {
+			flagged = matches
+		}
+	}
+
+	// Score the receipt once, so its points do not change when the rules do
+	record.Breakdown = s.Calculator.Breakdown(receipt)
+
+	// Hold suspicious receipts for a human decision before their points are awarded
+	if !record.ZeroPoints {
+		record.ReviewReasons = s.ReviewRules.Check(receipt, int(s.points(record)), flagged)
+		if len(record.ReviewReasons) > 0 {
+			record.Status = review.StatusPendingReview
+		}
+	}
+	if err := s.Storage.Put(record); err != nil {
+		s.Storage.Fingerprints.Remove(fingerprint, receiptID)
+		return nil, err
+	}
+	submitter := s.auditPrincipal(principal)
+	entries := []audit.Entry{{
+		Action:      audit.ActionReceiptCreated,
+		Principal:   submitter,
+		ReceiptID:   receiptID,
+		ContentHash: audit.ContentHash(receipt),
+	}}
+	if record.Status == review.StatusApproved {
+		entries = append(entries, s.awardEntry(record, submitter))
+	}
+	if err := s.appendAudit(entries...); err != nil {
+		return nil, err
+	}
+
+	return server.PostReceiptsProcess200JSONResponse{Id: receiptID}, nil
+}
+
+// prepare rewrites the purchase time of a receipt about to be stored into its canonical form, checks what the schema
+// cannot, and fills in the exchange rate and time zone it is scored with. Every receipt stored passes through it,
+// however it arrived.
+//
+// Returns:
+//
+//	A Bad Request (400) problem if the receipt carries an exchange rate, if the currency is unknown or does not match
+//	the decimals of the amounts, or if the time zone is unknown.
+func (s *Service) prepare(receipt *server.Receipt) error {
+	s.Normalizer.Receipt(receipt)
+
+	// A client-chosen rate would let it scale the item prices it is scored at, so only the server's rates are used
+	if receipt.ExchangeRate != nil {
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "The exchange rate is set by the server")
+		p.Errors = []validation.FieldError{{Field: "/exchangeRate", Message: "is taken from the server's rates and cannot be submitted"}}
+		return p
+	}
+
+	// Amounts are written in the receipt's currency, which the schema cannot check
+	if errs := currency.Check(*receipt); len(errs) > 0 {
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "The amounts do not match the receipt's currency")
+		p.Errors = errs
+		return p
+	}
+
+	// Store the exchange rate the receipt is scored at, so later changes to the rates do not change its points
+	if rate := s.Rates.Resolve(*receipt); rate != "" {
+		receipt.ExchangeRate = &rate
+	}
+
+	// Store the time zone the receipt is evaluated in, inheriting the retailer's or the default if it has none
+	if zone := s.Timezones.Resolve(*receipt); zone != "" {
+		if _, err := calculation.ParseLocation(zone); err != nil {
+			return problem.New(http.StatusBadRequest, problem.CodeInvalidTimezone, err.Error())
+		}
+		receipt.Timezone = &zone
+	}
+	return nil
+}
+
+// GetReceiptsId returns the stored record for a receipt.
+// The record includes the receipt itself, its fingerprint and the IDs of any earlier receipts it duplicates.
+//
+// Returns:
+//
+//	The receipt record if the receipt exists, with the ETag of its current version.
+//	If the ID is malformed, it returns a Bad Request (400) problem.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+func (s *Service) GetReceiptsId(ctx context.Context, request server.GetReceiptsIdRequestObject) (server.GetReceiptsIdResponseObject, error) {
+	record, err := s.get(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	return server.GetReceiptsId200JSONResponse{
+		Body:    record.API(),
+		Headers: server.GetReceiptsId200ResponseHeaders{ETag: record.ETag()},
+	}, nil
+}
+
+// GetReceiptsIdPoints returns the points awarded for a receipt.
+//
+// Returns:
+//
+//	The points (200) if the receipt exists and has been approved, or its status (202) while it is pending review.
+//	If the ID is malformed, it returns a Bad Request (400) problem.
+//	If the receipt was rejected, it returns a Conflict (409) problem with the rejection reason.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+func (s *Service) GetReceiptsIdPoints(ctx context.Context, request server.GetReceiptsIdPointsRequestObject) (server.GetReceiptsIdPointsResponseObject, error) {
+	record, err := s.get(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Points only become visible once the receipt has been approved
+	switch record.Status {
+	case review.StatusPendingReview:
+		return server.GetReceiptsIdPoints202JSONResponse{Status: server.Status(record.Status)}, nil
+	case review.StatusRejected:
+		return nil, problem.New(http.StatusConflict, problem.CodeReceiptRejected,
+			fmt.Sprintf("Receipt with ID %s was rejected: %s", request.Id, record.Decision.Reason))
+	}
+
+	// Duplicates under the zero points policy earn nothing
+	points := int64(0)
+	if !record.ZeroPoints {
+		points = s.points(record)
+	}
+	return server.GetReceiptsIdPoints200JSONResponse{Points: points}, nil
+}
+
+// GetReceiptsIdBreakdown explains the points awarded for a receipt rule by rule.
+// A duplicate under the zero points policy lists what each rule would award followed by a "duplicate" entry that
+// cancels them, so the rules always add up to the points.
+//
+// Returns:
+//
+//	The breakdown (200) if the receipt exists and has been approved, or its status (202) while it is pending review.
+//	If the ID is malformed, it returns a Bad Request (400) problem.
+//	If the receipt was rejected, it returns a Conflict (409) problem with the rejection reason.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+func (s *Service) GetReceiptsIdBreakdown(ctx context.Context, request server.GetReceiptsIdBreakdownRequestObject) (server.GetReceiptsIdBreakdownResponseObject, error) {
+	record, err := s.get(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Points only become visible once the receipt has been approved
+	switch record.Status {
+	case review.StatusPendingReview:
+		return server.GetReceiptsIdBreakdown202JSONResponse{Status: server.Status(record.Status)}, nil
+	case review.StatusRejected:
+		return nil, problem.New(http.StatusConflict, problem.CodeReceiptRejected,
+			fmt.Sprintf("Receipt with ID %s was rejected: %s", request.Id, record.Decision.Reason))
+	}
+
+	breakdown := server.PointsBreakdown{Rules: make([]server.RulePoints, 0)}
+	for _, rule := range s.breakdown(record) {
+		breakdown.Rules = append(breakdown.Rules, server.RulePoints{Rule: rule.Rule, Description: rule.Description, Points: rule.Points})
+		breakdown.Points += int64(rule.Points)
+	}
+
+	// Duplicates under the zero points policy earn nothing
+	if record.ZeroPoints {
+		breakdown.Rules = append(breakdown.Rules, server.RulePoints{
+			Rule:        "duplicate",
+			Description: "A duplicate of an earlier receipt earns no points",
+			Points:      int(-breakdown.Points),
+		})
+		breakdown.Points = 0
+	}
+	return server.GetReceiptsIdBreakdown200JSONResponse(breakdown), nil
+}
+
+// GetReceipts lists the stored receipt records submitted by the caller a page at a time, in ID order. The page ends
+// with a cursor to pass as after for the next one.
+//
+// Returns:
+//
+//	The page of records.
+//	If the cursor is malformed, it returns a Bad Request (400) problem.
+func (s *Service) GetReceipts(ctx context.Context, request server.GetReceiptsRequestObject) (server.GetReceiptsResponseObject, error) {
+	after := ""
+	if request.Params.After != nil {
+		after = *request.Params.After
+		if !ids.Valid(after) {
+			return nil, InvalidID(after)
+		}
+	}
+	limit := DefaultPageSize
+	if request.Params.Limit != nil && *request.Params.Limit > 0 {
+		limit = min(*request.Params.Limit, MaxPageSize)
+	}
+
+	records, more := s.Storage.Page(request.Params.XPrincipalId, after, limit)
+	page := server.ReceiptPage{Receipts: make([]server.ReceiptRecord, 0, len(records))}
+	for _, record := range records {
+		page.Receipts = append(page.Receipts, record.API())
+	}
+	if more {
+		next := records[len(records)-1].ID
+		page.Next = &next
+	}
+	return server.GetReceipts200JSONResponse(page), nil
+}
+
+// GetAdminReviewQueue lists the receipts awaiting review, oldest submission first.
+func (s *Service) GetAdminReviewQueue(ctx context.Context, request server.GetAdminReviewQueueRequestObject) (server.GetAdminReviewQueueResponseObject, error) {
+	pending := s.Storage.ListByStatus(review.StatusPendingReview)
+	records := make(server.GetAdminReviewQueue200JSONResponse, 0, len(pending))
+	for _, record := range pending {
+		records = append(records, record.API())
+	}
+	return records, nil
+}
+
+// PostAdminReceiptsIdApprove approves a receipt awaiting review, which makes its points visible.
+// A reason may be given in the request body. See decide for the failures.
+func (s *Service) PostAdminReceiptsIdApprove(ctx context.Context, request server.PostAdminReceiptsIdApproveRequestObject) (server.PostAdminReceiptsIdApproveResponseObject, error) {
+	record, err := s.decide(request.Id, review.StatusApproved, request.Body)
+	if err != nil {
+		return nil, err
+	}
+	return server.PostAdminReceiptsIdApprove200JSONResponse{DecidedJSONResponse: server.DecidedJSONResponse(record)}, nil
+}
+
+// PostAdminReceiptsIdReject rejects a receipt awaiting review, which means its points are never awarded.
+// A reason must be given in the request body. See decide for the failures.
+func (s *Service) PostAdminReceiptsIdReject(ctx context.Context, request server.PostAdminReceiptsIdRejectRequestObject) (server.PostAdminReceiptsIdRejectResponseObject, error) {
+	record, err := s.decide(request.Id, review.StatusRejected, request.Body)
+	if err != nil {
+		return nil, err
+	}
+	return server.PostAdminReceiptsIdReject200JSONResponse{DecidedJSONResponse: server.DecidedJSONResponse(record)}, nil
+}
+
+// decide records an admin decision on a receipt awaiting review.
+//
+// Returns:
+//
+//	The updated receipt record if successful.
+//	If the ID is malformed or a rejection has no reason, it returns a Bad Request (400) problem.
+//	If the receipt does not exist, it returns a Not Found (404) problem.
+//	If the receipt is not awaiting review, it returns a Conflict (409) problem.
+func (s *Service) decide(id string, status review.Status, body *server.ReviewDecision) (server.ReceiptRecord, error) {
+	if !ids.Valid(id) {
+		return server.ReceiptRecord{}, InvalidID(id)
+	}
+	var reason string
+	if body != nil {
+		reason = body.Reason
+	}
+	if status == review.StatusRejected && strings.TrimSpace(reason) == "" {
+		return server.ReceiptRecord{}, problem.New(http.StatusBadRequest, problem.CodeReasonRequired,
+			"A reason is required to reject a receipt")
+	}
+
+	var updated server.ReceiptRecord
+	var entries []audit.Entry
+	exists, err := s.Storage.Update(id, func(record *Record) error {
+		if err := review.Transition(record.Status, status); err != nil {
+			return err
+		}
+		record.Status = status
+		record.Decision = &review.Decision{
+			Status:    status,
+			Reason:    reason,
+			DecidedAt: time.Now().UTC(),
+		}
+		updated = record.API()
+
+		action := audit.ActionReceiptApproved
+		if status == review.StatusRejected {
+			action = audit.ActionReceiptRejected
+		}
+		entries = []audit.Entry{{
+			Action:      action,
+			Principal:   audit.PrincipalAdmin,
+			ReceiptID:   id,
+			ContentHash: audit.ContentHash(record.Decision),
+			Detail:      reason,
+		}}
+		if status == review.StatusApproved {
+			entries = append(entries, s.awardEntry(record, audit.PrincipalAdmin))
+		}
+		return nil
+	})
+	if !exists {
+		return server.ReceiptRecord{}, NotFound(id)
+	}
+	if errors.Is(err, review.ErrInvalidTransition) {
+		return server.ReceiptRecord{}, problem.New(http.StatusConflict, problem.CodeNotPendingReview,
+			fmt.Sprintf("Receipt with ID %s is not awaiting review", id))
+	}
+	if err != nil {
+		return server.ReceiptRecord{}, err
+	}
+	if err := s.appendAudit(entries...); err != nil {
+		return server.ReceiptRecord{}, err
+	}
+	return updated, nil
+}
+
+// awardEntry returns the audit entry for the points awarded to a record on its approval.
+func (s *Service) awardEntry(record *Record, principal string) audit.Entry {
+	points := s.awarded(record)
+	return audit.Entry{
+		Action:      audit.ActionPointsAwarded,
+		Principal:   principal,
+		ReceiptID:   record.ID,
+		ContentHash: audit.ContentHash(record.Receipt),
+		Points:      &points,
+	}
+}
+
+// auditPrincipal returns how the audit log identifies a submitter: by the hash their erasure is recorded under, since
+// the log outlives an erasure and must not keep who they were. Anonymous changes stay anonymous.
+func (s *Service) auditPrincipal(principal string) string {
+	if principal == "" {
+		return ""
+	}
+	return retention.HashPrincipal(s.PrincipalKey, principal)
+}
+
+// appendAudit appends entries to the audit log, if there is one. The change they record has already been made, so
+// a failure is returned for the caller to report rather than undoing it.
+func (s *Service) appendAudit(entries ...audit.Entry) error {
+	if s.Audit == nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if _, err := s.Audit.Append(entry); err != nil {
+			log.Printf("Appending to the audit log: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// breakdown returns the score of a record rule by rule as it was stored when the record was scored. Records stored
+// before their score was kept are scored now.
+func (s *Service) breakdown(record *Record) []calculation.RulePoints {
+	if record.Breakdown != nil {
+		return record.Breakdown
+	}
+	return s.Calculator.Breakdown(record.Receipt)
+}
+
+// points adds up the breakdown of a record.
+func (s *Service) points(record *Record) int64 {
+	points := int64(0)
+	for _, rule := range s.breakdown(record) {
+		points += int64(rule.Points)
+	}
+	return points
+}
+
+// get looks up the record for a receipt ID, rejecting malformed IDs before the lookup.
+func (s *Service) get(id string) (*Record, error) {
+	if !ids.Valid(id) {
+		return nil, InvalidID(id)
+	}
+	record, exists := s.Storage.Get(id)
+	if !exists {
+		return nil, NotFound(id)
+	}
+	return record, nil
+}
+
+// InvalidID returns the Bad Request (400) problem for a receipt ID that no receipt can have.
+func InvalidID(id string) *problem.Problem {
+	return problem.New(http.StatusBadRequest, problem.CodeInvalidReceiptID,
+		fmt.Sprintf("Receipt ID %q is neither a UUID nor a ULID", id))
+}
+
+// NotFound returns the Not Found (404) problem for a receipt ID that is not stored.
+func NotFound(id string) *problem.Problem {
+	return problem.New(http.StatusNotFound, problem.CodeReceiptNotFound, fmt.Sprintf("Receipt with ID %s not found", id))
+}
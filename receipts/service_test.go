@@ -0,0 +1,337 @@
+package receipts
+
+import (
+	"context"
+	"errors"
+	"fetch-app/currency"
+	"fetch-app/fraud"
+	"fetch-app/ids"
+	"fetch-app/problem"
+	"fetch-app/review"
+	"fetch-app/script"
+	"fetch-app/server"
+	"github.com/google/uuid"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testReceipt returns a receipt earning 20 points, which is not held for review.
+func testReceipt() server.Receipt {
+	return server.Receipt{
+		Retailer:     "Target",
+		PurchaseDate: types.Date{Time: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "13:01",
+		Items: []server.Item{
+			{ShortDescription: "Mountain Dew 12PK", Price: "6.49"},
+			{ShortDescription: "Emils Cheese Pizza", Price: "12.25"},
+		},
+		Total: "18.74",
+	}
+}
+
+// process submits the receipt directly to the service and returns its ID.
+func process(t *testing.T, service *Service, receipt server.Receipt) string {
+	response, err := service.PostReceiptsProcess(context.Background(), server.PostReceiptsProcessRequestObject{Body: &receipt})
+	if err != nil {
+		t.Fatalf("Error processing receipt: %v", err)
+	}
+	return response.(server.PostReceiptsProcess200JSONResponse).Id
+}
+
+// assertProblem checks that err is a problem with the given status and code.
+func assertProblem(t *testing.T, err error, status int, code problem.Code) *problem.Problem {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		t.Fatalf("Expected a problem, got %v", err)
+	}
+	assert.Equal(t, status, p.Status)
+	assert.Equal(t, code, p.Code)
+	return p
+}
+
+// TestServicePoints tests the typed responses of the points operation through the review workflow.
+func TestServicePoints(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	receiptID := process(t, service, testReceipt())
+
+	response, err := service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetReceiptsIdPoints200JSONResponse{Points: 20}, response)
+
+	// The resubmission is flagged, so its points are withheld until it is decided
+	duplicateID := process(t, service, testReceipt())
+	response, err = service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: duplicateID})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetReceiptsIdPoints202JSONResponse{Status: server.PendingReview}, response)
+
+	reason := server.ReviewDecision{Reason: "Same photo"}
+	decided, err := service.PostAdminReceiptsIdReject(ctx, server.PostAdminReceiptsIdRejectRequestObject{Id: duplicateID, Body: &reason})
+	assert.NoError(t, err)
+	record := decided.(server.PostAdminReceiptsIdReject200JSONResponse)
+	assert.Equal(t, server.Rejected, record.Status)
+	assert.Equal(t, []string{receiptID}, record.DuplicateOf)
+
+	_, err = service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: duplicateID})
+	p := assertProblem(t, err, http.StatusConflict, problem.CodeReceiptRejected)
+	assert.Contains(t, p.Detail, "Same photo")
+
+	_, err = service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: uuid.New().String()})
+	assertProblem(t, err, http.StatusNotFound, problem.CodeReceiptNotFound)
+}
+
+// TestServiceProcessProblems tests the problems returned when a receipt cannot be stored.
+func TestServiceProcessProblems(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+
+	_, err := service.PostReceiptsProcess(ctx, server.PostReceiptsProcessRequestObject{})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidRequest)
+
+	receipt := testReceipt()
+	zone := "Atlantis/Capital"
+	receipt.Timezone = &zone
+	_, err = service.PostReceiptsProcess(ctx, server.PostReceiptsProcessRequestObject{Body: &receipt})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidTimezone)
+
+	originalID := process(t, service, testReceipt())
+	receipt = testReceipt()
+	_, err = service.PostReceiptsProcess(ctx, server.PostReceiptsProcessRequestObject{Body: &receipt})
+	p := assertProblem(t, err, http.StatusConflict, problem.CodeDuplicateReceipt)
+	assert.Equal(t, []string{originalID}, p.DuplicateOf)
+}
+
+// TestServiceNormalizes tests that the purchase time of every receipt stored is rewritten into its canonical form,
+// whichever way it arrived.
+func TestServiceNormalizes(t *testing.T) {
+	service := NewService(NewStorage())
+	receipt := testReceipt()
+	receipt.PurchaseTime = "1:01 PM"
+	receiptID := process(t, service, receipt)
+
+	record, _ := service.Storage.Get(receiptID)
+	assert.Equal(t, "13:01", record.Receipt.PurchaseTime)
+}
+
+// TestServiceCurrency tests that amounts are checked against the receipt's currency and that the exchange rate a
+// receipt is scored at is stored with it.
+func TestServiceCurrency(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	rates, err := currency.NewRates("USD", map[string]string{"JPY": "150"})
+	assert.NoError(t, err)
+	service.Rates = rates
+
+	receipt := testReceipt()
+	code := "JPY"
+	receipt.Currency = &code
+	_, err = service.PostReceiptsProcess(ctx, server.PostReceiptsProcessRequestObject{Body: &receipt})
+	p := assertProblem(t, err, http.StatusBadRequest, problem.CodeValidationFailed)
+	assert.Len(t, p.Errors, 3)
+	assert.Equal(t, "/total", p.Errors[0].Field)
+
+	code = "XYZ"
+	_, err = service.PostReceiptsProcess(ctx, server.PostReceiptsProcessRequestObject{Body: &receipt})
+	p = assertProblem(t, err, http.StatusBadRequest, problem.CodeValidationFailed)
+	assert.Equal(t, "/currency", p.Errors[0].Field)
+
+	// 1500 yen is 10 dollars, so the description rule awards 2 points rather than 300
+	code = "JPY"
+	receipt.Total = "1500"
+	receipt.Items = []server.Item{{ShortDescription: "Gum", Price: "1500"}}
+	id := process(t, service, receipt)
+	record := service.Storage.Receipts[id]
+	if assert.NotNil(t, record.Receipt.ExchangeRate) {
+		assert.Equal(t, "150", *record.Receipt.ExchangeRate)
+	}
+	assert.Equal(t, 2, service.breakdown(record)[4].Points)
+
+	// A later rate does not change the points of stored receipts
+	service.Rates, err = currency.NewRates("USD", map[string]string{"JPY": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, service.breakdown(record)[4].Points)
+
+	// Receipts cannot carry their own rate, in the base currency or any other
+	rate := "0.01"
+	for _, code := range []string{"USD", "JPY"} {
+		receipt := testReceipt()
+		if code != "USD" {
+			receipt.Total = "1500"
+			receipt.Items = []server.Item{{ShortDescription: "Gum", Price: "1500"}}
+		}
+		receipt.Currency = &code
+		receipt.ExchangeRate = &rate
+		_, err = service.PostReceiptsProcess(ctx, server.PostReceiptsProcessRequestObject{Body: &receipt})
+		p = assertProblem(t, err, http.StatusBadRequest, problem.CodeValidationFailed)
+		assert.Equal(t, "/exchangeRate", p.Errors[0].Field, code)
+	}
+}
+
+// TestServiceIdempotencyKey tests that a retried submission returns the original ID without storing it again.
+func TestServiceIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+
+	key := "retry-1"
+	receipt := testReceipt()
+	request := server.PostReceiptsProcessRequestObject{Params: server.PostReceiptsProcessParams{IdempotencyKey: &key}, Body: &receipt}
+	first, err := service.PostReceiptsProcess(ctx, request)
+	assert.NoError(t, err)
+	retried, err := service.PostReceiptsProcess(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, first, retried)
+	assert.Len(t, service.Storage.Receipts, 1)
+
+	// The key cannot be reused for another receipt
+	other := testReceipt()
+	other.Total = "20.00"
+	request.Body = &other
+	_, err = service.PostReceiptsProcess(ctx, request)
+	assertProblem(t, err, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused)
+
+	// Once it expired, it can, and remembering the new receipt forgets the expired submission
+	submission := service.Storage.IdempotencyKeys[key]
+	submission.At = submission.At.Add(-DefaultIdempotencyTTL)
+	service.Storage.IdempotencyKeys[key] = submission
+	reused, err := service.PostReceiptsProcess(ctx, request)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, reused)
+	assert.Len(t, service.Storage.Receipts, 2)
+	assert.Len(t, service.Storage.IdempotencyKeys, 1)
+}
+
+// TestServiceBreakdown tests that the breakdown adds up to the points, including for a zero points duplicate.
+func TestServiceBreakdown(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.DuplicatePolicy = fraud.PolicyZeroPoints
+	receiptID := process(t, service, testReceipt())
+
+	response, err := service.GetReceiptsIdBreakdown(ctx, server.GetReceiptsIdBreakdownRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	breakdown := response.(server.GetReceiptsIdBreakdown200JSONResponse)
+	assert.Equal(t, int64(20), breakdown.Points)
+	assert.Len(t, breakdown.Rules, 7)
+
+	duplicateID := process(t, service, testReceipt())
+	response, err = service.GetReceiptsIdBreakdown(ctx, server.GetReceiptsIdBreakdownRequestObject{Id: duplicateID})
+	assert.NoError(t, err)
+	breakdown = response.(server.GetReceiptsIdBreakdown200JSONResponse)
+	assert.Equal(t, int64(0), breakdown.Points)
+	assert.Equal(t, server.RulePoints{Rule: "duplicate", Description: "A duplicate of an earlier receipt earns no points", Points: -20},
+		breakdown.Rules[len(breakdown.Rules)-1])
+
+	_, err = service.GetReceiptsIdBreakdown(ctx, server.GetReceiptsIdBreakdownRequestObject{Id: uuid.New().String()})
+	assertProblem(t, err, http.StatusNotFound, problem.CodeReceiptNotFound)
+}
+
+// TestServiceScoreKept tests that a receipt keeps the points it was awarded when the rules change.
+func TestServiceScoreKept(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	receiptID := process(t, service, testReceipt())
+
+	bonus, err := script.Compile("bonus", []byte("def score(receipt, points):\n    return 100, \"Launch bonus\"\n"), script.Limits{})
+	assert.NoError(t, err)
+	service.Calculator.Scripts = []*script.Rule{bonus}
+	response, err := service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetReceiptsIdPoints200JSONResponse{Points: 20}, response)
+	breakdown, err := service.GetReceiptsIdBreakdown(ctx, server.GetReceiptsIdBreakdownRequestObject{Id: receiptID})
+	assert.NoError(t, err)
+	assert.Len(t, breakdown.(server.GetReceiptsIdBreakdown200JSONResponse).Rules, 7)
+
+	// Receipts submitted since are scored under the new rules
+	roundID := process(t, service, roundReceipt())
+	response, err = service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: roundID})
+	assert.NoError(t, err)
+	assert.Greater(t, response.(server.GetReceiptsIdPoints200JSONResponse).Points, int64(100))
+}
+
+// TestServiceInvalidID tests that malformed IDs are rejected before the lookup.
+func TestServiceInvalidID(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+
+	_, err := service.GetReceiptsId(ctx, server.GetReceiptsIdRequestObject{Id: "missing"})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidReceiptID)
+	_, err = service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: "../etc"})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidReceiptID)
+	_, err = service.PostAdminReceiptsIdApprove(ctx, server.PostAdminReceiptsIdApproveRequestObject{Id: "1 OR 1=1"})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidReceiptID)
+}
+
+// TestServiceReceiptPages tests that time-ordered IDs page through a principal's receipts in submission order.
+func TestServiceReceiptPages(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	service.IDs = ids.StrategyULID
+	service.DuplicatePolicy = fraud.PolicyZeroPoints
+
+	submitted := make([]string, 5)
+	for i := range submitted {
+		submitted[i] = processAs(t, service, "alice", testReceipt())
+		// Other principals' receipts are not listed
+		processAs(t, service, "bob", testReceipt())
+	}
+
+	limit := 2
+	listed := make([]string, 0)
+	params := server.GetReceiptsParams{Limit: &limit, XPrincipalId: "alice"}
+	for pages := 1; ; pages++ {
+		response, err := service.GetReceipts(ctx, server.GetReceiptsRequestObject{Params: params})
+		assert.NoError(t, err)
+		page := response.(server.GetReceipts200JSONResponse)
+		for _, record := range page.Receipts {
+			listed = append(listed, record.Id)
+		}
+		if page.Next == nil {
+			assert.Equal(t, 3, pages)
+			break
+		}
+		params.After = page.Next
+	}
+	assert.Equal(t, submitted, listed)
+
+	after := "missing"
+	_, err := service.GetReceipts(ctx, server.GetReceiptsRequestObject{Params: server.GetReceiptsParams{After: &after}})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeInvalidReceiptID)
+}
+
+// TestServiceDecisions tests the problems returned for invalid admin decisions.
+func TestServiceDecisions(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStorage())
+	receiptID := process(t, service, testReceipt())
+
+	_, err := service.PostAdminReceiptsIdReject(ctx, server.PostAdminReceiptsIdRejectRequestObject{Id: receiptID})
+	assertProblem(t, err, http.StatusBadRequest, problem.CodeReasonRequired)
+
+	_, err = service.PostAdminReceiptsIdApprove(ctx, server.PostAdminReceiptsIdApproveRequestObject{Id: receiptID})
+	assertProblem(t, err, http.StatusConflict, problem.CodeNotPendingReview)
+
+	_, err = service.PostAdminReceiptsIdApprove(ctx, server.PostAdminReceiptsIdApproveRequestObject{Id: uuid.New().String()})
+	assertProblem(t, err, http.StatusNotFound, problem.CodeReceiptNotFound)
+}
+
+// TestRecordAPI tests that a record converts into the API model.
+func TestRecordAPI(t *testing.T) {
+	decidedAt := time.Date(2022, time.January, 2, 9, 0, 0, 0, time.UTC)
+	record := &Record{
+		ID:          "abc",
+		Receipt:     testReceipt(),
+		Fingerprint: "fp",
+		Status:      review.StatusApproved,
+		Decision:    &review.Decision{Status: review.StatusApproved, Reason: "Checked", DecidedAt: decidedAt},
+	}
+
+	api := record.API()
+	assert.Equal(t, "abc", api.Id)
+	assert.Equal(t, server.Approved, api.Status)
+	assert.Equal(t, &server.Decision{Status: server.Approved, Reason: "Checked", DecidedAt: decidedAt}, api.Decision)
+}
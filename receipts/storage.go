@@ -0,0 +1,491 @@
+package receipts
+
+import (
+	"errors"
+	"fetch-app/calculation"
+	"fetch-app/fraud"
+	"fetch-app/retention"
+	"fetch-app/review"
+	"fetch-app/server"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Record is a stored receipt together with the metadata recorded when it was submitted.
+type Record struct {
+	ID            string           `json:"id"`
+	Receipt       server.Receipt   `json:"receipt"`
+	SubmittedAt   time.Time        `json:"submittedAt"`
+	PurchasedAt   *time.Time       `json:"purchasedAt,omitempty"`
+	Fingerprint   string           `json:"fingerprint"`
+	DuplicateOf   []string         `json:"duplicateOf,omitempty"`
+	ZeroPoints    bool             `json:"zeroPoints,omitempty"`
+	Status        review.Status    `json:"status"`
+	ReviewReasons []string         `json:"reviewReasons,omitempty"`
+	Decision      *review.Decision `json:"decision,omitempty"`
+	Principal     string           `json:"principal,omitempty"`
+	ItemsPurgedAt *time.Time       `json:"itemsPurgedAt,omitempty"`
+	// Breakdown is the score the receipt was awarded, kept so it does not change with the rules and survives the
+	// item details being purged. Records stored before scores were kept have none until they are purged.
+	Breakdown []calculation.RulePoints `json:"breakdown,omitempty"`
+	// Image describes the image attached to the receipt, which is kept in the service's BlobStore.
+	Image *Image `json:"image,omitempty"`
+	// Version numbers the versions of the receipt from 1, the one submitted. Records stored before receipts could
+	// be corrected have none, which is version 1.
+	Version int `json:"version,omitempty"`
+	// Correction is how the current version replaced the one before it, or nil if the receipt was never corrected.
+	Correction *Correction `json:"correction,omitempty"`
+	// Versions are the earlier versions of the receipt, oldest first.
+	Versions []Version `json:"versions,omitempty"`
+}
+
+// Version is an earlier version of a corrected receipt.
+type Version struct {
+	Version int            `json:"version"`
+	Receipt server.Receipt `json:"receipt"`
+	// Points are the points the version was awarded when it was replaced.
+	Points int64 `json:"points"`
+	// Correction is how the version replaced the one before it, or nil for the version submitted.
+	Correction *Correction `json:"correction,omitempty"`
+}
+
+// Correction records a version of a receipt replacing the one before it.
+type Correction struct {
+	At        time.Time `json:"at"`
+	Principal string    `json:"principal,omitempty"`
+	// Adjustment is the change in the points awarded that the correction made.
+	Adjustment int64 `json:"adjustment"`
+}
+
+// CurrentVersion returns the number of the record's current version.
+func (r *Record) CurrentVersion() int {
+	return max(r.Version, 1)
+}
+
+// ETag returns the entity tag of the record's current version, which corrections must send in If-Match.
+func (r *Record) ETag() string {
+	return fmt.Sprintf(`"%d"`, r.CurrentVersion())
+}
+
+// clone returns a copy of the record that a change can be made to without touching the record. The receipts and
+// the values the copy points to are only ever replaced, never changed in place, so they are shared.
+func (r *Record) clone() *Record {
+	c := *r
+	c.Receipt.Items = slices.Clone(r.Receipt.Items)
+	c.PurchasedAt = clonePointer(r.PurchasedAt)
+	c.DuplicateOf = slices.Clone(r.DuplicateOf)
+	c.ReviewReasons = slices.Clone(r.ReviewReasons)
+	c.Decision = clonePointer(r.Decision)
+	c.ItemsPurgedAt = clonePointer(r.ItemsPurgedAt)
+	c.Breakdown = slices.Clone(r.Breakdown)
+	c.Image = clonePointer(r.Image)
+	c.Correction = clonePointer(r.Correction)
+	c.Versions = slices.Clone(r.Versions)
+	for i := range c.Versions {
+		c.Versions[i].Receipt.Items = slices.Clone(r.Versions[i].Receipt.Items)
+		c.Versions[i].Correction = clonePointer(r.Versions[i].Correction)
+	}
+	return &c
+}
+
+// clonePointer returns a pointer to a copy of what p points to, or nil if p is nil.
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// Image describes an image attached to a receipt.
+type Image struct {
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+}
+
+// API converts the image into the model served by the API.
+func (i *Image) API() server.ReceiptImage {
+	return server.ReceiptImage{ContentType: i.ContentType, Size: i.Size, Sha256: i.SHA256, UploadedAt: i.UploadedAt}
+}
+
+// API converts the record into the model served by the API.
+func (r *Record) API() server.ReceiptRecord {
+	record := server.ReceiptRecord{
+		Id:            r.ID,
+		Receipt:       r.Receipt,
+		SubmittedAt:   r.SubmittedAt,
+		PurchasedAt:   r.PurchasedAt,
+		Fingerprint:   r.Fingerprint,
+		DuplicateOf:   r.DuplicateOf,
+		ZeroPoints:    r.ZeroPoints,
+		Status:        server.Status(r.Status),
+		ReviewReasons: r.ReviewReasons,
+		ItemsPurgedAt: r.ItemsPurgedAt,
+		Version:       r.CurrentVersion(),
+	}
+	if r.Image != nil {
+		image := r.Image.API()
+		record.Image = &image
+	}
+	if r.Decision != nil {
+		record.Decision = &server.Decision{
+			Status:    server.Status(r.Decision.Status),
+			Reason:    r.Decision.Reason,
+			DecidedAt: r.Decision.DecidedAt,
+		}
+	}
+	return record
+}
+
+// Submission is what an Idempotency-Key was first used for: a hash of the request body and the ID it was assigned.
+type Submission struct {
+	BodyHash  string `json:"bodyHash"`
+	ReceiptID string `json:"receiptId"`
+	// At is when the key was first used. The key expires IdempotencyTTL after it.
+	At time.Time `json:"at"`
+}
+
+// DefaultIdempotencyTTL is how long an Idempotency-Key is remembered unless configured otherwise.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// State is what the storage keeps besides the records: the anonymized totals, the purge log and the idempotency
+// keys. A backend persists it along with the records.
+type State struct {
+	Anonymized      retention.Ledger      `json:"anonymized"`
+	Purges          []retention.Purge     `json:"purges,omitempty"`
+	IdempotencyKeys map[string]Submission `json:"idempotencyKeys,omitempty"`
+}
+
+// Storage holds the receipts and provides a storage mechanism.
+type Storage struct {
+	mu              sync.RWMutex
+	Receipts        map[string]*Record
+	Fingerprints    *fraud.Index
+	IdempotencyKeys map[string]Submission
+	// Anonymized totals the receipts removed from the store and the points they were awarded.
+	Anonymized retention.Ledger
+	// Purges is the audit log of every purge, oldest first.
+	Purges []retention.Purge
+	// IdempotencyTTL is how long an Idempotency-Key is remembered after it was first used.
+	IdempotencyTTL time.Duration
+	// Backend persists the records and the state. Without one, they are only kept in memory.
+	Backend Backend
+}
+
+// Backend persists the stored records, so they survive a restart. Storage calls it while holding its lock.
+type Backend interface {
+	// Save persists the record, replacing any earlier version.
+	Save(record *Record) error
+	// Delete removes the persisted record. Deleting a record that was never saved is not an error.
+	Delete(id string) error
+	// Load returns every persisted record.
+	Load() ([]*Record, error)
+	// SaveState persists the state, replacing any earlier one.
+	SaveState(state *State) error
+	// LoadState returns the persisted state, or nil if none was saved yet.
+	LoadState() (*State, error)
+}
+
+// NewStorage initializes and returns a new Storage instance.
+func NewStorage() *Storage {
+	return &Storage{
+		Receipts:        make(map[string]*Record), // Initialize the map
+		Fingerprints:    fraud.NewIndex(),
+		IdempotencyKeys: make(map[string]Submission),
+		IdempotencyTTL:  DefaultIdempotencyTTL,
+	}
+}
+
+// Restore loads the records and the state persisted by the backend and indexes the fingerprints of the records in
+// submission order. It is called once, before the storage is used.
+func (s *Storage) Restore() error {
+	records, err := s.Backend.Load()
+	if err != nil {
+		return err
+	}
+	state, err := s.Backend.LoadState()
+	if err != nil {
+		return err
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].SubmittedAt.Before(records[j].SubmittedAt)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range records {
+		s.Receipts[record.ID] = record
+		s.Fingerprints.Register(record.Fingerprint, record.ID, fraud.PolicyFlag)
+	}
+	if state != nil {
+		s.Anonymized = state.Anonymized
+		s.Purges = state.Purges
+		for key, submission := range state.IdempotencyKeys {
+			s.IdempotencyKeys[key] = submission
+		}
+	}
+	return nil
+}
+
+// saveState persists the state with the backend, if there is one. The caller holds the storage lock.
+func (s *Storage) saveState() error {
+	if s.Backend == nil {
+		return nil
+	}
+	return s.Backend.SaveState(&State{Anonymized: s.Anonymized, Purges: s.Purges, IdempotencyKeys: s.IdempotencyKeys})
+}
+
+// Get returns a copy of the record stored under id, if any, so it does not change while the caller reads it.
+func (s *Storage) Get(id string) (*Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, exists := s.Receipts[id]
+	if !exists {
+		return nil, false
+	}
+	return record.clone(), true
+}
+
+// Put stores the record under its ID. With a backend, the record is only stored once it was persisted.
+func (s *Storage) Put(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Backend != nil {
+		if err := s.Backend.Save(record); err != nil {
+			return err
+		}
+	}
+	s.Receipts[record.ID] = record
+	return nil
+}
+
+// Update applies fn to a copy of the record stored under id while holding the storage lock, and stores the copy in
+// its place once it was persisted. If fn or persisting fails, the stored record is left as it was.
+// It returns false if no such record exists, otherwise the error returned by fn or by persisting the record.
+func (s *Storage) Update(id string, fn func(record *Record) error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, exists := s.Receipts[id]
+	if !exists {
+		return false, nil
+	}
+	updated := record.clone()
+	if err := fn(updated); err != nil {
+		return true, err
+	}
+	if s.Backend != nil {
+		if err := s.Backend.Save(updated); err != nil {
+			return true, err
+		}
+	}
+	s.Receipts[id] = updated
+	return true, nil
+}
+
+// ListByStatus returns copies of the records with the given status, oldest submission first.
+func (s *Storage) ListByStatus(status review.Status) []*Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]*Record, 0)
+	for _, record := range s.Receipts {
+		if record.Status == status {
+			records = append(records, record.clone())
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].SubmittedAt.Before(records[j].SubmittedAt)
+	})
+	return records
+}
+
+// Page returns copies of up to limit records submitted by the principal whose IDs sort after the given one, in ID order, and
+// whether more follow. An empty after starts from the first record. With time-ordered IDs, ID order is submission
+// order.
+func (s *Storage) Page(principal, after string, limit int) ([]*Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]*Record, 0)
+	for id, record := range s.Receipts {
+		if id > after && record.Principal == principal {
+			records = append(records, record.clone())
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+	if len(records) > limit {
+		return records[:limit], true
+	}
+	return records, false
+}
+
+// IDs returns the IDs of the stored records in ID order, as they are when it is called.
+func (s *Storage) IDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.Receipts))
+	for id := range s.Receipts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Submission returns what the Idempotency-Key was first used for, if anything. An expired key was used for nothing.
+func (s *Storage) Submission(key string) (Submission, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	submission, exists := s.IdempotencyKeys[key]
+	if !exists || s.expired(submission, time.Now()) {
+		return Submission{}, false
+	}
+	return submission, true
+}
+
+// Remember records the submission an Idempotency-Key was used for, forgets the keys that expired, and persists them.
+// The key is remembered in memory even if persisting fails.
+func (s *Storage) Remember(key string, submission Submission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, earlier := range s.IdempotencyKeys {
+		if s.expired(earlier, now) {
+			delete(s.IdempotencyKeys, k)
+		}
+	}
+	s.IdempotencyKeys[key] = submission
+	return s.saveState()
+}
+
+// expired reports whether the idempotency key used for the submission expired by now.
+func (s *Storage) expired(submission Submission, now time.Time) bool {
+	return s.IdempotencyTTL > 0 && !now.Before(submission.At.Add(s.IdempotencyTTL))
+}
+
+// UpdateWhere applies fn to a copy of every record matching match while holding the storage lock, and stores each
+// copy in place of its record once it was persisted. A record whose copy could not be persisted is left as it was.
+// It returns the IDs of the updated records in ID order, and the errors persisting the others.
+func (s *Storage) UpdateWhere(match func(record *Record) bool, fn func(record *Record)) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated := make([]string, 0)
+	var errs []error
+	for id, record := range s.Receipts {
+		if !match(record) {
+			continue
+		}
+		changed := record.clone()
+		fn(changed)
+		if s.Backend != nil {
+			if err := s.Backend.Save(changed); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		s.Receipts[id] = changed
+		updated = append(updated, id)
+	}
+	sort.Strings(updated)
+	return updated, errors.Join(errs...)
+}
+
+// Remove deletes every record matching match, along with its fingerprint and idempotency keys, and adds it to the
+// anonymized totals with the points returned by points. Both happen under the storage lock, so the totals never
+// miss a removed receipt.
+//
+// Returns:
+//
+//	The IDs of the removed records in ID order, the points they were awarded, and the errors deleting records
+//	from the backend, which are kept, or persisting the state.
+func (s *Storage) Remove(match func(record *Record) bool, points func(record *Record) int64) ([]string, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := make([]string, 0)
+	total := int64(0)
+	var errs []error
+	for id, record := range s.Receipts {
+		if !match(record) {
+			continue
+		}
+		if s.Backend != nil {
+			if err := s.Backend.Delete(id); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		awarded := points(record)
+		s.Anonymized.Add(awarded)
+		total += awarded
+		s.Fingerprints.Remove(record.Fingerprint, id)
+		delete(s.Receipts, id)
+		removed = append(removed, id)
+	}
+	if len(removed) == 0 {
+		return removed, 0, errors.Join(errs...)
+	}
+
+	// Retrying a submission whose receipt was removed stores it anew
+	gone := make(map[string]bool, len(removed))
+	for _, id := range removed {
+		gone[id] = true
+	}
+	for key, submission := range s.IdempotencyKeys {
+		if gone[submission.ReceiptID] {
+			delete(s.IdempotencyKeys, key)
+		}
+	}
+	errs = append(errs, s.saveState())
+	sort.Strings(removed)
+	return removed, total, errors.Join(errs...)
+}
+
+// Audit appends a purge to the audit log and persists it. The purge is logged in memory even if persisting fails.
+func (s *Storage) Audit(purge retention.Purge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Purges = append(s.Purges, purge)
+	return s.saveState()
+}
+
+// AuditLog returns the purges recorded so far, oldest first.
+func (s *Storage) AuditLog() []retention.Purge {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]retention.Purge(nil), s.Purges...)
+}
+
+// Adjustments totals the corrections made to the stored records and the change in points they made.
+func (s *Storage) Adjustments() (corrections, points int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.Receipts {
+		for _, version := range record.Versions {
+			if version.Correction != nil {
+				corrections++
+				points += version.Correction.Adjustment
+			}
+		}
+		if record.Correction != nil {
+			corrections++
+			points += record.Correction.Adjustment
+		}
+	}
+	return corrections, points
+}
+
+// Totals returns the ledger of the stored records, with the points returned by points, and the anonymized ledger
+// of the removed ones.
+func (s *Storage) Totals(points func(record *Record) int64) (retention.Ledger, retention.Ledger) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var stored retention.Ledger
+	for _, record := range s.Receipts {
+		stored.Add(points(record))
+	}
+	return stored, s.Anonymized
+}
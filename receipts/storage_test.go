@@ -0,0 +1,46 @@
+package receipts
+
+import (
+	"errors"
+	"fetch-app/review"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// brokenBackend fails to persist any record.
+type brokenBackend struct{}
+
+func (brokenBackend) Save(record *Record) error    { return errors.New("disk full") }
+func (brokenBackend) Delete(id string) error       { return nil }
+func (brokenBackend) Load() ([]*Record, error)     { return nil, nil }
+func (brokenBackend) SaveState(state *State) error { return nil }
+func (brokenBackend) LoadState() (*State, error)   { return nil, nil }
+
+// TestStorageCopies tests that readers get copies of the records, and that changes only take effect once persisted.
+func TestStorageCopies(t *testing.T) {
+	service := NewService(NewStorage())
+	receiptID := process(t, service, testReceipt())
+
+	record, _ := service.Storage.Get(receiptID)
+	record.Status = review.StatusRejected
+	record.Receipt.Items[0].Price = "0.00"
+	stored, _ := service.Storage.Get(receiptID)
+	assert.Equal(t, review.StatusApproved, stored.Status)
+	assert.Equal(t, "6.49", stored.Receipt.Items[0].Price)
+
+	// A change that could not be persisted is not made
+	service.Storage.Backend = brokenBackend{}
+	exists, err := service.Storage.Update(receiptID, func(record *Record) error {
+		record.Status = review.StatusRejected
+		return nil
+	})
+	assert.True(t, exists)
+	assert.Error(t, err)
+	updated, err := service.Storage.UpdateWhere(func(record *Record) bool { return true }, func(record *Record) {
+		record.Status = review.StatusRejected
+	})
+	assert.Empty(t, updated)
+	assert.Error(t, err)
+	stored, _ = service.Storage.Get(receiptID)
+	assert.Equal(t, review.StatusApproved, stored.Status)
+}
@@ -0,0 +1,99 @@
+package receipts
+
+import (
+	"context"
+	"errors"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"fetch-app/textparse"
+	"fetch-app/validation"
+	"net/http"
+)
+
+// PostReceiptsProcessText reads a receipt from its printed text and processes it like a submitted one. See
+// textparse.Parse for how the text is read.
+//
+// Returns:
+//
+//	The generated receipt ID, the receipt as read, and the confidence in each of its fields.
+//	If the body is missing or the time zone is unknown, it returns a Bad Request (400) problem.
+//	If the receipt duplicates an earlier one and the duplicate policy rejects it, it returns a Conflict (409)
+//	problem.
+//	If a field cannot be found in the text, or the receipt read violates the Receipt schema, it returns an
+//	Unprocessable Entity (422) problem listing the fields at fault.
+func (s *Service) PostReceiptsProcessText(ctx context.Context, request server.PostReceiptsProcessTextRequestObject) (server.PostReceiptsProcessTextResponseObject, error) {
+	if request.Body == nil {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "The request has no text")
+	}
+	principal := ""
+	if request.Params.XPrincipalId != nil {
+		principal = *request.Params.XPrincipalId
+	}
+	result, err := textparse.Parse(*request.Body, s.Normalizer.Order)
+	id, err := s.processRead(result, err, "text", principal)
+	if err != nil {
+		return nil, err
+	}
+	return server.PostReceiptsProcessText200JSONResponse{
+		Id:         id,
+		Receipt:    result.Receipt,
+		Confidence: fieldConfidence(result.Confidence),
+	}, nil
+}
+
+// processRead processes a receipt read from text, such as a printed receipt or an email.
+//
+// Parameters:
+//
+//	result    - The receipt read.
+//	err       - The error reading it, a *textparse.MissingError if fields could not be found.
+//	source    - What the receipt was read from, for the problem detail.
+//	principal - Who the receipt is submitted by, or empty.
+//
+// Returns:
+//
+//	The generated receipt ID, or the problem of PostReceiptsProcessText.
+func (s *Service) processRead(result textparse.Result, err error, source, principal string) (string, error) {
+	var missing *textparse.MissingError
+	if errors.As(err, &missing) {
+		p := problem.New(http.StatusUnprocessableEntity, problem.CodeReceiptUnreadable, "No receipt could be read from the "+source)
+		for _, field := range missing.Fields {
+			p.Errors = append(p.Errors, validation.FieldError{Field: "/" + field, Message: "not found in the " + source})
+		}
+		return "", p
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// The parser cleans the fields, but a receipt read from text is still checked like a submitted one
+	if s.Validator != nil {
+		var violation *validation.Error
+		err := s.Validator.ValidateSchema("Receipt", result.Receipt)
+		if errors.As(err, &violation) {
+			p := problem.New(http.StatusUnprocessableEntity, problem.CodeReceiptUnreadable, "The receipt read from the "+source+" is not valid")
+			p.Errors = violation.Fields
+			return "", p
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	response, err := s.process(result.Receipt, principal)
+	if err != nil {
+		return "", err
+	}
+	return response.(server.PostReceiptsProcess200JSONResponse).Id, nil
+}
+
+// fieldConfidence converts the confidence of a receipt read from text to its API representation.
+func fieldConfidence(confidence textparse.Confidence) server.FieldConfidence {
+	return server.FieldConfidence{
+		Retailer:     float32(confidence.Retailer),
+		PurchaseDate: float32(confidence.PurchaseDate),
+		PurchaseTime: float32(confidence.PurchaseTime),
+		Items:        float32(confidence.Items),
+		Total:        float32(confidence.Total),
+	}
+}
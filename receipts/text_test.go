@@ -0,0 +1,53 @@
+package receipts
+
+import (
+	"context"
+	"fetch-app/problem"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// TestProcessText tests that a receipt read from text is stored and scored like a submitted one.
+func TestProcessText(t *testing.T) {
+	service := NewService(NewStorage())
+	text, err := os.ReadFile("../textparse/testdata/target.txt")
+	assert.NoError(t, err)
+	body := string(text)
+	principal := "user-1"
+
+	response, err := service.PostReceiptsProcessText(context.Background(), server.PostReceiptsProcessTextRequestObject{
+		Body:   &body,
+		Params: server.PostReceiptsProcessTextParams{XPrincipalId: &principal},
+	})
+	assert.NoError(t, err)
+	processed := response.(server.PostReceiptsProcessText200JSONResponse)
+	assert.Equal(t, float32(0.95), processed.Confidence.Total)
+
+	record, exists := service.Storage.Get(processed.Id)
+	if assert.True(t, exists) {
+		assert.Equal(t, "user-1", record.Principal)
+		assert.Equal(t, "TARGET", record.Receipt.Retailer)
+		assert.Len(t, record.Receipt.Items, 2)
+	}
+	points, err := service.GetReceiptsIdPoints(context.Background(), server.GetReceiptsIdPointsRequestObject{Id: processed.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, server.GetReceiptsIdPoints200JSONResponse{Points: 20}, points)
+}
+
+// TestProcessTextUnreadable tests that text without a receipt is refused with the missing fields.
+func TestProcessTextUnreadable(t *testing.T) {
+	service := NewService(NewStorage())
+	body := "Corner Shop\nTea 2.50\nTOTAL 2.50"
+
+	_, err := service.PostReceiptsProcessText(context.Background(), server.PostReceiptsProcessTextRequestObject{Body: &body})
+	assertProblem(t, err, http.StatusUnprocessableEntity, problem.CodeReceiptUnreadable)
+	var p *problem.Problem
+	if assert.ErrorAs(t, err, &p) && assert.Len(t, p.Errors, 2) {
+		assert.Equal(t, "/purchaseDate", p.Errors[0].Field)
+		assert.Equal(t, "/purchaseTime", p.Errors[1].Field)
+	}
+	assert.Empty(t, service.Storage.Receipts)
+}
@@ -0,0 +1,115 @@
+package retention
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the description of every item whose details were purged.
+const Redacted = "REDACTED"
+
+// Policy decides how long raw receipt data is kept. A zero duration keeps the data forever.
+type Policy struct {
+	// ItemDetails is how long after submission the item descriptions are kept. The points are kept after.
+	ItemDetails time.Duration
+	// Records is how long after submission the whole record is kept. Its points stay in the anonymized totals.
+	Records time.Duration
+}
+
+// PurgeItems reports whether the item details of a receipt submitted at submittedAt are due to be purged.
+func (p Policy) PurgeItems(submittedAt, now time.Time) bool {
+	return p.ItemDetails > 0 && now.Sub(submittedAt) >= p.ItemDetails
+}
+
+// Expire reports whether a receipt submitted at submittedAt is due to be removed.
+func (p Policy) Expire(submittedAt, now time.Time) bool {
+	return p.Records > 0 && now.Sub(submittedAt) >= p.Records
+}
+
+// ParseDays converts a configuration value counting days into a duration.
+// An empty value or zero disables the purge.
+func ParseDays(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid number of days %q", s)
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// Reason says why receipt data was purged.
+type Reason string
+
+const (
+	// ReasonItemDetails marks the item details of receipts that outlived Policy.ItemDetails.
+	ReasonItemDetails Reason = "item_details"
+	// ReasonExpired marks receipts that outlived Policy.Records.
+	ReasonExpired Reason = "expired"
+	// ReasonErasure marks the receipts of a principal who asked for them to be erased.
+	ReasonErasure Reason = "erasure"
+)
+
+// Purge is the audit record of one purge. It says what was purged and why without keeping the purged data.
+type Purge struct {
+	At     time.Time `json:"at"`
+	Reason Reason    `json:"reason"`
+	// Principal is the hash of the principal whose receipts were erased, so an erasure can be proven without
+	// keeping who asked for it.
+	Principal  string   `json:"principal,omitempty"`
+	ReceiptIDs []string `json:"receiptIds"`
+	// Points is the number of points the purged receipts had been awarded, which the ledger keeps.
+	Points int64 `json:"points"`
+}
+
+// MinPrincipalKeySize is the fewest bytes a principal hash key may have.
+const MinPrincipalKeySize = 32
+
+// HashPrincipal returns the hash identifying a principal in the audit records: an HMAC-SHA256 under a server secret,
+// so a principal cannot be recovered by hashing guesses.
+//
+// Parameters:
+//
+//	key: The secret the hashes are keyed with. Hashes under different keys do not match.
+//	principal: The principal to hash.
+func HashPrincipal(key []byte, principal string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(principal))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParsePrincipalKey decodes the base64 principal hash key read from a key file, ignoring surrounding whitespace.
+//
+// Returns:
+//
+//	The key, or an error if it is not base64 or shorter than MinPrincipalKeySize.
+func ParsePrincipalKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decoding principal hash key: %w", err)
+	}
+	if len(key) < MinPrincipalKeySize {
+		return nil, fmt.Errorf("principal hash key has %d bytes, need at least %d", len(key), MinPrincipalKeySize)
+	}
+	return key, nil
+}
+
+// Ledger totals receipts and the points awarded to them, without saying whose they were.
+type Ledger struct {
+	Receipts int64 `json:"receipts"`
+	Points   int64 `json:"points"`
+}
+
+// Add counts a receipt awarded the given points.
+func (l *Ledger) Add(points int64) {
+	l.Receipts++
+	l.Points += points
+}
@@ -0,0 +1,66 @@
+package retention
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// TestPolicy tests when receipt data is due to be purged.
+func TestPolicy(t *testing.T) {
+	submittedAt := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	policy := Policy{ItemDetails: 30 * 24 * time.Hour, Records: 365 * 24 * time.Hour}
+
+	assert.False(t, policy.PurgeItems(submittedAt, submittedAt.AddDate(0, 0, 29)))
+	assert.True(t, policy.PurgeItems(submittedAt, submittedAt.AddDate(0, 0, 30)))
+	assert.False(t, policy.Expire(submittedAt, submittedAt.AddDate(0, 0, 364)))
+	assert.True(t, policy.Expire(submittedAt, submittedAt.AddDate(1, 0, 0)))
+
+	// The zero policy keeps everything
+	assert.False(t, Policy{}.PurgeItems(submittedAt, submittedAt.AddDate(10, 0, 0)))
+	assert.False(t, Policy{}.Expire(submittedAt, submittedAt.AddDate(10, 0, 0)))
+}
+
+// TestParseDays tests that configuration values count days.
+func TestParseDays(t *testing.T) {
+	days, err := ParseDays("")
+	assert.NoError(t, err)
+	assert.Zero(t, days)
+
+	days, err = ParseDays(" 30 ")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, days)
+
+	_, err = ParseDays("-1")
+	assert.Error(t, err)
+	_, err = ParseDays("30d")
+	assert.Error(t, err)
+}
+
+// TestHashPrincipal tests that principals are hashed consistently under a key without appearing in the hash.
+func TestHashPrincipal(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, MinPrincipalKeySize)
+	assert.Equal(t, HashPrincipal(key, "user-1"), HashPrincipal(key, "user-1"))
+	assert.NotEqual(t, HashPrincipal(key, "user-1"), HashPrincipal(key, "user-2"))
+	assert.NotContains(t, HashPrincipal(key, "user-1"), "user-1")
+	assert.Len(t, HashPrincipal(key, "user-1"), 64)
+
+	// A hash under another key does not match
+	other := bytes.Repeat([]byte{2}, MinPrincipalKeySize)
+	assert.NotEqual(t, HashPrincipal(key, "user-1"), HashPrincipal(other, "user-1"))
+}
+
+// TestParsePrincipalKey tests that principal hash keys must be long enough base64.
+func TestParsePrincipalKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, MinPrincipalKeySize)
+	parsed, err := ParsePrincipalKey(base64.StdEncoding.EncodeToString(key) + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParsePrincipalKey(base64.StdEncoding.EncodeToString(key[:16]))
+	assert.Error(t, err)
+	_, err = ParsePrincipalKey("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package review
+
+import (
+	"errors"
+	"fetch-app/calculation"
+	"fetch-app/currency"
+	"fetch-app/server"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status is the position of a receipt in the review workflow.
+type Status string
+
+const (
+	// StatusPendingReview marks a suspicious receipt whose points are withheld until an admin decides on it.
+	StatusPendingReview Status = "pending_review"
+	// StatusApproved marks a receipt whose points are awarded.
+	StatusApproved Status = "approved"
+	// StatusRejected marks a receipt whose points will never be awarded.
+	StatusRejected Status = "rejected"
+)
+
+// DefaultMaxPoints is the points value above which a receipt is considered extreme unless configured otherwise.
+const DefaultMaxPoints = 1000
+
+// ErrInvalidTransition is returned when a status change is not allowed by the workflow.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions lists the statuses each status may move to. Approved and rejected receipts are final.
+var transitions = map[Status][]Status{
+	StatusPendingReview: {StatusApproved, StatusRejected},
+}
+
+// Transition checks that a receipt may move from one status to another.
+func Transition(from, to Status) error {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+}
+
+// Decision records an admin's approval or rejection of a receipt.
+type Decision struct {
+	Status    Status    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	DecidedAt time.Time `json:"decidedAt"`
+}
+
+// Rules decides which receipts are suspicious enough to need a human decision.
+type Rules struct {
+	// MaxPoints is the largest points value awarded without review. Zero disables the check.
+	MaxPoints int
+}
+
+// Check lists the reasons a receipt must be reviewed before its points are awarded.
+//
+// Parameters:
+//
+//	receipt     - The submitted receipt.
+//	points      - The points the receipt would earn.
+//	duplicateOf - The IDs of earlier receipts with the same fingerprint, if the duplicate was flagged.
+//
+// Returns:
+//
+//	A human-readable reason for each failed check, or nil if the receipt can be approved automatically.
+func (r Rules) Check(receipt server.Receipt, points int, duplicateOf []string) []string {
+	var reasons []string
+
+	// Duplicates of earlier submissions
+	if len(duplicateOf) > 0 {
+		reasons = append(reasons, fmt.Sprintf("duplicate of %s", strings.Join(duplicateOf, ", ")))
+	}
+
+	// Totals that do not match the sum of the item prices
+	if reason := checkTotal(receipt); reason != "" {
+		reasons = append(reasons, reason)
+	}
+
+	// Extreme point values
+	if r.MaxPoints > 0 && points > r.MaxPoints {
+		reasons = append(reasons, fmt.Sprintf("points %d exceed the limit of %d", points, r.MaxPoints))
+	}
+
+	return reasons
+}
+
+// checkTotal compares the receipt total with what its amounts add up to: the subtotal plus tax and tip, less the
+// item discounts. Without a subtotal, it is the item prices before their discounts, since each price already
+// accounts for its discount. The amounts are compared exactly, as the calculation reads them, and a reason is
+// returned if they differ.
+func checkTotal(receipt server.Receipt) string {
+	digits, ok := currency.Digits(currency.Code(receipt))
+	if !ok {
+		digits = 2
+	}
+	total, ok := calculation.ParseAmount(receipt.Total)
+	if !ok {
+		return fmt.Sprintf("total %q is not a valid amount", receipt.Total)
+	}
+
+	var items, discounts int64
+	for _, item := range receipt.Items {
+		price, ok := calculation.ParseAmount(item.Price)
+		if !ok {
+			return fmt.Sprintf("price %q of item %q is not a valid amount", item.Price, item.ShortDescription)
+		}
+		items += price
+		if item.Discount != nil {
+			discount, ok := calculation.ParseAmount(*item.Discount)
+			if !ok {
+				return fmt.Sprintf("discount %q of item %q is not a valid amount", *item.Discount, item.ShortDescription)
+			}
+			discounts += discount
+		}
+	}
+
+	subtotal, tax, tip := items+discounts, int64(0), int64(0)
+	for _, optional := range []struct {
+		name   string
+		amount *string
+		value  *int64
+	}{{"subtotal", receipt.Subtotal, &subtotal}, {"tax", receipt.Tax, &tax}, {"tip", receipt.Tip, &tip}} {
+		if optional.amount == nil {
+			continue
+		}
+		value, ok := calculation.ParseAmount(*optional.amount)
+		if !ok {
+			return fmt.Sprintf("%s %q is not a valid amount", optional.name, *optional.amount)
+		}
+		*optional.value = value
+	}
+
+	expected := subtotal + tax + tip - discounts
+	if expected == total {
+		return ""
+	}
+	if receipt.Subtotal == nil && receipt.Tax == nil && receipt.Tip == nil {
+		return fmt.Sprintf("total %s does not match the item sum %s", receipt.Total, formatAmount(expected, digits))
+	}
+	return fmt.Sprintf("total %s does not match %s, the subtotal plus tax and tip less discounts",
+		receipt.Total, formatAmount(expected, digits))
+}
+
+// formatAmount formats millionths of a currency unit as an amount with the digits of the currency, truncating any
+// further digits.
+func formatAmount(millionths int64, digits int) string {
+	sign := ""
+	if millionths < 0 {
+		sign, millionths = "-", -millionths
+	}
+	whole, fraction := millionths/calculation.AmountUnit, millionths%calculation.AmountUnit
+	if digits == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	fractionDigits := fmt.Sprintf("%06d", fraction)
+	if digits < len(fractionDigits) {
+		fractionDigits = fractionDigits[:digits]
+	}
+	return fmt.Sprintf("%s%d.%s", sign, whole, fractionDigits)
+}
@@ -0,0 +1,107 @@
+package review
+
+import (
+	"errors"
+	"fetch-app/server"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Helper function to create a test receipt whose total matches its items
+func createTestReceipt() server.Receipt {
+	return server.Receipt{
+		Retailer:     "M&M Corner Market",
+		PurchaseDate: types.Date{Time: time.Date(2022, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "14:33",
+		Total:        "9.00",
+		Items: []server.Item{
+			{ShortDescription: "Gatorade", Price: "2.25"},
+			{ShortDescription: "Gatorade", Price: "2.25"},
+			{ShortDescription: "Gatorade", Price: "4.50"},
+		},
+	}
+}
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from    Status
+		to      Status
+		allowed bool
+	}{
+		{StatusPendingReview, StatusApproved, true},
+		{StatusPendingReview, StatusRejected, true},
+		{StatusApproved, StatusRejected, false},
+		{StatusRejected, StatusApproved, false},
+		{StatusApproved, StatusPendingReview, false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.from)+" to "+string(test.to), func(t *testing.T) {
+			err := Transition(test.from, test.to)
+			if test.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidTransition))
+			}
+		})
+	}
+}
+
+func TestRulesCheck(t *testing.T) {
+	rules := Rules{MaxPoints: 100}
+
+	// A clean receipt needs no review
+	assert.Empty(t, rules.Check(createTestReceipt(), 50, nil))
+
+	// Flagged duplicates
+	reasons := rules.Check(createTestReceipt(), 50, []string{"a", "b"})
+	assert.Equal(t, []string{"duplicate of a, b"}, reasons)
+
+	// Totals not matching the items
+	receipt := createTestReceipt()
+	receipt.Total = "10.00"
+	reasons = rules.Check(receipt, 50, nil)
+	assert.Equal(t, []string{"total 10.00 does not match the item sum 9.00"}, reasons)
+
+	// Totals in currencies with other minor units are compared exactly
+	code := "BHD"
+	receipt = createTestReceipt()
+	receipt.Currency = &code
+	receipt.Total = "0.375"
+	receipt.Items = []server.Item{{ShortDescription: "Gatorade", Price: "0.125"}, {ShortDescription: "Gatorade", Price: "0.125"}}
+	reasons = rules.Check(receipt, 50, nil)
+	assert.Equal(t, []string{"total 0.375 does not match the item sum 0.250"}, reasons)
+	receipt.Total = "0.250"
+	assert.Empty(t, rules.Check(receipt, 50, nil))
+
+	// Totals are the subtotal plus tax and tip, less the item discounts the prices already account for
+	receipt = createTestReceipt()
+	subtotal, tax, tip, discount := "9.50", "0.72", "1.00", "0.50"
+	receipt.Items[2].Discount = &discount
+	receipt.Subtotal, receipt.Tax, receipt.Tip = &subtotal, &tax, &tip
+	receipt.Total = "10.72"
+	assert.Empty(t, rules.Check(receipt, 50, nil))
+	receipt.Total = "9.00"
+	reasons = rules.Check(receipt, 50, nil)
+	assert.Equal(t, []string{"total 9.00 does not match 10.72, the subtotal plus tax and tip less discounts"}, reasons)
+
+	// Without a subtotal, the item prices stand in for it
+	receipt.Subtotal = nil
+	receipt.Total = "10.72"
+	assert.Empty(t, rules.Check(receipt, 50, nil))
+
+	// Unparseable amounts
+	receipt = createTestReceipt()
+	receipt.Items[0].Price = "free"
+	reasons = rules.Check(receipt, 50, nil)
+	assert.Equal(t, []string{`price "free" of item "Gatorade" is not a valid amount`}, reasons)
+
+	// Extreme point values
+	reasons = rules.Check(createTestReceipt(), 101, nil)
+	assert.Equal(t, []string{"points 101 exceed the limit of 100"}, reasons)
+
+	// A zero limit disables the points check
+	assert.Empty(t, Rules{}.Check(createTestReceipt(), 100000, nil))
+}
@@ -0,0 +1,6 @@
+package rpc
+
+// The messages and gRPC service in receipts.pb.go and receipts_grpc.pb.go are generated from receipts.proto.
+// Edit receipts.proto rather than the generated code, then run go generate ./... with protoc, protoc-gen-go and
+// protoc-gen-go-grpc installed to regenerate it.
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative receipts.proto
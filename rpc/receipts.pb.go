@@ -0,0 +1,934 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: receipts.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PaymentMethod is how a receipt was paid.
+type PaymentMethod int32
+
+const (
+	PaymentMethod_PAYMENT_METHOD_UNSPECIFIED PaymentMethod = 0
+	PaymentMethod_PAYMENT_METHOD_CASH        PaymentMethod = 1
+	PaymentMethod_PAYMENT_METHOD_CREDIT      PaymentMethod = 2
+	PaymentMethod_PAYMENT_METHOD_DEBIT       PaymentMethod = 3
+	PaymentMethod_PAYMENT_METHOD_GIFT_CARD   PaymentMethod = 4
+	PaymentMethod_PAYMENT_METHOD_MOBILE      PaymentMethod = 5
+	PaymentMethod_PAYMENT_METHOD_CHECK       PaymentMethod = 6
+	PaymentMethod_PAYMENT_METHOD_OTHER       PaymentMethod = 7
+)
+
+// Enum value maps for PaymentMethod.
+var (
+	PaymentMethod_name = map[int32]string{
+		0: "PAYMENT_METHOD_UNSPECIFIED",
+		1: "PAYMENT_METHOD_CASH",
+		2: "PAYMENT_METHOD_CREDIT",
+		3: "PAYMENT_METHOD_DEBIT",
+		4: "PAYMENT_METHOD_GIFT_CARD",
+		5: "PAYMENT_METHOD_MOBILE",
+		6: "PAYMENT_METHOD_CHECK",
+		7: "PAYMENT_METHOD_OTHER",
+	}
+	PaymentMethod_value = map[string]int32{
+		"PAYMENT_METHOD_UNSPECIFIED": 0,
+		"PAYMENT_METHOD_CASH":        1,
+		"PAYMENT_METHOD_CREDIT":      2,
+		"PAYMENT_METHOD_DEBIT":       3,
+		"PAYMENT_METHOD_GIFT_CARD":   4,
+		"PAYMENT_METHOD_MOBILE":      5,
+		"PAYMENT_METHOD_CHECK":       6,
+		"PAYMENT_METHOD_OTHER":       7,
+	}
+)
+
+func (x PaymentMethod) Enum() *PaymentMethod {
+	p := new(PaymentMethod)
+	*p = x
+	return p
+}
+
+func (x PaymentMethod) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PaymentMethod) Descriptor() protoreflect.EnumDescriptor {
+	return file_receipts_proto_enumTypes[0].Descriptor()
+}
+
+func (PaymentMethod) Type() protoreflect.EnumType {
+	return &file_receipts_proto_enumTypes[0]
+}
+
+func (x PaymentMethod) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PaymentMethod.Descriptor instead.
+func (PaymentMethod) EnumDescriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{0}
+}
+
+// Status is the position of a receipt in the review workflow.
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED    Status = 0
+	Status_STATUS_PENDING_REVIEW Status = 1
+	Status_STATUS_APPROVED       Status = 2
+	Status_STATUS_REJECTED       Status = 3
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_PENDING_REVIEW",
+		2: "STATUS_APPROVED",
+		3: "STATUS_REJECTED",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED":    0,
+		"STATUS_PENDING_REVIEW": 1,
+		"STATUS_APPROVED":       2,
+		"STATUS_REJECTED":       3,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_receipts_proto_enumTypes[1].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_receipts_proto_enumTypes[1]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{1}
+}
+
+// Receipt is a purchase receipt, with the same fields and formats as the Receipt schema of the HTTP API.
+type Receipt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name of the retailer or store the receipt is from.
+	Retailer string `protobuf:"bytes,1,opt,name=retailer,proto3" json:"retailer,omitempty"`
+	// The date of the purchase printed on the receipt, as YYYY-MM-DD or as printed, such as 03/20/2022.
+	PurchaseDate string `protobuf:"bytes,2,opt,name=purchase_date,json=purchaseDate,proto3" json:"purchase_date,omitempty"`
+	// The time of the purchase printed on the receipt, as 24-hour HH:MM or as printed, such as 2:33 PM.
+	PurchaseTime string `protobuf:"bytes,3,opt,name=purchase_time,json=purchaseTime,proto3" json:"purchase_time,omitempty"`
+	// The items purchased. At least one is required.
+	Items []*Item `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	// The total amount paid on the receipt, such as "6.49".
+	Total string `protobuf:"bytes,5,opt,name=total,proto3" json:"total,omitempty"`
+	// Where the purchase happened, as an IANA time zone name or a UTC offset.
+	// Inherited from the retailer or the deployment default when omitted.
+	Timezone *string `protobuf:"bytes,6,opt,name=timezone,proto3,oneof" json:"timezone,omitempty"`
+	// The ISO 4217 code of the currency the amounts are in, such as "EUR". Receipts without one are in dollars.
+	// The exchange rate the item prices are scored at is taken from the server's rates.
+	Currency *string `protobuf:"bytes,7,opt,name=currency,proto3,oneof" json:"currency,omitempty"`
+	// The amount of the items before tax and tip, such as "6.00".
+	Subtotal *string `protobuf:"bytes,8,opt,name=subtotal,proto3,oneof" json:"subtotal,omitempty"`
+	// The tax charged on the receipt.
+	Tax *string `protobuf:"bytes,9,opt,name=tax,proto3,oneof" json:"tax,omitempty"`
+	// The tip or gratuity added to the receipt.
+	Tip *string `protobuf:"bytes,10,opt,name=tip,proto3,oneof" json:"tip,omitempty"`
+	// How the receipt was paid, if known.
+	PaymentMethod PaymentMethod `protobuf:"varint,11,opt,name=payment_method,json=paymentMethod,proto3,enum=receipts.v1.PaymentMethod" json:"payment_method,omitempty"`
+	// The number the retailer gives the store the purchase was made in.
+	StoreNumber *string `protobuf:"bytes,12,opt,name=store_number,json=storeNumber,proto3,oneof" json:"store_number,omitempty"`
+	// The number the store printed on the receipt, such as its transaction number.
+	ReceiptNumber *string `protobuf:"bytes,13,opt,name=receipt_number,json=receiptNumber,proto3,oneof" json:"receipt_number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Receipt) Reset() {
+	*x = Receipt{}
+	mi := &file_receipts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Receipt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Receipt) ProtoMessage() {}
+
+func (x *Receipt) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Receipt.ProtoReflect.Descriptor instead.
+func (*Receipt) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Receipt) GetRetailer() string {
+	if x != nil {
+		return x.Retailer
+	}
+	return ""
+}
+
+func (x *Receipt) GetPurchaseDate() string {
+	if x != nil {
+		return x.PurchaseDate
+	}
+	return ""
+}
+
+func (x *Receipt) GetPurchaseTime() string {
+	if x != nil {
+		return x.PurchaseTime
+	}
+	return ""
+}
+
+func (x *Receipt) GetItems() []*Item {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *Receipt) GetTotal() string {
+	if x != nil {
+		return x.Total
+	}
+	return ""
+}
+
+func (x *Receipt) GetTimezone() string {
+	if x != nil && x.Timezone != nil {
+		return *x.Timezone
+	}
+	return ""
+}
+
+func (x *Receipt) GetCurrency() string {
+	if x != nil && x.Currency != nil {
+		return *x.Currency
+	}
+	return ""
+}
+
+func (x *Receipt) GetSubtotal() string {
+	if x != nil && x.Subtotal != nil {
+		return *x.Subtotal
+	}
+	return ""
+}
+
+func (x *Receipt) GetTax() string {
+	if x != nil && x.Tax != nil {
+		return *x.Tax
+	}
+	return ""
+}
+
+func (x *Receipt) GetTip() string {
+	if x != nil && x.Tip != nil {
+		return *x.Tip
+	}
+	return ""
+}
+
+func (x *Receipt) GetPaymentMethod() PaymentMethod {
+	if x != nil {
+		return x.PaymentMethod
+	}
+	return PaymentMethod_PAYMENT_METHOD_UNSPECIFIED
+}
+
+func (x *Receipt) GetStoreNumber() string {
+	if x != nil && x.StoreNumber != nil {
+		return *x.StoreNumber
+	}
+	return ""
+}
+
+func (x *Receipt) GetReceiptNumber() string {
+	if x != nil && x.ReceiptNumber != nil {
+		return *x.ReceiptNumber
+	}
+	return ""
+}
+
+// Item is a line of a receipt.
+type Item struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The Short Product Description for the item.
+	ShortDescription string `protobuf:"bytes,1,opt,name=short_description,json=shortDescription,proto3" json:"short_description,omitempty"`
+	// The total price paid for this item, such as "6.49".
+	Price string `protobuf:"bytes,2,opt,name=price,proto3" json:"price,omitempty"`
+	// The number of units the item was bought in. An item without one is a single unit.
+	Quantity *int32 `protobuf:"varint,3,opt,name=quantity,proto3,oneof" json:"quantity,omitempty"`
+	// The price of one unit of the item, before any discount.
+	UnitPrice *string `protobuf:"bytes,4,opt,name=unit_price,json=unitPrice,proto3,oneof" json:"unit_price,omitempty"`
+	// The discount taken off the item, which the price already accounts for.
+	Discount      *string `protobuf:"bytes,5,opt,name=discount,proto3,oneof" json:"discount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Item) Reset() {
+	*x = Item{}
+	mi := &file_receipts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Item) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Item) ProtoMessage() {}
+
+func (x *Item) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Item.ProtoReflect.Descriptor instead.
+func (*Item) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Item) GetShortDescription() string {
+	if x != nil {
+		return x.ShortDescription
+	}
+	return ""
+}
+
+func (x *Item) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Item) GetQuantity() int32 {
+	if x != nil && x.Quantity != nil {
+		return *x.Quantity
+	}
+	return 0
+}
+
+func (x *Item) GetUnitPrice() string {
+	if x != nil && x.UnitPrice != nil {
+		return *x.UnitPrice
+	}
+	return ""
+}
+
+func (x *Item) GetDiscount() string {
+	if x != nil && x.Discount != nil {
+		return *x.Discount
+	}
+	return ""
+}
+
+type ProcessReceiptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Receipt       *Receipt               `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessReceiptRequest) Reset() {
+	*x = ProcessReceiptRequest{}
+	mi := &file_receipts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessReceiptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessReceiptRequest) ProtoMessage() {}
+
+func (x *ProcessReceiptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessReceiptRequest.ProtoReflect.Descriptor instead.
+func (*ProcessReceiptRequest) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProcessReceiptRequest) GetReceipt() *Receipt {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+type ProcessReceiptResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The ID assigned to the receipt.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessReceiptResponse) Reset() {
+	*x = ProcessReceiptResponse{}
+	mi := &file_receipts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessReceiptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessReceiptResponse) ProtoMessage() {}
+
+func (x *ProcessReceiptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessReceiptResponse.ProtoReflect.Descriptor instead.
+func (*ProcessReceiptResponse) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProcessReceiptResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// ProcessReceiptResult answers one receipt of a batch.
+type ProcessReceiptResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Outcome:
+	//
+	//	*ProcessReceiptResult_Id
+	//	*ProcessReceiptResult_Error
+	Outcome       isProcessReceiptResult_Outcome `protobuf_oneof:"outcome"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessReceiptResult) Reset() {
+	*x = ProcessReceiptResult{}
+	mi := &file_receipts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessReceiptResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessReceiptResult) ProtoMessage() {}
+
+func (x *ProcessReceiptResult) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessReceiptResult.ProtoReflect.Descriptor instead.
+func (*ProcessReceiptResult) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProcessReceiptResult) GetOutcome() isProcessReceiptResult_Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+func (x *ProcessReceiptResult) GetId() string {
+	if x != nil {
+		if x, ok := x.Outcome.(*ProcessReceiptResult_Id); ok {
+			return x.Id
+		}
+	}
+	return ""
+}
+
+func (x *ProcessReceiptResult) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Outcome.(*ProcessReceiptResult_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isProcessReceiptResult_Outcome interface {
+	isProcessReceiptResult_Outcome()
+}
+
+type ProcessReceiptResult_Id struct {
+	// The ID assigned to the receipt.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3,oneof"`
+}
+
+type ProcessReceiptResult_Error struct {
+	// Why the receipt was not stored.
+	Error *Error `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ProcessReceiptResult_Id) isProcessReceiptResult_Outcome() {}
+
+func (*ProcessReceiptResult_Error) isProcessReceiptResult_Outcome() {}
+
+// Error describes why a request failed, like the problem responses of the HTTP API.
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The stable problem code, such as "duplicate_receipt".
+	Code string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Explains this occurrence of the error.
+	Detail string `protobuf:"bytes,2,opt,name=detail,proto3" json:"detail,omitempty"`
+	// Each field violating the API contract, when the receipt was rejected by validation.
+	Errors []*FieldError `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	// The earlier receipts a rejected duplicate matches.
+	DuplicateOf   []string `protobuf:"bytes,4,rep,name=duplicate_of,json=duplicateOf,proto3" json:"duplicate_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_receipts_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Error) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Error) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *Error) GetErrors() []*FieldError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *Error) GetDuplicateOf() []string {
+	if x != nil {
+		return x.DuplicateOf
+	}
+	return nil
+}
+
+// FieldError describes one way a receipt violates the API contract.
+type FieldError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A JSON pointer to the invalid value in the HTTP representation, such as "/items/0/price".
+	Field         string `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldError) Reset() {
+	*x = FieldError{}
+	mi := &file_receipts_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldError) ProtoMessage() {}
+
+func (x *FieldError) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldError.ProtoReflect.Descriptor instead.
+func (*FieldError) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FieldError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type GetPointsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The ID of the receipt.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPointsRequest) Reset() {
+	*x = GetPointsRequest{}
+	mi := &file_receipts_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPointsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPointsRequest) ProtoMessage() {}
+
+func (x *GetPointsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPointsRequest.ProtoReflect.Descriptor instead.
+func (*GetPointsRequest) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetPointsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetPointsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The position of the receipt in the review workflow.
+	Status Status `protobuf:"varint,1,opt,name=status,proto3,enum=receipts.v1.Status" json:"status,omitempty"`
+	// The points awarded. Only set once the receipt has been approved.
+	Points        int64 `protobuf:"varint,2,opt,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPointsResponse) Reset() {
+	*x = GetPointsResponse{}
+	mi := &file_receipts_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPointsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPointsResponse) ProtoMessage() {}
+
+func (x *GetPointsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_receipts_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPointsResponse.ProtoReflect.Descriptor instead.
+func (*GetPointsResponse) Descriptor() ([]byte, []int) {
+	return file_receipts_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetPointsResponse) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *GetPointsResponse) GetPoints() int64 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+var File_receipts_proto protoreflect.FileDescriptor
+
+const file_receipts_proto_rawDesc = "" +
+	"\n" +
+	"\x0ereceipts.proto\x12\vreceipts.v1\"\xb1\x04\n" +
+	"\aReceipt\x12\x1a\n" +
+	"\bretailer\x18\x01 \x01(\tR\bretailer\x12#\n" +
+	"\rpurchase_date\x18\x02 \x01(\tR\fpurchaseDate\x12#\n" +
+	"\rpurchase_time\x18\x03 \x01(\tR\fpurchaseTime\x12'\n" +
+	"\x05items\x18\x04 \x03(\v2\x11.receipts.v1.ItemR\x05items\x12\x14\n" +
+	"\x05total\x18\x05 \x01(\tR\x05total\x12\x1f\n" +
+	"\btimezone\x18\x06 \x01(\tH\x00R\btimezone\x88\x01\x01\x12\x1f\n" +
+	"\bcurrency\x18\a \x01(\tH\x01R\bcurrency\x88\x01\x01\x12\x1f\n" +
+	"\bsubtotal\x18\b \x01(\tH\x02R\bsubtotal\x88\x01\x01\x12\x15\n" +
+	"\x03tax\x18\t \x01(\tH\x03R\x03tax\x88\x01\x01\x12\x15\n" +
+	"\x03tip\x18\n" +
+	" \x01(\tH\x04R\x03tip\x88\x01\x01\x12A\n" +
+	"\x0epayment_method\x18\v \x01(\x0e2\x1a.receipts.v1.PaymentMethodR\rpaymentMethod\x12&\n" +
+	"\fstore_number\x18\f \x01(\tH\x05R\vstoreNumber\x88\x01\x01\x12*\n" +
+	"\x0ereceipt_number\x18\r \x01(\tH\x06R\rreceiptNumber\x88\x01\x01B\v\n" +
+	"\t_timezoneB\v\n" +
+	"\t_currencyB\v\n" +
+	"\t_subtotalB\x06\n" +
+	"\x04_taxB\x06\n" +
+	"\x04_tipB\x0f\n" +
+	"\r_store_numberB\x11\n" +
+	"\x0f_receipt_number\"\xd8\x01\n" +
+	"\x04Item\x12+\n" +
+	"\x11short_description\x18\x01 \x01(\tR\x10shortDescription\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\tR\x05price\x12\x1f\n" +
+	"\bquantity\x18\x03 \x01(\x05H\x00R\bquantity\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"unit_price\x18\x04 \x01(\tH\x01R\tunitPrice\x88\x01\x01\x12\x1f\n" +
+	"\bdiscount\x18\x05 \x01(\tH\x02R\bdiscount\x88\x01\x01B\v\n" +
+	"\t_quantityB\r\n" +
+	"\v_unit_priceB\v\n" +
+	"\t_discount\"G\n" +
+	"\x15ProcessReceiptRequest\x12.\n" +
+	"\areceipt\x18\x01 \x01(\v2\x14.receipts.v1.ReceiptR\areceipt\"(\n" +
+	"\x16ProcessReceiptResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"_\n" +
+	"\x14ProcessReceiptResult\x12\x10\n" +
+	"\x02id\x18\x01 \x01(\tH\x00R\x02id\x12*\n" +
+	"\x05error\x18\x02 \x01(\v2\x12.receipts.v1.ErrorH\x00R\x05errorB\t\n" +
+	"\aoutcome\"\x87\x01\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06detail\x18\x02 \x01(\tR\x06detail\x12/\n" +
+	"\x06errors\x18\x03 \x03(\v2\x17.receipts.v1.FieldErrorR\x06errors\x12!\n" +
+	"\fduplicate_of\x18\x04 \x03(\tR\vduplicateOf\"<\n" +
+	"\n" +
+	"FieldError\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\"\n" +
+	"\x10GetPointsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"X\n" +
+	"\x11GetPointsResponse\x12+\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x13.receipts.v1.StatusR\x06status\x12\x16\n" +
+	"\x06points\x18\x02 \x01(\x03R\x06points*\xea\x01\n" +
+	"\rPaymentMethod\x12\x1e\n" +
+	"\x1aPAYMENT_METHOD_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13PAYMENT_METHOD_CASH\x10\x01\x12\x19\n" +
+	"\x15PAYMENT_METHOD_CREDIT\x10\x02\x12\x18\n" +
+	"\x14PAYMENT_METHOD_DEBIT\x10\x03\x12\x1c\n" +
+	"\x18PAYMENT_METHOD_GIFT_CARD\x10\x04\x12\x19\n" +
+	"\x15PAYMENT_METHOD_MOBILE\x10\x05\x12\x18\n" +
+	"\x14PAYMENT_METHOD_CHECK\x10\x06\x12\x18\n" +
+	"\x14PAYMENT_METHOD_OTHER\x10\a*e\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15STATUS_PENDING_REVIEW\x10\x01\x12\x13\n" +
+	"\x0fSTATUS_APPROVED\x10\x02\x12\x13\n" +
+	"\x0fSTATUS_REJECTED\x10\x032\x8f\x02\n" +
+	"\bReceipts\x12Y\n" +
+	"\x0eProcessReceipt\x12\".receipts.v1.ProcessReceiptRequest\x1a#.receipts.v1.ProcessReceiptResponse\x12J\n" +
+	"\tGetPoints\x12\x1d.receipts.v1.GetPointsRequest\x1a\x1e.receipts.v1.GetPointsResponse\x12\\\n" +
+	"\x0fProcessReceipts\x12\".receipts.v1.ProcessReceiptRequest\x1a!.receipts.v1.ProcessReceiptResult(\x010\x01B\x0fZ\rfetch-app/rpcb\x06proto3"
+
+var (
+	file_receipts_proto_rawDescOnce sync.Once
+	file_receipts_proto_rawDescData []byte
+)
+
+func file_receipts_proto_rawDescGZIP() []byte {
+	file_receipts_proto_rawDescOnce.Do(func() {
+		file_receipts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_receipts_proto_rawDesc), len(file_receipts_proto_rawDesc)))
+	})
+	return file_receipts_proto_rawDescData
+}
+
+var file_receipts_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_receipts_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_receipts_proto_goTypes = []any{
+	(PaymentMethod)(0),             // 0: receipts.v1.PaymentMethod
+	(Status)(0),                    // 1: receipts.v1.Status
+	(*Receipt)(nil),                // 2: receipts.v1.Receipt
+	(*Item)(nil),                   // 3: receipts.v1.Item
+	(*ProcessReceiptRequest)(nil),  // 4: receipts.v1.ProcessReceiptRequest
+	(*ProcessReceiptResponse)(nil), // 5: receipts.v1.ProcessReceiptResponse
+	(*ProcessReceiptResult)(nil),   // 6: receipts.v1.ProcessReceiptResult
+	(*Error)(nil),                  // 7: receipts.v1.Error
+	(*FieldError)(nil),             // 8: receipts.v1.FieldError
+	(*GetPointsRequest)(nil),       // 9: receipts.v1.GetPointsRequest
+	(*GetPointsResponse)(nil),      // 10: receipts.v1.GetPointsResponse
+}
+var file_receipts_proto_depIdxs = []int32{
+	3,  // 0: receipts.v1.Receipt.items:type_name -> receipts.v1.Item
+	0,  // 1: receipts.v1.Receipt.payment_method:type_name -> receipts.v1.PaymentMethod
+	2,  // 2: receipts.v1.ProcessReceiptRequest.receipt:type_name -> receipts.v1.Receipt
+	7,  // 3: receipts.v1.ProcessReceiptResult.error:type_name -> receipts.v1.Error
+	8,  // 4: receipts.v1.Error.errors:type_name -> receipts.v1.FieldError
+	1,  // 5: receipts.v1.GetPointsResponse.status:type_name -> receipts.v1.Status
+	4,  // 6: receipts.v1.Receipts.ProcessReceipt:input_type -> receipts.v1.ProcessReceiptRequest
+	9,  // 7: receipts.v1.Receipts.GetPoints:input_type -> receipts.v1.GetPointsRequest
+	4,  // 8: receipts.v1.Receipts.ProcessReceipts:input_type -> receipts.v1.ProcessReceiptRequest
+	5,  // 9: receipts.v1.Receipts.ProcessReceipt:output_type -> receipts.v1.ProcessReceiptResponse
+	10, // 10: receipts.v1.Receipts.GetPoints:output_type -> receipts.v1.GetPointsResponse
+	6,  // 11: receipts.v1.Receipts.ProcessReceipts:output_type -> receipts.v1.ProcessReceiptResult
+	9,  // [9:12] is the sub-list for method output_type
+	6,  // [6:9] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_receipts_proto_init() }
+func file_receipts_proto_init() {
+	if File_receipts_proto != nil {
+		return
+	}
+	file_receipts_proto_msgTypes[0].OneofWrappers = []any{}
+	file_receipts_proto_msgTypes[1].OneofWrappers = []any{}
+	file_receipts_proto_msgTypes[4].OneofWrappers = []any{
+		(*ProcessReceiptResult_Id)(nil),
+		(*ProcessReceiptResult_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_receipts_proto_rawDesc), len(file_receipts_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_receipts_proto_goTypes,
+		DependencyIndexes: file_receipts_proto_depIdxs,
+		EnumInfos:         file_receipts_proto_enumTypes,
+		MessageInfos:      file_receipts_proto_msgTypes,
+	}.Build()
+	File_receipts_proto = out.File
+	file_receipts_proto_goTypes = nil
+	file_receipts_proto_depIdxs = nil
+}
@@ -0,0 +1,260 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"fmt"
+	"github.com/oapi-codegen/runtime/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// PrincipalMetadata is the metadata key that names who a receipt is submitted by.
+const PrincipalMetadata = "x-principal-id"
+
+// Server implements the Receipts gRPC service on top of the same receipts.Service as the HTTP API,
+// so both share one store and scoring pipeline.
+type Server struct {
+	UnimplementedReceiptsServer
+
+	service   *receipts.Service
+	validator *validation.Validator
+}
+
+// NewServer creates a Server for the service.
+//
+// Parameters:
+//
+//	service   - The receipt service shared with the HTTP API.
+//	validator - Checks submitted receipts against the Receipt schema of the OpenAPI spec, like the HTTP API does.
+//	            Nil skips the check.
+//
+// Returns:
+//
+//	The Server, ready to be registered with RegisterReceiptsServer.
+func NewServer(service *receipts.Service, validator *validation.Validator) *Server {
+	return &Server{service: service, validator: validator}
+}
+
+// ProcessReceipt submits a receipt for processing and returns the ID assigned to it.
+// Failures are returned as a status carrying an Error detail with the problem code.
+func (s *Server) ProcessReceipt(ctx context.Context, request *ProcessReceiptRequest) (*ProcessReceiptResponse, error) {
+	id, err := s.process(ctx, request.GetReceipt())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &ProcessReceiptResponse{Id: id}, nil
+}
+
+// GetPoints returns the points awarded for a receipt, or only its status while it is pending review.
+// A receipt rejected on review fails with FailedPrecondition, and an unknown ID with NotFound.
+func (s *Server) GetPoints(ctx context.Context, request *GetPointsRequest) (*GetPointsResponse, error) {
+	response, err := s.service.GetReceiptsIdPoints(ctx, server.GetReceiptsIdPointsRequestObject{Id: request.GetId()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	switch response := response.(type) {
+	case server.GetReceiptsIdPoints200JSONResponse:
+		return &GetPointsResponse{Status: Status_STATUS_APPROVED, Points: response.Points}, nil
+	case server.GetReceiptsIdPoints202JSONResponse:
+		return &GetPointsResponse{Status: Status_STATUS_PENDING_REVIEW}, nil
+	default:
+		return nil, toStatus(fmt.Errorf("unexpected points response %T", response))
+	}
+}
+
+// ProcessReceipts processes a stream of receipts, sending one result per receipt in the order they arrive.
+// A receipt that cannot be processed gets a result with its error, so one bad receipt does not end the batch.
+func (s *Server) ProcessReceipts(stream Receipts_ProcessReceiptsServer) error {
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := &ProcessReceiptResult{}
+		id, err := s.process(stream.Context(), request.GetReceipt())
+		var p *problem.Problem
+		switch {
+		case err == nil:
+			result.Outcome = &ProcessReceiptResult_Id{Id: id}
+		case errors.As(err, &p) && p.Code != problem.CodeInternal:
+			result.Outcome = &ProcessReceiptResult_Error{Error: toError(p)}
+		default:
+			return toStatus(err)
+		}
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+}
+
+// process validates and stores a receipt, returning its ID or a problem explaining why it was not stored. The receipt
+// is submitted by the principal in the call's x-principal-id metadata, like the X-Principal-Id header over HTTP.
+func (s *Server) process(ctx context.Context, message *Receipt) (string, error) {
+	if message == nil {
+		return "", problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "A receipt is required")
+	}
+	receipt, err := s.toReceipt(message)
+	if err != nil {
+		return "", err
+	}
+
+	request := server.PostReceiptsProcessRequestObject{Body: &receipt}
+	if principal := principalOf(ctx); principal != "" {
+		request.Params.XPrincipalId = &principal
+	}
+	response, err := s.service.PostReceiptsProcess(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	processed, ok := response.(server.PostReceiptsProcess200JSONResponse)
+	if !ok {
+		return "", fmt.Errorf("unexpected process response %T", response)
+	}
+	return processed.Id, nil
+}
+
+// paymentMethods maps the payment methods of receipt messages to those of the HTTP API.
+var paymentMethods = map[PaymentMethod]server.PaymentMethod{
+	PaymentMethod_PAYMENT_METHOD_CASH:      server.Cash,
+	PaymentMethod_PAYMENT_METHOD_CREDIT:    server.Credit,
+	PaymentMethod_PAYMENT_METHOD_DEBIT:     server.Debit,
+	PaymentMethod_PAYMENT_METHOD_GIFT_CARD: server.GiftCard,
+	PaymentMethod_PAYMENT_METHOD_MOBILE:    server.Mobile,
+	PaymentMethod_PAYMENT_METHOD_CHECK:     server.Check,
+	PaymentMethod_PAYMENT_METHOD_OTHER:     server.Other,
+}
+
+// principalOf returns the principal in the x-principal-id metadata of an incoming call, or empty if there is none.
+func principalOf(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, PrincipalMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// toReceipt converts a receipt message into the model of the HTTP API, rewriting the purchase date and time into
+// their canonical forms like the HTTP API does, and checking it against the Receipt schema.
+func (s *Server) toReceipt(message *Receipt) (server.Receipt, error) {
+	receipt := server.Receipt{
+		Retailer:      message.GetRetailer(),
+		PurchaseTime:  message.GetPurchaseTime(),
+		Items:         make([]server.Item, 0, len(message.GetItems())),
+		Total:         message.GetTotal(),
+		Timezone:      message.Timezone,
+		Currency:      message.Currency,
+		Subtotal:      message.Subtotal,
+		Tax:           message.Tax,
+		Tip:           message.Tip,
+		StoreNumber:   message.StoreNumber,
+		ReceiptNumber: message.ReceiptNumber,
+	}
+	for _, item := range message.GetItems() {
+		converted := server.Item{
+			ShortDescription: item.GetShortDescription(),
+			Price:            item.GetPrice(),
+			UnitPrice:        item.UnitPrice,
+			Discount:         item.Discount,
+		}
+		if item.Quantity != nil {
+			quantity := int(item.GetQuantity())
+			converted.Quantity = &quantity
+		}
+		receipt.Items = append(receipt.Items, converted)
+	}
+
+	var fields []validation.FieldError
+	if method := message.GetPaymentMethod(); method != PaymentMethod_PAYMENT_METHOD_UNSPECIFIED {
+		if converted, ok := paymentMethods[method]; ok {
+			receipt.PaymentMethod = &converted
+		} else {
+			fields = append(fields, validation.FieldError{Field: "/paymentMethod", Message: fmt.Sprintf("unknown payment method %d", method)})
+		}
+	}
+	normalizer := s.service.Normalizer
+	date := message.GetPurchaseDate()
+	if canonical, ok := normalizer.Date(date, normalizer.Order); ok {
+		date = canonical
+	}
+	purchaseDate, err := time.Parse(types.DateFormat, date)
+	if err != nil {
+		fields = append(fields, validation.FieldError{Field: "/purchaseDate", Message: "must be a date, such as 2022-03-20 or 03/20/2022"})
+	}
+	receipt.PurchaseDate = types.Date{Time: purchaseDate}
+	normalizer.Receipt(&receipt)
+
+	if s.validator != nil {
+		var violation *validation.Error
+		if err := s.validator.ValidateSchema("Receipt", receipt); errors.As(err, &violation) {
+			fields = append(fields, violation.Fields...)
+		} else if err != nil {
+			return server.Receipt{}, err
+		}
+	}
+	if len(fields) > 0 {
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "The receipt does not match the API contract")
+		p.Errors = fields
+		return server.Receipt{}, p
+	}
+	return receipt, nil
+}
+
+// toError converts a problem into the Error message of the API.
+func toError(p *problem.Problem) *Error {
+	message := &Error{
+		Code:        string(p.Code),
+		Detail:      p.Detail,
+		DuplicateOf: p.DuplicateOf,
+	}
+	for _, field := range p.Errors {
+		message.Errors = append(message.Errors, &FieldError{Field: field.Field, Message: field.Message})
+	}
+	return message
+}
+
+// toStatus converts an error from the service into a gRPC status. Problems keep their code and detail in an
+// Error detail; anything else is logged and reported as Internal without its text.
+func toStatus(err error) error {
+	var p *problem.Problem
+	if !errors.As(err, &p) || p.Code == problem.CodeInternal {
+		log.Print(err)
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	code := codes.Internal
+	switch {
+	case p.Code == problem.CodeDuplicateReceipt:
+		code = codes.AlreadyExists
+	case p.Status == http.StatusBadRequest, p.Status == http.StatusUnsupportedMediaType,
+		p.Status == http.StatusUnprocessableEntity:
+		code = codes.InvalidArgument
+	case p.Status == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case p.Status == http.StatusForbidden:
+		code = codes.PermissionDenied
+	case p.Status == http.StatusNotFound:
+		code = codes.NotFound
+	case p.Status == http.StatusConflict, p.Status == http.StatusPreconditionFailed:
+		code = codes.FailedPrecondition
+	case p.Status == http.StatusRequestEntityTooLarge, p.Status == http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	}
+	st, detailErr := status.New(code, p.Error()).WithDetails(toError(p))
+	if detailErr != nil {
+		return status.Error(code, p.Error())
+	}
+	return st.Err()
+}
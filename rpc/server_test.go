@@ -0,0 +1,310 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fetch-app/currency"
+	"fetch-app/fraud"
+	"fetch-app/normalize"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// newTestClient serves the service over an in-process connection and returns a client for it.
+func newTestClient(t *testing.T, service *receipts.Service) ReceiptsClient {
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		t.Fatalf("Error loading spec: %v", err)
+	}
+	validator, err := validation.New(swagger)
+	if err != nil {
+		t.Fatalf("Error creating validator: %v", err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	RegisterReceiptsServer(grpcServer, NewServer(service, validator))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Error dialing server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewReceiptsClient(conn)
+}
+
+// testReceipt returns a receipt earning 20 points.
+func testReceipt() *Receipt {
+	return &Receipt{
+		Retailer:     "Target",
+		PurchaseDate: "2022-01-01",
+		PurchaseTime: "13:01",
+		Items: []*Item{
+			{ShortDescription: "Mountain Dew 12PK", Price: "6.49"},
+			{ShortDescription: "Emils Cheese Pizza", Price: "12.25"},
+		},
+		Total: "18.74",
+	}
+}
+
+// errorDetail extracts the Error detail of a status.
+func errorDetail(t *testing.T, err error) *Error {
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*Error); ok {
+			return detail
+		}
+	}
+	t.Fatalf("Expected an Error detail in %v", err)
+	return nil
+}
+
+// TestProcessReceiptAndGetPoints tests submitting a receipt and reading its points over gRPC.
+func TestProcessReceiptAndGetPoints(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	client := newTestClient(t, service)
+
+	processed, err := client.ProcessReceipt(ctx, &ProcessReceiptRequest{Receipt: testReceipt()})
+	assert.NoError(t, err)
+
+	points, err := client.GetPoints(ctx, &GetPointsRequest{Id: processed.GetId()})
+	assert.NoError(t, err)
+	assert.Equal(t, Status_STATUS_APPROVED, points.GetStatus())
+	assert.Equal(t, int64(20), points.GetPoints())
+
+	// The receipt is in the store shared with the HTTP API
+	record, exists := service.Storage.Get(processed.GetId())
+	assert.True(t, exists)
+	assert.Equal(t, "Target", record.Receipt.Retailer)
+
+	// A resubmission is flagged, so its points are withheld
+	duplicate, err := client.ProcessReceipt(ctx, &ProcessReceiptRequest{Receipt: testReceipt()})
+	assert.NoError(t, err)
+	points, err = client.GetPoints(ctx, &GetPointsRequest{Id: duplicate.GetId()})
+	assert.NoError(t, err)
+	assert.Equal(t, Status_STATUS_PENDING_REVIEW, points.GetStatus())
+	assert.Zero(t, points.GetPoints())
+}
+
+// TestGetPointsNotFound tests the status for a receipt that does not exist.
+func TestGetPointsNotFound(t *testing.T) {
+	client := newTestClient(t, receipts.NewService(receipts.NewStorage()))
+
+	_, err := client.GetPoints(context.Background(), &GetPointsRequest{Id: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "receipt_not_found", errorDetail(t, err).GetCode())
+}
+
+// TestProcessReceiptInvalid tests that receipts are checked against the same schema as over HTTP.
+func TestProcessReceiptInvalid(t *testing.T) {
+	client := newTestClient(t, receipts.NewService(receipts.NewStorage()))
+
+	receipt := testReceipt()
+	receipt.PurchaseDate = "02/30/2022"
+	receipt.Items[0].Price = "6.5"
+	_, err := client.ProcessReceipt(context.Background(), &ProcessReceiptRequest{Receipt: receipt})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	detail := errorDetail(t, err)
+	assert.Equal(t, "validation_failed", detail.GetCode())
+	var fields []string
+	for _, field := range detail.GetErrors() {
+		fields = append(fields, field.GetField())
+	}
+	assert.ElementsMatch(t, []string{"/purchaseDate", "/items/0/price"}, fields)
+
+	_, err = client.ProcessReceipt(context.Background(), &ProcessReceiptRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestProcessReceiptDetails tests that the amounts, payment and store details of a receipt are kept, and checked
+// against the same schema as over HTTP.
+// TestProcessReceiptNormalizes tests that dates and times are read in the forms the HTTP API accepts, in the
+// configured order.
+func TestProcessReceiptNormalizes(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	service.Normalizer = normalize.Normalizer{Order: normalize.DayFirst}
+	client := newTestClient(t, service)
+
+	receipt := testReceipt()
+	receipt.PurchaseDate, receipt.PurchaseTime = "03/04/2022", "1:01 PM"
+	processed, err := client.ProcessReceipt(context.Background(), &ProcessReceiptRequest{Receipt: receipt})
+	assert.NoError(t, err)
+
+	record, _ := service.Storage.Get(processed.GetId())
+	assert.Equal(t, "2022-04-03", record.Receipt.PurchaseDate.String())
+	assert.Equal(t, "13:01", record.Receipt.PurchaseTime)
+}
+
+func TestProcessReceiptDetails(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	client := newTestClient(t, service)
+
+	subtotal, tax, tip, store, number := "18.74", "1.26", "1.00", "1234", "0042-7781"
+	quantity, unitPrice, discount := int32(2), "3.50", "0.51"
+	receipt := testReceipt()
+	receipt.Total = "20.49"
+	receipt.Subtotal, receipt.Tax, receipt.Tip = &subtotal, &tax, &tip
+	receipt.StoreNumber, receipt.ReceiptNumber = &store, &number
+	receipt.PaymentMethod = PaymentMethod_PAYMENT_METHOD_GIFT_CARD
+	receipt.Items[0].Quantity, receipt.Items[0].UnitPrice, receipt.Items[0].Discount = &quantity, &unitPrice, &discount
+	processed, err := client.ProcessReceipt(ctx, &ProcessReceiptRequest{Receipt: receipt})
+	assert.NoError(t, err)
+
+	record, _ := service.Storage.Get(processed.GetId())
+	assert.Equal(t, &subtotal, record.Receipt.Subtotal)
+	assert.Equal(t, &tax, record.Receipt.Tax)
+	assert.Equal(t, &tip, record.Receipt.Tip)
+	assert.Equal(t, &store, record.Receipt.StoreNumber)
+	assert.Equal(t, &number, record.Receipt.ReceiptNumber)
+	if assert.NotNil(t, record.Receipt.PaymentMethod) {
+		assert.Equal(t, server.GiftCard, *record.Receipt.PaymentMethod)
+	}
+	item := record.Receipt.Items[0]
+	if assert.NotNil(t, item.Quantity) {
+		assert.Equal(t, 2, *item.Quantity)
+	}
+	assert.Equal(t, &unitPrice, item.UnitPrice)
+	assert.Equal(t, &discount, item.Discount)
+
+	// The details are checked like the rest of the receipt
+	quantity = 0
+	receipt.PaymentMethod = PaymentMethod(42)
+	_, err = client.ProcessReceipt(ctx, &ProcessReceiptRequest{Receipt: receipt})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	var fields []string
+	for _, field := range errorDetail(t, err).GetErrors() {
+		fields = append(fields, field.GetField())
+	}
+	assert.ElementsMatch(t, []string{"/paymentMethod", "/items/0/quantity"}, fields)
+}
+
+// TestProcessReceiptCurrency tests that a receipt's currency is kept, and scored at the server's rate.
+func TestProcessReceiptCurrency(t *testing.T) {
+	ctx := context.Background()
+	service := receipts.NewService(receipts.NewStorage())
+	var err error
+	service.Rates, err = currency.NewRates("USD", map[string]string{"JPY": "150"})
+	assert.NoError(t, err)
+	client := newTestClient(t, service)
+
+	code := "JPY"
+	receipt := &Receipt{
+		Retailer:     "Target",
+		PurchaseDate: "2022-01-01",
+		PurchaseTime: "13:01",
+		Items:        []*Item{{ShortDescription: "Gum", Price: "1500"}},
+		Total:        "1500",
+		Currency:     &code,
+	}
+	processed, err := client.ProcessReceipt(ctx, &ProcessReceiptRequest{Receipt: receipt})
+	assert.NoError(t, err)
+
+	record, _ := service.Storage.Get(processed.GetId())
+	assert.Equal(t, "JPY", *record.Receipt.Currency)
+	if assert.NotNil(t, record.Receipt.ExchangeRate) {
+		assert.Equal(t, "150", *record.Receipt.ExchangeRate)
+	}
+}
+
+// TestProcessReceiptsStream tests that a batch is answered receipt by receipt.
+func TestProcessReceiptsStream(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	service.DuplicatePolicy = fraud.PolicyReject
+	client := newTestClient(t, service)
+
+	stream, err := client.ProcessReceipts(context.Background())
+	assert.NoError(t, err)
+
+	other := testReceipt()
+	other.Retailer = "Walgreens"
+	invalid := testReceipt()
+	invalid.Total = "lots"
+	for _, receipt := range []*Receipt{testReceipt(), testReceipt(), invalid, other} {
+		assert.NoError(t, stream.Send(&ProcessReceiptRequest{Receipt: receipt}))
+	}
+	assert.NoError(t, stream.CloseSend())
+
+	var results []*ProcessReceiptResult
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		results = append(results, result)
+	}
+
+	// Each receipt is answered in order, and the failures do not end the batch
+	assert.Len(t, results, 4)
+	original := results[0].GetId()
+	assert.NotEmpty(t, original)
+	assert.Equal(t, "duplicate_receipt", results[1].GetError().GetCode())
+	assert.Equal(t, []string{original}, results[1].GetError().GetDuplicateOf())
+	assert.Equal(t, "validation_failed", results[2].GetError().GetCode())
+	assert.NotEmpty(t, results[3].GetId())
+	assert.Len(t, service.Storage.Receipts, 2)
+}
+
+// TestProcessReceiptPrincipal tests that receipts are submitted by the principal in the call's metadata.
+func TestProcessReceiptPrincipal(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	client := newTestClient(t, service)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), PrincipalMetadata, "user-1")
+	processed, err := client.ProcessReceipt(ctx, &ProcessReceiptRequest{Receipt: testReceipt()})
+	assert.NoError(t, err)
+	record, _ := service.Storage.Get(processed.GetId())
+	assert.Equal(t, "user-1", record.Principal)
+
+	other := testReceipt()
+	other.Retailer = "Walgreens"
+	processed, err = client.ProcessReceipt(context.Background(), &ProcessReceiptRequest{Receipt: other})
+	assert.NoError(t, err)
+	record, _ = service.Storage.Get(processed.GetId())
+	assert.Empty(t, record.Principal)
+}
+
+// TestToStatus tests that problems map to the gRPC codes of their HTTP status.
+func TestToStatus(t *testing.T) {
+	for httpStatus, code := range map[int]codes.Code{
+		http.StatusBadRequest:            codes.InvalidArgument,
+		http.StatusUnauthorized:          codes.Unauthenticated,
+		http.StatusForbidden:             codes.PermissionDenied,
+		http.StatusNotFound:              codes.NotFound,
+		http.StatusConflict:              codes.FailedPrecondition,
+		http.StatusPreconditionFailed:    codes.FailedPrecondition,
+		http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+		http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+		http.StatusUnprocessableEntity:   codes.InvalidArgument,
+		http.StatusTooManyRequests:       codes.ResourceExhausted,
+	} {
+		err := toStatus(problem.New(httpStatus, problem.CodeInvalidRequest, "detail"))
+		assert.Equal(t, code, status.Code(err), httpStatus)
+		assert.Equal(t, "invalid_request", errorDetail(t, err).GetCode())
+	}
+	assert.Equal(t, codes.AlreadyExists, status.Code(toStatus(problem.New(http.StatusConflict, problem.CodeDuplicateReceipt, "detail"))))
+	assert.Equal(t, codes.Internal, status.Code(toStatus(errors.New("disk full"))))
+}
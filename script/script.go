@@ -0,0 +1,238 @@
+// Package script runs custom scoring rules written in Starlark, so promotions such as "double points at coffee
+// retailers on Mondays" can be added without a release. Scripts run in a sandbox without I/O or load, and every
+// run is bounded in execution steps and time.
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fetch-app/server"
+	"fmt"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxSteps is the number of Starlark execution steps a run may take when the limits name none.
+	DefaultMaxSteps = 100_000
+	// DefaultTimeout is how long a run may take when the limits name no timeout.
+	DefaultTimeout = 100 * time.Millisecond
+)
+
+// Limits bound each run of a rule, including the run of the script's top level when it is compiled.
+type Limits struct {
+	// MaxSteps is the number of execution steps a run may take. Zero means DefaultMaxSteps.
+	MaxSteps uint64
+	// Timeout is how long a run may take. Zero means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Rule is a compiled custom rule. It is safe for concurrent use.
+type Rule struct {
+	// Name identifies the rule, such as "coffee_mondays".
+	Name   string
+	score  starlark.Callable
+	limits Limits
+}
+
+// fileOptions are the Starlark dialect scripts are written in. It has no while loops or recursion, so only the
+// limits stop a runaway rule.
+var fileOptions = &syntax.FileOptions{}
+
+// predeclared are the names scripts can use besides the Starlark built-ins.
+var predeclared = starlark.StringDict{
+	"weekday": starlark.NewBuiltin("weekday", weekday),
+}
+
+// Compile compiles a rule and runs its top level. The script must define a function score(receipt, points) that
+// returns the points the rule awards and an explanation, as in:
+//
+//	def score(receipt, points):
+//	    if "coffee" in receipt["retailer"].lower() and weekday(receipt["purchaseDate"]) == "Monday":
+//	        return points, "Double points at coffee retailers on Mondays"
+//	    return 0, "Double points at coffee retailers on Mondays"
+//
+// receipt is the receipt as its JSON object, frozen, and points is what the built-in rules awarded.
+func Compile(name string, src []byte, limits Limits) (*Rule, error) {
+	_, program, err := starlark.SourceProgramOptions(fileOptions, name+".star", src, predeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+	rule := &Rule{Name: name, limits: limits}
+	var globals starlark.StringDict
+	err = rule.run(func(thread *starlark.Thread) (err error) {
+		globals, err = program.Init(thread, predeclared)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	globals.Freeze()
+	score, ok := globals["score"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s does not define a score(receipt, points) function", name)
+	}
+	rule.score = score
+	return rule, nil
+}
+
+// LoadDir compiles every .star file in a directory, naming each rule after its file, in name order.
+func LoadDir(dir string, limits Limits) ([]*Rule, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.star"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	rules := make([]*Rule, 0, len(paths))
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rule, err := Compile(strings.TrimSuffix(filepath.Base(path), ".star"), src, limits)
+		if err != nil {
+			return nil, fmt.Errorf("compiling %s: %w", path, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Score runs the rule on a receipt.
+//
+// Parameters:
+//
+//	receipt - The receipt being scored.
+//	points  - The points the built-in rules awarded it.
+//
+// Returns:
+//
+//	The points the rule awards and its explanation.
+//	An error if the script fails, exceeds its limits, or does not return a non-negative int and a string.
+func (r *Rule) Score(receipt server.Receipt, points int) (int, string, error) {
+	value, err := receiptValue(receipt)
+	if err != nil {
+		return 0, "", err
+	}
+	var result starlark.Value
+	err = r.run(func(thread *starlark.Thread) (err error) {
+		result, err = starlark.Call(thread, r.score, starlark.Tuple{value, starlark.MakeInt(points)}, nil)
+		return err
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	tuple, ok := result.(starlark.Tuple)
+	if !ok || len(tuple) != 2 {
+		return 0, "", fmt.Errorf("%s returned %s, expected a (points, explanation) tuple", r.Name, result.Type())
+	}
+	var awarded int
+	if err := starlark.AsInt(tuple[0], &awarded); err != nil || awarded < 0 {
+		return 0, "", fmt.Errorf("%s returned %s points, expected a non-negative int", r.Name, tuple[0])
+	}
+	explanation, ok := starlark.AsString(tuple[1])
+	if !ok {
+		return 0, "", fmt.Errorf("%s returned a %s explanation, expected a string", r.Name, tuple[1].Type())
+	}
+	return awarded, explanation, nil
+}
+
+// run runs a function on a new thread within the rule's limits. The thread cannot load modules and discards
+// whatever is printed.
+func (r *Rule) run(fn func(thread *starlark.Thread) error) error {
+	maxSteps, timeout := r.limits.MaxSteps, r.limits.Timeout
+	if maxSteps == 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	thread := &starlark.Thread{
+		Name:  r.Name,
+		Print: func(*starlark.Thread, string) {},
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not available to custom rules")
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	timer := time.AfterFunc(timeout, func() { thread.Cancel(fmt.Sprintf("ran for more than %s", timeout)) })
+	defer timer.Stop()
+	return fn(thread)
+}
+
+// receiptValue converts a receipt into a frozen Starlark dict of its JSON object, so scripts see the fields by
+// their API names.
+func receiptValue(receipt server.Receipt) (starlark.Value, error) {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	value, err := toStarlark(document)
+	if err != nil {
+		return nil, err
+	}
+	value.Freeze()
+	return value, nil
+}
+
+// toStarlark converts a decoded JSON value into a Starlark one. Whole numbers become ints.
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case float64:
+		if v == float64(int64(v)) {
+			return starlark.MakeInt64(int64(v)), nil
+		}
+		return starlark.Float(v), nil
+	case []interface{}:
+		elems := make([]starlark.Value, 0, len(v))
+		for _, elem := range v {
+			value, err := toStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, value)
+		}
+		return starlark.NewList(elems), nil
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(v))
+		for key, elem := range v {
+			value, err := toStarlark(elem)
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key), value); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to Starlark", v)
+}
+
+// weekday returns the English name of the weekday of a date such as "2022-01-01", the form of purchase dates.
+func weekday(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var date string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &date); err != nil {
+		return nil, err
+	}
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not a date", fn.Name(), date)
+	}
+	return starlark.String(parsed.Weekday().String()), nil
+}
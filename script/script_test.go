@@ -0,0 +1,149 @@
+package script
+
+import (
+	"fetch-app/server"
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const coffeeMondays = `
+explanation = "Double points at coffee retailers on Mondays"
+
+def score(receipt, points):
+    if "coffee" in receipt["retailer"].lower() and weekday(receipt["purchaseDate"]) == "Monday":
+        return points, explanation
+    return 0, explanation
+`
+
+// mondayReceipt creates a receipt purchased on a Monday.
+func mondayReceipt(retailer string) server.Receipt {
+	return server.Receipt{
+		Retailer:     retailer,
+		PurchaseDate: types.Date{Time: time.Date(2022, time.March, 21, 0, 0, 0, 0, time.UTC)},
+		PurchaseTime: "08:15",
+		Total:        "4.50",
+		Items:        []server.Item{{ShortDescription: "Latte", Price: "4.50"}},
+	}
+}
+
+func TestScore(t *testing.T) {
+	rule, err := Compile("coffee_mondays", []byte(coffeeMondays), Limits{})
+	assert.NoError(t, err)
+	assert.Equal(t, "coffee_mondays", rule.Name)
+
+	points, explanation, err := rule.Score(mondayReceipt("Corner Coffee"), 40)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, points)
+	assert.Equal(t, "Double points at coffee retailers on Mondays", explanation)
+
+	points, _, err = rule.Score(mondayReceipt("Target"), 40)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, points)
+}
+
+// TestScoreReceiptFields tests that scripts see the receipt by its API field names, with amounts as strings.
+func TestScoreReceiptFields(t *testing.T) {
+	rule, err := Compile("items", []byte(`
+def score(receipt, points):
+    total = 0.0
+    for item in receipt["items"]:
+        total += float(item["price"])
+    return len(receipt["items"]) + int(total), receipt["purchaseTime"]
+`), Limits{})
+	assert.NoError(t, err)
+	points, explanation, err := rule.Score(mondayReceipt("Target"), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, points)
+	assert.Equal(t, "08:15", explanation)
+
+	// The receipt is frozen, so a rule cannot change what the next one sees
+	rule, err = Compile("mutate", []byte(`
+def score(receipt, points):
+    receipt["retailer"] = "Free Points"
+    return 0, ""
+`), Limits{})
+	assert.NoError(t, err)
+	_, _, err = rule.Score(mondayReceipt("Target"), 0)
+	assert.ErrorContains(t, err, "frozen")
+}
+
+func TestScoreProblems(t *testing.T) {
+	tests := []struct {
+		name, src, expected string
+	}{
+		{"not a tuple", "def score(receipt, points):\n    return 1\n", "expected a (points, explanation) tuple"},
+		{"negative", "def score(receipt, points):\n    return -1, \"\"\n", "expected a non-negative int"},
+		{"explanation", "def score(receipt, points):\n    return 1, 2\n", "expected a string"},
+		{"fails", "def score(receipt, points):\n    return receipt[\"missing\"]\n", "missing"},
+		{"too many steps", "def score(receipt, points):\n    for i in range(1000000):\n        pass\n    return 0, \"\"\n", "too many steps"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := Compile("rule", []byte(test.src), Limits{MaxSteps: 10_000})
+			assert.NoError(t, err)
+			_, _, err = rule.Score(mondayReceipt("Target"), 0)
+			assert.ErrorContains(t, err, test.expected)
+		})
+	}
+}
+
+func TestScoreTimeout(t *testing.T) {
+	rule, err := Compile("slow", []byte(`
+def score(receipt, points):
+    for i in range(1000000000):
+        pass
+    return 0, ""
+`), Limits{MaxSteps: 1 << 62, Timeout: 10 * time.Millisecond})
+	assert.NoError(t, err)
+	_, _, err = rule.Score(mondayReceipt("Target"), 0)
+	assert.ErrorContains(t, err, "ran for more than 10ms")
+}
+
+// TestCompileSandbox tests that scripts cannot load modules, reach I/O or escape the limits at their top level.
+func TestCompileSandbox(t *testing.T) {
+	for name, src := range map[string]string{
+		"load":      "load(\"os.star\", \"read\")\ndef score(receipt, points):\n    return 0, \"\"\n",
+		"open":      "def score(receipt, points):\n    return len(open(\"/etc/passwd\")), \"\"\n",
+		"while":     "def score(receipt, points):\n    while True:\n        pass\n",
+		"top level": "x = [i for i in range(1000000)]\ndef score(receipt, points):\n    return 0, \"\"\n",
+		"no score":  "def rule(receipt, points):\n    return 0, \"\"\n",
+	} {
+		_, err := Compile(name, []byte(src), Limits{MaxSteps: 10_000})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "coffee_mondays.star"), []byte(coffeeMondays), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "bonus.star"), []byte("def score(receipt, points):\n    return 5, \"Bonus\"\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("Not a rule"), 0o600))
+
+	rules, err := LoadDir(dir, Limits{})
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 2) {
+		assert.Equal(t, "bonus", rules[0].Name)
+		assert.Equal(t, "coffee_mondays", rules[1].Name)
+	}
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.star"), []byte("def score("), 0o600))
+	_, err = LoadDir(dir, Limits{})
+	assert.ErrorContains(t, err, "broken.star")
+}
+
+func TestWeekday(t *testing.T) {
+	rule, err := Compile("weekday", []byte("def score(receipt, points):\n    return 0, weekday(\"2022-01-01\")\n"), Limits{})
+	assert.NoError(t, err)
+	_, explanation, err := rule.Score(mondayReceipt("Target"), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Saturday", explanation)
+
+	rule, err = Compile("weekday", []byte("def score(receipt, points):\n    return 0, weekday(\"01/01/2022\")\n"), Limits{})
+	assert.NoError(t, err)
+	_, _, err = rule.Score(mondayReceipt("Target"), 0)
+	assert.ErrorContains(t, err, "is not a date")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fetch-app/problem"
+	"fetch-app/receipts"
+	"fetch-app/server"
+	"fetch-app/validation"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestServeMux tests that the API can be mounted on a net/http ServeMux instead of Echo, guarded the same way.
+func TestServeMux(t *testing.T) {
+	service := receipts.NewService(receipts.NewStorage())
+	swagger, err := server.GetSwagger()
+	assert.NoError(t, err)
+	validator, err := validation.New(swagger)
+	assert.NoError(t, err)
+	api, err := NewAPI(service, APIOptions{AdminToken: testAdminToken, Validator: validator})
+	assert.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle("/", api)
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body, _ := json.Marshal(duplicateTestReceipt())
+	rec := serve(asPrincipal(jsonRequest(http.MethodPost, "/receipts/process", string(body)), "user-1"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var processed server.ProcessedReceipt
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &processed))
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/points", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"points": 20}`, rec.Body.String())
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"approved"`)
+
+	// Callers only list their own receipts
+	rec = serve(asPrincipal(httptest.NewRequest(http.MethodGet, "/receipts?limit=10", nil), "user-1"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), processed.Id)
+	rec = serve(asPrincipal(httptest.NewRequest(http.MethodGet, "/receipts?limit=10", nil), "user-2"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"receipts": []}`, rec.Body.String())
+
+	// The admin routes need the token
+	rec = serve(httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	req := httptest.NewRequest(http.MethodGet, "/admin/review-queue", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec = serve(req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	// Failures are rendered as problems, like on Echo
+	req = httptest.NewRequest(http.MethodPost, "/admin/receipts/"+processed.Id+"/approve", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec = serve(req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	var response problem.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, problem.CodeNotPendingReview, response.Code)
+	assert.Equal(t, "/admin/receipts/"+processed.Id+"/approve", response.Instance)
+
+	// Requests are checked against the spec before they reach the routes
+	rec = serve(jsonRequest(http.MethodPost, "/receipts/process", `{"retailer": `))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, problem.CodeValidationFailed, response.Code)
+
+	rec = serve(asPrincipal(httptest.NewRequest(http.MethodGet, "/receipts?limit=ten", nil), "user-1"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, problem.CodeValidationFailed, response.Code)
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/admin/receipts/export?from=2022-01-01", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	req = httptest.NewRequest(http.MethodGet, "/admin/receipts/export?from=2022-01-01", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec = serve(req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), processed.Id)
+
+	rec = serve(uploadRequest("/receipts/import", "receipt,retailer,purchaseDate,purchaseTime,total,shortDescription,price\n", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"imported": [], "errors": []}`, rec.Body.String())
+
+	rec = serve(textRequest("/receipts/process/text", "Corner Shop\n2022-01-03 10:15\nTea 2.50\nTOTAL 2.50\n"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var text server.TextReceipt
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &text))
+	assert.Equal(t, "Corner Shop", text.Receipt.Retailer)
+	assert.NotEmpty(t, text.Id)
+
+	rec = serve(emailRequest("From: Corner Shop <shop@example.com>\r\nDate: Mon, 03 Jan 2022 10:15:00 +0000\r\n\r\nTea 2.50\r\nTOTAL 2.50\r\n"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var emailed server.EmailReceipt
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &emailed))
+	assert.Equal(t, "Corner Shop", emailed.Receipt.Retailer)
+
+	// Only admins may attach and read images, like they alone may correct receipts
+	asAdmin := func(req *http.Request) *http.Request {
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		return req
+	}
+	rec = serve(asPrincipal(imageRequest("/receipts/"+processed.Id+"/image", pngImage(t)), "user-1"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve(asPrincipal(httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/image", nil), "user-1"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve(asAdmin(imageRequest("/receipts/"+processed.Id+"/image", pngImage(t))))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = serve(asAdmin(httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/image", nil)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, pngImage(t), rec.Body.Bytes())
+
+	// Only admins may correct receipts, even their submitter, and corrections are guarded by the ETag of the version
+	// they correct
+	rec = serve(asPrincipal(correctionRequest(http.MethodPatch, "/receipts/"+processed.Id, `"1"`, `{"purchaseTime": "2:30 PM"}`), "user-1"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, problem.CodeUnauthorized, response.Code)
+	req = asAdmin(correctionRequest(http.MethodPatch, "/receipts/"+processed.Id, "", `{"purchaseTime": "14:30"}`))
+	req.Header.Del("If-Match")
+	rec = serve(req)
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	rec = serve(asAdmin(correctionRequest(http.MethodPatch, "/receipts/"+processed.Id, `"1"`, `{"purchaseTime": "2:30 PM"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	assert.NotContains(t, rec.Body.String(), "user-1")
+	rec = serve(asAdmin(correctionRequest(http.MethodPut, "/receipts/"+processed.Id, `"1"`, string(body))))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	rec = serve(asAdmin(correctionRequest(http.MethodPut, "/receipts/"+processed.Id, `"2"`, string(body))))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = serve(httptest.NewRequest(http.MethodGet, "/receipts/"+processed.Id+"/versions", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var versions []server.ReceiptVersion
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &versions))
+	assert.Len(t, versions, 3)
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/receipts/not-an-id/points", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, problem.CodeInvalidReceiptID, response.Code)
+}
@@ -0,0 +1,5 @@
+package server
+
+// The models and net/http routing in openapi-server.gen.go are generated from the OpenAPI spec in api.yml.
+// Edit api.yml rather than the generated code, then run go generate ./... to regenerate it.
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.1 --config=oapi-codegen.yml api.yml
@@ -1,28 +1,309 @@
+//go:build go1.22
+
+// Package server provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.1 DO NOT EDIT.
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"github.com/labstack/echo"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/oapi-codegen/runtime"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
-// Autogenerated using oapi-codegen
+const (
+	AdminTokenScopes = "adminToken.Scopes"
+)
+
+// Defines values for ExportFormat.
+const (
+	Csv    ExportFormat = "csv"
+	Ndjson ExportFormat = "ndjson"
+)
+
+// Defines values for ExportRows.
+const (
+	ExportRowsItem    ExportRows = "item"
+	ExportRowsReceipt ExportRows = "receipt"
+)
+
+// Defines values for PaymentMethod.
+const (
+	Cash     PaymentMethod = "cash"
+	Check    PaymentMethod = "check"
+	Credit   PaymentMethod = "credit"
+	Debit    PaymentMethod = "debit"
+	GiftCard PaymentMethod = "gift_card"
+	Mobile   PaymentMethod = "mobile"
+	Other    PaymentMethod = "other"
+)
+
+// Defines values for ProblemCode.
+const (
+	ProblemCodeDuplicateReceipt     ProblemCode = "duplicate_receipt"
+	ProblemCodeIdempotencyKeyReused ProblemCode = "idempotency_key_reused"
+	ProblemCodeImageNotFound        ProblemCode = "image_not_found"
+	ProblemCodeImageTooLarge        ProblemCode = "image_too_large"
+	ProblemCodeInternalError        ProblemCode = "internal_error"
+	ProblemCodeInvalidReceiptId     ProblemCode = "invalid_receipt_id"
+	ProblemCodeInvalidRequest       ProblemCode = "invalid_request"
+	ProblemCodeInvalidTimezone      ProblemCode = "invalid_timezone"
+	ProblemCodeItemsPurged          ProblemCode = "items_purged"
+	ProblemCodeMethodNotAllowed     ProblemCode = "method_not_allowed"
+	ProblemCodeNotFound             ProblemCode = "not_found"
+	ProblemCodeNotPendingReview     ProblemCode = "not_pending_review"
+	ProblemCodePreconditionFailed   ProblemCode = "precondition_failed"
+	ProblemCodePreconditionRequired ProblemCode = "precondition_required"
+	ProblemCodeReasonRequired       ProblemCode = "reason_required"
+	ProblemCodeReceiptNotFound      ProblemCode = "receipt_not_found"
+	ProblemCodeReceiptRejected      ProblemCode = "receipt_rejected"
+	ProblemCodeReceiptUnreadable    ProblemCode = "receipt_unreadable"
+	ProblemCodeResponseInvalid      ProblemCode = "response_invalid"
+	ProblemCodeUnauthorized         ProblemCode = "unauthorized"
+	ProblemCodeUnsupportedImage     ProblemCode = "unsupported_image"
+	ProblemCodeValidationFailed     ProblemCode = "validation_failed"
+)
+
+// Defines values for PurgeReason.
+const (
+	Erasure     PurgeReason = "erasure"
+	Expired     PurgeReason = "expired"
+	ItemDetails PurgeReason = "item_details"
+)
+
+// Defines values for Status.
+const (
+	Approved      Status = "approved"
+	PendingReview Status = "pending_review"
+	Rejected      Status = "rejected"
+)
+
+// Decision An admin's approval or rejection of a receipt.
+type Decision struct {
+	DecidedAt time.Time `json:"decidedAt"`
+	Reason    string    `json:"reason,omitempty"`
+
+	// Status The position of the receipt in the review workflow.
+	Status Status `json:"status"`
+}
+
+// EmailReceipt defines model for EmailReceipt.
+type EmailReceipt struct {
+	// Confidence How confident the reading of each field is, from 0 for a guess to 1 for certain. A total summed from the items because the text had none scores low, as do items that do not add up to the printed total.
+	Confidence FieldConfidence `json:"confidence"`
+
+	// Id The ID assigned to the receipt.
+	Id      string  `json:"id"`
+	Receipt Receipt `json:"receipt"`
+
+	// Template The retailer whose template the receipt was read with. Absent when it was read like a text receipt.
+	Template *string `json:"template,omitempty"`
+}
+
+// ExportFormat CSV, or newline-delimited JSON with a receipt record per line.
+type ExportFormat string
+
+// ExportRows Whether a CSV export has a row per receipt or a row per item.
+type ExportRows string
+
+// FieldConfidence How confident the reading of each field is, from 0 for a guess to 1 for certain. A total summed from the items because the text had none scores low, as do items that do not add up to the printed total.
+type FieldConfidence struct {
+	Items        float32 `json:"items"`
+	PurchaseDate float32 `json:"purchaseDate"`
+	PurchaseTime float32 `json:"purchaseTime"`
+	Retailer     float32 `json:"retailer"`
+	Total        float32 `json:"total"`
+}
+
+// FieldError defines model for FieldError.
+type FieldError struct {
+	// Field Locates the invalid value: a JSON pointer such as "/items/0/price" for the body, or the parameter name.
+	Field string `json:"field"`
+
+	// Message What is wrong with the value.
+	Message string `json:"message"`
+}
+
+// ImportReport defines model for ImportReport.
+type ImportReport struct {
+	// Errors The errors of the rows left out, in row order.
+	Errors   []RowError        `json:"errors"`
+	Imported []ImportedReceipt `json:"imported"`
+}
+
+// ImportedReceipt defines model for ImportedReceipt.
+type ImportedReceipt struct {
+	// Id The ID the receipt was stored under.
+	Id string `json:"id"`
+
+	// Receipt The receipt key shared by the rows.
+	Receipt string `json:"receipt"`
+
+	// Rows The rows of the receipt's items, where the header is row 1.
+	Rows []int `json:"rows"`
+}
 
 // Item defines model for Item.
 type Item struct {
+	// Discount The discount taken off the item, which the price already accounts for.
+	Discount *string `json:"discount,omitempty"`
+
 	// Price The total price payed for this item.
 	Price string `json:"price"`
 
+	// Quantity The number of units the item was bought in, such as 3 for a line reading "3 @ 1.25". An item without one is a single unit.
+	Quantity *int `json:"quantity,omitempty"`
+
 	// ShortDescription The Short Product Description for the item.
 	ShortDescription string `json:"shortDescription"`
+
+	// UnitPrice The price of one unit of the item, before any discount.
+	UnitPrice *string `json:"unitPrice,omitempty"`
+}
+
+// Ledger defines model for Ledger.
+type Ledger struct {
+	// Adjustments The corrections to the stored receipts, and the net change in points they made.
+	Adjustments LedgerAdjustments `json:"adjustments"`
+
+	// Anonymized The part of the totals whose receipts were purged, and so belong to no one.
+	Anonymized LedgerTotals `json:"anonymized"`
+
+	// Points The points awarded to those receipts.
+	Points int64 `json:"points"`
+
+	// Receipts The number of receipts, stored or purged.
+	Receipts int64 `json:"receipts"`
+}
+
+// LedgerAdjustments The corrections to the stored receipts, and the net change in points they made.
+type LedgerAdjustments struct {
+	Corrections int64 `json:"corrections"`
+	Points      int64 `json:"points"`
+}
+
+// LedgerTotals The part of the totals whose receipts were purged, and so belong to no one.
+type LedgerTotals struct {
+	Points   int64 `json:"points"`
+	Receipts int64 `json:"receipts"`
+}
+
+// Normalization defines model for Normalization.
+type Normalization struct {
+	// Field The JSON pointer of the field.
+	Field string `json:"field"`
+
+	// Original The value as submitted.
+	Original string `json:"original"`
+
+	// Value The canonical value stored.
+	Value string `json:"value"`
+}
+
+// PaymentMethod How the receipt was paid.
+type PaymentMethod string
+
+// Points defines model for Points.
+type Points struct {
+	// Points The number of points awarded.
+	Points int64 `json:"points"`
+}
+
+// PointsBreakdown defines model for PointsBreakdown.
+type PointsBreakdown struct {
+	// Points The number of points awarded, which is the sum of the rules' points.
+	Points int64 `json:"points"`
+
+	// Rules The points awarded by each scoring rule, in the order they are applied.
+	Rules []RulePoints `json:"rules"`
+}
+
+// Problem An RFC 7807 problem details object describing why the request failed.
+type Problem struct {
+	// Code Identifies the kind of problem. Codes never change meaning. invalid_request: the request could not be read, such as a body that is not JSON. validation_failed: the request violates this spec. invalid_timezone: the receipt's time zone, or the one it inherits, is unknown. reason_required: a rejection has no reason. invalid_receipt_id: the receipt ID is neither a UUID nor a ULID. unauthorized: the admin token is missing or invalid. receipt_not_found: no receipt has the ID. not_found: the API has no such path. method_not_allowed: the path does not support the method. duplicate_receipt: the duplicate policy rejected the receipt. receipt_rejected: the receipt was rejected on review. not_pending_review: the receipt is not awaiting review. idempotency_key_reused: the Idempotency-Key was already used for a different receipt. receipt_unreadable: no receipt could be read from the submitted text or email. image_not_found: the receipt has no image. image_too_large: the image is larger than the server accepts. unsupported_image: the upload is not a JPEG, PNG, GIF or WebP image. items_purged: the receipt's item details were purged, so it can no longer be corrected. precondition_failed: If-Match does not match the receipt's current ETag. precondition_required: a correction has no If-Match. internal_error: the server failed unexpectedly. response_invalid: a response violated this spec, which is only checked in development.
+	Code ProblemCode `json:"code"`
+
+	// Detail Explains this occurrence of the problem.
+	Detail *string `json:"detail,omitempty"`
+
+	// DuplicateOf The IDs of the earlier receipts with the same fingerprint, when a duplicate was rejected.
+	DuplicateOf *[]string `json:"duplicateOf,omitempty"`
+
+	// Errors Each way the request violates the API contract, when it was rejected by validation.
+	Errors *[]FieldError `json:"errors,omitempty"`
+
+	// Instance The path of the request that caused the problem.
+	Instance *string `json:"instance,omitempty"`
+
+	// Status The HTTP status code of the response.
+	Status int `json:"status"`
+
+	// Title Summarizes the kind of problem. It is the same for every occurrence.
+	Title string `json:"title"`
+
+	// Type Identifies the kind of problem, as "/problems/" followed by the code.
+	Type string `json:"type"`
+}
+
+// ProblemCode Identifies the kind of problem. Codes never change meaning. invalid_request: the request could not be read, such as a body that is not JSON. validation_failed: the request violates this spec. invalid_timezone: the receipt's time zone, or the one it inherits, is unknown. reason_required: a rejection has no reason. invalid_receipt_id: the receipt ID is neither a UUID nor a ULID. unauthorized: the admin token is missing or invalid. receipt_not_found: no receipt has the ID. not_found: the API has no such path. method_not_allowed: the path does not support the method. duplicate_receipt: the duplicate policy rejected the receipt. receipt_rejected: the receipt was rejected on review. not_pending_review: the receipt is not awaiting review. idempotency_key_reused: the Idempotency-Key was already used for a different receipt. receipt_unreadable: no receipt could be read from the submitted text or email. image_not_found: the receipt has no image. image_too_large: the image is larger than the server accepts. unsupported_image: the upload is not a JPEG, PNG, GIF or WebP image. items_purged: the receipt's item details were purged, so it can no longer be corrected. precondition_failed: If-Match does not match the receipt's current ETag. precondition_required: a correction has no If-Match. internal_error: the server failed unexpectedly. response_invalid: a response violated this spec, which is only checked in development.
+type ProblemCode string
+
+// ProcessedReceipt defines model for ProcessedReceipt.
+type ProcessedReceipt struct {
+	// Id The ID assigned to the receipt.
+	Id string `json:"id"`
+
+	// Normalized The fields submitted in another format than their canonical one, which were stored rewritten.
+	Normalized *[]Normalization `json:"normalized,omitempty"`
+}
+
+// Purge The audit record of one purge. It says what was purged and why without keeping the purged data.
+type Purge struct {
+	// At When the purge happened.
+	At time.Time `json:"at"`
+
+	// Points The points the purged receipts had been awarded, which the ledger keeps.
+	Points int64 `json:"points"`
+
+	// Principal The SHA-256 hash of the principal whose receipts were erased.
+	Principal string `json:"principal,omitempty"`
+
+	// Reason Why the data was purged. item_details: the item details outlived the retention policy. expired: the records outlived the retention policy. erasure: the principal asked for their receipts to be erased.
+	Reason PurgeReason `json:"reason"`
+
+	// ReceiptIds The IDs of the purged receipts.
+	ReceiptIds []string `json:"receiptIds"`
 }
 
+// PurgeReason Why the data was purged. item_details: the item details outlived the retention policy. expired: the records outlived the retention policy. erasure: the principal asked for their receipts to be erased.
+type PurgeReason string
+
 // Receipt defines model for Receipt.
 type Receipt struct {
-	Items []Item `json:"items"`
+	// Currency The ISO 4217 code of the currency the amounts are in. Every amount has as many decimals as the currency has minor units, such as "6.49" in USD, "1200" in JPY or "1.250" in BHD. Receipts without one are in USD.
+	Currency *string `json:"currency,omitempty"`
+
+	// ExchangeRate How many units of the receipt's currency one unit of the deployment's base currency bought, used to score item prices in the base currency. Filled in by the server from the deployment's rates, so a submission or correction carrying one is refused.
+	ExchangeRate *string `json:"exchangeRate,omitempty"`
+	Items        []Item  `json:"items"`
+
+	// PaymentMethod How the receipt was paid.
+	PaymentMethod *PaymentMethod `json:"paymentMethod,omitempty"`
 
 	// PurchaseDate The date of the purchase printed on the receipt.
 	PurchaseDate openapi_types.Date `json:"purchaseDate"`
@@ -30,85 +311,3387 @@ type Receipt struct {
 	// PurchaseTime The time of the purchase printed on the receipt. 24-hour time expected.
 	PurchaseTime string `json:"purchaseTime"`
 
+	// ReceiptNumber The number the store printed on the receipt, such as its transaction number.
+	ReceiptNumber *string `json:"receiptNumber,omitempty"`
+
 	// Retailer The name of the retailer or store the receipt is from.
 	Retailer string `json:"retailer"`
 
-	// Total The total amount paid on the receipt.
+	// StoreNumber The number the retailer gives the store the purchase was made in.
+	StoreNumber *string `json:"storeNumber,omitempty"`
+
+	// Subtotal The amount of the items before tax and tip.
+	Subtotal *string `json:"subtotal,omitempty"`
+
+	// Tax The tax charged on the receipt.
+	Tax *string `json:"tax,omitempty"`
+
+	// Timezone Where the purchase happened, as an IANA time zone name such as "America/New_York" or a UTC offset such as "-05:00". Inherited from the retailer or the deployment default when omitted.
+	Timezone *string `json:"timezone,omitempty"`
+
+	// Tip The tip or gratuity added to the receipt.
+	Tip *string `json:"tip,omitempty"`
+
+	// Total The total amount paid on the receipt, in its currency.
 	Total string `json:"total"`
 }
 
+// ReceiptImage An image attached to a receipt, such as a photo of the printed receipt.
+type ReceiptImage struct {
+	// ContentType The content type detected from the image.
+	ContentType string `json:"contentType"`
+
+	// Sha256 The SHA-256 hash of the image, hex encoded.
+	Sha256 string `json:"sha256"`
+
+	// Size The size of the image in bytes.
+	Size int64 `json:"size"`
+
+	// UploadedAt When the image was attached.
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// ReceiptPage defines model for ReceiptPage.
+type ReceiptPage struct {
+	// Next The cursor to pass as `after` for the next page. Absent on the last page.
+	Next *string `json:"next,omitempty"`
+
+	// Receipts The receipt records, in ID order.
+	Receipts []ReceiptRecord `json:"receipts"`
+}
+
+// ReceiptPatch A JSON merge patch of a Receipt. The patched receipt must match the Receipt schema.
+type ReceiptPatch = map[string]interface{}
+
+// ReceiptRecord defines model for ReceiptRecord.
+type ReceiptRecord struct {
+	// Decision An admin's approval or rejection of a receipt.
+	Decision *Decision `json:"decision,omitempty"`
+
+	// DuplicateOf The IDs of earlier receipts with the same fingerprint.
+	DuplicateOf []string `json:"duplicateOf,omitempty"`
+
+	// Fingerprint Identifies the physical receipt regardless of formatting, to detect duplicate submissions.
+	Fingerprint string `json:"fingerprint"`
+
+	// Id The ID assigned to the receipt.
+	Id string `json:"id"`
+
+	// Image An image attached to a receipt, such as a photo of the printed receipt.
+	Image *ReceiptImage `json:"image,omitempty"`
+
+	// ItemsPurgedAt When the retention policy purged the item details. The item descriptions then read "REDACTED", and the points are those awarded before the purge.
+	ItemsPurgedAt *time.Time `json:"itemsPurgedAt,omitempty"`
+
+	// PurchasedAt The purchase instant, combining the purchase date, time and time zone.
+	PurchasedAt *time.Time `json:"purchasedAt,omitempty"`
+	Receipt     Receipt    `json:"receipt"`
+
+	// ReviewReasons Why the receipt was held for review.
+	ReviewReasons []string `json:"reviewReasons,omitempty"`
+
+	// Status The position of the receipt in the review workflow.
+	Status Status `json:"status"`
+
+	// SubmittedAt When the receipt was submitted.
+	SubmittedAt time.Time `json:"submittedAt"`
+
+	// Version The version of the receipt, 1 as submitted and one more for each correction.
+	Version int `json:"version"`
+
+	// ZeroPoints Whether the receipt earns no points because it duplicates an earlier one.
+	ZeroPoints bool `json:"zeroPoints,omitempty"`
+}
+
+// ReceiptVersion defines model for ReceiptVersion.
+type ReceiptVersion struct {
+	// Adjustment The change in the points awarded that the correction creating the version made.
+	Adjustment *int64 `json:"adjustment,omitempty"`
+
+	// CreatedAt When the version was submitted or the correction made.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Points The points awarded for the version: the current ones for the current version, and those awarded when it was replaced for the earlier ones.
+	Points  int64   `json:"points"`
+	Receipt Receipt `json:"receipt"`
+	Version int     `json:"version"`
+}
+
+// ReviewDecision defines model for ReviewDecision.
+type ReviewDecision struct {
+	// Reason Why the admin approved or rejected the receipt. Required when rejecting.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReviewStatus defines model for ReviewStatus.
+type ReviewStatus struct {
+	// Status The position of the receipt in the review workflow.
+	Status Status `json:"status"`
+}
+
+// RowError defines model for RowError.
+type RowError struct {
+	// Field The field at fault, if the error concerns one.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+
+	// Receipt The receipt key of the row.
+	Receipt string `json:"receipt"`
+
+	// Row The row number, where the header is row 1.
+	Row int `json:"row"`
+}
+
+// RulePoints defines model for RulePoints.
+type RulePoints struct {
+	// Description Explains what the rule awards points for.
+	Description string `json:"description"`
+
+	// Points The points the rule awarded. A duplicate receipt's points are cancelled by a negative entry.
+	Points int `json:"points"`
+
+	// Rule Identifies the rule. Custom rules are named "custom:" and the name of their script.
+	Rule string `json:"rule"`
+}
+
+// Status The position of the receipt in the review workflow.
+type Status string
+
+// TextReceipt defines model for TextReceipt.
+type TextReceipt struct {
+	// Confidence How confident the reading of each field is, from 0 for a guess to 1 for certain. A total summed from the items because the text had none scores low, as do items that do not add up to the printed total.
+	Confidence FieldConfidence `json:"confidence"`
+
+	// Id The ID assigned to the receipt.
+	Id      string  `json:"id"`
+	Receipt Receipt `json:"receipt"`
+}
+
+// IdempotencyKey defines model for IdempotencyKey.
+type IdempotencyKey = string
+
+// IfMatch defines model for IfMatch.
+type IfMatch = string
+
+// PrincipalId defines model for PrincipalId.
+type PrincipalId = string
+
+// ReceiptId defines model for ReceiptId.
+type ReceiptId = string
+
+// Corrected defines model for Corrected.
+type Corrected = ReceiptRecord
+
+// Decided defines model for Decided.
+type Decided = ReceiptRecord
+
+// InvalidCorrection An RFC 7807 problem details object describing why the request failed.
+type InvalidCorrection = Problem
+
+// InvalidDecision An RFC 7807 problem details object describing why the request failed.
+type InvalidDecision = Problem
+
+// InvalidId An RFC 7807 problem details object describing why the request failed.
+type InvalidId = Problem
+
+// NotCorrectable An RFC 7807 problem details object describing why the request failed.
+type NotCorrectable = Problem
+
+// NotFound An RFC 7807 problem details object describing why the request failed.
+type NotFound = Problem
+
+// NotPending An RFC 7807 problem details object describing why the request failed.
+type NotPending = Problem
+
+// PreconditionFailed An RFC 7807 problem details object describing why the request failed.
+type PreconditionFailed = Problem
+
+// PreconditionRequired An RFC 7807 problem details object describing why the request failed.
+type PreconditionRequired = Problem
+
+// Unauthorized An RFC 7807 problem details object describing why the request failed.
+type Unauthorized = Problem
+
+// GetAdminReceiptsExportParams defines parameters for GetAdminReceiptsExport.
+type GetAdminReceiptsExportParams struct {
+	// Format The format of the export.
+	Format *ExportFormat `form:"format,omitempty" json:"format,omitempty"`
+
+	// Rows Whether a CSV export has a row per receipt or a row per item, repeating the receipt columns. NDJSON exports always have a line per receipt, with its items.
+	Rows *ExportRows `form:"rows,omitempty" json:"rows,omitempty"`
+
+	// From Exports only the receipts purchased on or after this date.
+	From *openapi_types.Date `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Exports only the receipts purchased on or before this date.
+	To *openapi_types.Date `form:"to,omitempty" json:"to,omitempty"`
+
+	// Principal Exports only the receipts submitted by this principal.
+	Principal *string `form:"principal,omitempty" json:"principal,omitempty"`
+}
+
+// GetReceiptsParams defines parameters for GetReceipts.
+type GetReceiptsParams struct {
+	// After Lists the receipts after this ID. Pass the `next` cursor of the previous page.
+	After *string `form:"after,omitempty" json:"after,omitempty"`
+
+	// Limit The maximum number of receipts to list.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// XPrincipalId Identifies the caller, whose receipts are listed
+	XPrincipalId string `json:"X-Principal-Id"`
+}
+
+// PostReceiptsImportMultipartBody defines parameters for PostReceiptsImport.
+type PostReceiptsImportMultipartBody struct {
+	// File The CSV file.
+	File openapi_types.File `json:"file"`
+
+	// Mapping Maps fields to the headers of their columns, as field=header pairs separated by commas, such as `retailer=Store,total=Amount`. The fields are receipt, retailer, purchaseDate, purchaseTime, total, timezone, currency, shortDescription and price, and each defaults to the column named after it.
+	Mapping *string `json:"mapping,omitempty"`
+}
+
+// PostReceiptsImportParams defines parameters for PostReceiptsImport.
+type PostReceiptsImportParams struct {
+	// XPrincipalId Identifies who submitted the receipt, so their receipts can be erased on request
+	XPrincipalId *PrincipalId `json:"X-Principal-Id,omitempty"`
+}
+
+// PostReceiptsProcessParams defines parameters for PostReceiptsProcess.
+type PostReceiptsProcessParams struct {
+	// IdempotencyKey A client-chosen key that makes retrying the submission safe
+	IdempotencyKey *IdempotencyKey `json:"Idempotency-Key,omitempty"`
+
+	// XPrincipalId Identifies who submitted the receipt, so their receipts can be erased on request
+	XPrincipalId *PrincipalId `json:"X-Principal-Id,omitempty"`
+}
+
+// PostReceiptsProcessEmailParams defines parameters for PostReceiptsProcessEmail.
+type PostReceiptsProcessEmailParams struct {
+	// XPrincipalId Identifies who submitted the receipt, so their receipts can be erased on request
+	XPrincipalId *PrincipalId `json:"X-Principal-Id,omitempty"`
+}
+
+// PostReceiptsProcessTextTextBody defines parameters for PostReceiptsProcessText.
+type PostReceiptsProcessTextTextBody = string
+
+// PostReceiptsProcessTextParams defines parameters for PostReceiptsProcessText.
+type PostReceiptsProcessTextParams struct {
+	// XPrincipalId Identifies who submitted the receipt, so their receipts can be erased on request
+	XPrincipalId *PrincipalId `json:"X-Principal-Id,omitempty"`
+}
+
+// PatchReceiptsIdParams defines parameters for PatchReceiptsId.
+type PatchReceiptsIdParams struct {
+	// IfMatch The ETag of the version being corrected. A correction without it is refused with 428 Precondition Required.
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// PutReceiptsIdParams defines parameters for PutReceiptsId.
+type PutReceiptsIdParams struct {
+	// IfMatch The ETag of the version being corrected. A correction without it is refused with 428 Precondition Required.
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// PostAdminReceiptsIdApproveJSONRequestBody defines body for PostAdminReceiptsIdApprove for application/json ContentType.
+type PostAdminReceiptsIdApproveJSONRequestBody = ReviewDecision
+
+// PostAdminReceiptsIdRejectJSONRequestBody defines body for PostAdminReceiptsIdReject for application/json ContentType.
+type PostAdminReceiptsIdRejectJSONRequestBody = ReviewDecision
+
+// PostReceiptsImportMultipartRequestBody defines body for PostReceiptsImport for multipart/form-data ContentType.
+type PostReceiptsImportMultipartRequestBody PostReceiptsImportMultipartBody
+
 // PostReceiptsProcessJSONRequestBody defines body for PostReceiptsProcess for application/json ContentType.
 type PostReceiptsProcessJSONRequestBody = Receipt
 
+// PostReceiptsProcessTextTextRequestBody defines body for PostReceiptsProcessText for text/plain ContentType.
+type PostReceiptsProcessTextTextRequestBody = PostReceiptsProcessTextTextBody
+
+// PatchReceiptsIdApplicationMergePatchPlusJSONRequestBody defines body for PatchReceiptsId for application/merge-patch+json ContentType.
+type PatchReceiptsIdApplicationMergePatchPlusJSONRequestBody = ReceiptPatch
+
+// PutReceiptsIdJSONRequestBody defines body for PutReceiptsId for application/json ContentType.
+type PutReceiptsIdJSONRequestBody = Receipt
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Returns the points ledger totals
+	// (GET /admin/ledger)
+	GetAdminLedger(w http.ResponseWriter, r *http.Request)
+	// Erases the receipts of a principal
+	// (DELETE /admin/principals/{principal}/receipts)
+	DeleteAdminPrincipalsPrincipalReceipts(w http.ResponseWriter, r *http.Request, principal string)
+	// Lists the purge audit log
+	// (GET /admin/purges)
+	GetAdminPurges(w http.ResponseWriter, r *http.Request)
+	// Exports the stored receipts with their points
+	// (GET /admin/receipts/export)
+	GetAdminReceiptsExport(w http.ResponseWriter, r *http.Request, params GetAdminReceiptsExportParams)
+	// Approves a receipt awaiting review
+	// (POST /admin/receipts/{id}/approve)
+	PostAdminReceiptsIdApprove(w http.ResponseWriter, r *http.Request, id ReceiptId)
+	// Rejects a receipt awaiting review
+	// (POST /admin/receipts/{id}/reject)
+	PostAdminReceiptsIdReject(w http.ResponseWriter, r *http.Request, id ReceiptId)
+	// Lists the receipts awaiting review
+	// (GET /admin/review-queue)
+	GetAdminReviewQueue(w http.ResponseWriter, r *http.Request)
+	// Lists the caller's stored receipt records
+	// (GET /receipts)
+	GetReceipts(w http.ResponseWriter, r *http.Request, params GetReceiptsParams)
+	// Imports receipts from a CSV file
+	// (POST /receipts/import)
+	PostReceiptsImport(w http.ResponseWriter, r *http.Request, params PostReceiptsImportParams)
 	// Submits a receipt for processing
 	// (POST /receipts/process)
-	PostReceiptsProcess(ctx echo.Context) error
+	PostReceiptsProcess(w http.ResponseWriter, r *http.Request, params PostReceiptsProcessParams)
+	// Submits a receipt sent by email
+	// (POST /receipts/process/email)
+	PostReceiptsProcessEmail(w http.ResponseWriter, r *http.Request, params PostReceiptsProcessEmailParams)
+	// Submits a receipt as printed text
+	// (POST /receipts/process/text)
+	PostReceiptsProcessText(w http.ResponseWriter, r *http.Request, params PostReceiptsProcessTextParams)
+	// Returns the stored receipt record
+	// (GET /receipts/{id})
+	GetReceiptsId(w http.ResponseWriter, r *http.Request, id ReceiptId)
+	// Corrects fields of a receipt
+	// (PATCH /receipts/{id})
+	PatchReceiptsId(w http.ResponseWriter, r *http.Request, id ReceiptId, params PatchReceiptsIdParams)
+	// Corrects a receipt
+	// (PUT /receipts/{id})
+	PutReceiptsId(w http.ResponseWriter, r *http.Request, id ReceiptId, params PutReceiptsIdParams)
+	// Explains the points awarded for the receipt
+	// (GET /receipts/{id}/breakdown)
+	GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request, id ReceiptId)
+	// Returns the photo of the receipt
+	// (GET /receipts/{id}/image)
+	GetReceiptsIdImage(w http.ResponseWriter, r *http.Request, id ReceiptId)
+	// Attaches a photo to the receipt
+	// (PUT /receipts/{id}/image)
+	PutReceiptsIdImage(w http.ResponseWriter, r *http.Request, id ReceiptId)
 	// Returns the points awarded for the receipt
 	// (GET /receipts/{id}/points)
-	GetReceiptsIdPoints(ctx echo.Context, id string) error
+	GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request, id ReceiptId)
+	// Returns the versions of a receipt
+	// (GET /receipts/{id}/versions)
+	GetReceiptsIdVersions(w http.ResponseWriter, r *http.Request, id ReceiptId)
 }
 
-// ServerInterfaceWrapper converts echo contexts to parameters.
+// ServerInterfaceWrapper converts contexts to parameters.
 type ServerInterfaceWrapper struct {
-	Handler ServerInterface
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
 }
 
-// PostReceiptsProcess converts echo context to params.
-func (w *ServerInterfaceWrapper) PostReceiptsProcess(ctx echo.Context) error {
-	var err error
+type MiddlewareFunc func(http.Handler) http.Handler
 
-	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PostReceiptsProcess(ctx)
-	return err
+// GetAdminLedger operation middleware
+func (siw *ServerInterfaceWrapper) GetAdminLedger(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAdminLedger(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-// GetReceiptsIdPoints converts echo context to params.
-func (w *ServerInterfaceWrapper) GetReceiptsIdPoints(ctx echo.Context) error {
+// DeleteAdminPrincipalsPrincipalReceipts operation middleware
+func (siw *ServerInterfaceWrapper) DeleteAdminPrincipalsPrincipalReceipts(w http.ResponseWriter, r *http.Request) {
+
 	var err error
-	// ------------- Path parameter "id" -------------
-	var id string
 
-	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	// ------------- Path parameter "principal" -------------
+	var principal string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "principal", r.PathValue("principal"), &principal, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principal", Err: err})
+		return
 	}
 
-	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetReceiptsIdPoints(ctx, id)
-	return err
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteAdminPrincipalsPrincipalReceipts(w, r, principal)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetAdminPurges operation middleware
+func (siw *ServerInterfaceWrapper) GetAdminPurges(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAdminPurges(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-// This is a simple interface which specifies echo.Route addition functions which
-// are present on both echo.Echo and echo.Group, since we want to allow using
-// either of them for path registration
-type EchoRouter interface {
-	CONNECT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	DELETE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	HEAD(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	OPTIONS(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	PATCH(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
-	TRACE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route
+// GetAdminReceiptsExport operation middleware
+func (siw *ServerInterfaceWrapper) GetAdminReceiptsExport(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAdminReceiptsExportParams
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", r.URL.Query(), &params.Format)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "format", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "rows" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "rows", r.URL.Query(), &params.Rows)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "rows", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "principal" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "principal", r.URL.Query(), &params.Principal)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "principal", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAdminReceiptsExport(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-// RegisterHandlers adds each server route to the EchoRouter.
-func RegisterHandlers(router EchoRouter, si ServerInterface) {
-	RegisterHandlersWithBaseURL(router, si, "")
+// PostAdminReceiptsIdApprove operation middleware
+func (siw *ServerInterfaceWrapper) PostAdminReceiptsIdApprove(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostAdminReceiptsIdApprove(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-// Registers handlers, and prepends BaseURL to the paths, so that the paths
-// can be served under a prefix.
-func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL string) {
+// PostAdminReceiptsIdReject operation middleware
+func (siw *ServerInterfaceWrapper) PostAdminReceiptsIdReject(w http.ResponseWriter, r *http.Request) {
 
-	wrapper := ServerInterfaceWrapper{
-		Handler: si,
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
 	}
 
-	router.POST(baseURL+"/receipts/process", wrapper.PostReceiptsProcess)
-	router.GET(baseURL+"/receipts/:id/points", wrapper.GetReceiptsIdPoints)
+	ctx := r.Context()
 
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostAdminReceiptsIdReject(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetAdminReviewQueue operation middleware
+func (siw *ServerInterfaceWrapper) GetAdminReviewQueue(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAdminReviewQueue(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetReceipts operation middleware
+func (siw *ServerInterfaceWrapper) GetReceipts(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetReceiptsParams
+
+	// ------------- Optional query parameter "after" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "after", r.URL.Query(), &params.After)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "after", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Required header parameter "X-Principal-Id" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Principal-Id")]; found {
+		var XPrincipalId string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Principal-Id", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Principal-Id", valueList[0], &XPrincipalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: true})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Principal-Id", Err: err})
+			return
+		}
+
+		params.XPrincipalId = XPrincipalId
+
+	} else {
+		err := fmt.Errorf("Header parameter X-Principal-Id is required, but not found")
+		siw.ErrorHandlerFunc(w, r, &RequiredHeaderError{ParamName: "X-Principal-Id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceipts(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostReceiptsImport operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsImport(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostReceiptsImportParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "X-Principal-Id" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Principal-Id")]; found {
+		var XPrincipalId PrincipalId
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Principal-Id", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Principal-Id", valueList[0], &XPrincipalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Principal-Id", Err: err})
+			return
+		}
+
+		params.XPrincipalId = &XPrincipalId
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsImport(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostReceiptsProcess operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsProcess(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostReceiptsProcessParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey IdempotencyKey
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	// ------------- Optional header parameter "X-Principal-Id" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Principal-Id")]; found {
+		var XPrincipalId PrincipalId
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Principal-Id", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Principal-Id", valueList[0], &XPrincipalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Principal-Id", Err: err})
+			return
+		}
+
+		params.XPrincipalId = &XPrincipalId
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsProcess(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostReceiptsProcessEmail operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsProcessEmail(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostReceiptsProcessEmailParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "X-Principal-Id" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Principal-Id")]; found {
+		var XPrincipalId PrincipalId
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Principal-Id", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Principal-Id", valueList[0], &XPrincipalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Principal-Id", Err: err})
+			return
+		}
+
+		params.XPrincipalId = &XPrincipalId
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsProcessEmail(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PostReceiptsProcessText operation middleware
+func (siw *ServerInterfaceWrapper) PostReceiptsProcessText(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostReceiptsProcessTextParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "X-Principal-Id" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Principal-Id")]; found {
+		var XPrincipalId PrincipalId
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Principal-Id", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Principal-Id", valueList[0], &XPrincipalId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Principal-Id", Err: err})
+			return
+		}
+
+		params.XPrincipalId = &XPrincipalId
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostReceiptsProcessText(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetReceiptsId operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsId(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PatchReceiptsId operation middleware
+func (siw *ServerInterfaceWrapper) PatchReceiptsId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchReceiptsIdParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PatchReceiptsId(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PutReceiptsId operation middleware
+func (siw *ServerInterfaceWrapper) PutReceiptsId(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PutReceiptsIdParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutReceiptsId(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetReceiptsIdBreakdown operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdBreakdown(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetReceiptsIdImage operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdImage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdImage(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PutReceiptsIdImage operation middleware
+func (siw *ServerInterfaceWrapper) PutReceiptsIdImage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, AdminTokenScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutReceiptsIdImage(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetReceiptsIdPoints operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdPoints(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetReceiptsIdVersions operation middleware
+func (siw *ServerInterfaceWrapper) GetReceiptsIdVersions(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ReceiptId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetReceiptsIdVersions(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{})
+}
+
+// ServeMux is an abstraction of http.ServeMux.
+type ServeMux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+type StdHTTPServerOptions struct {
+	BaseURL          string
+	BaseRouter       ServeMux
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, m ServeMux) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{
+		BaseRouter: m,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, m ServeMux, baseURL string) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: m,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.Handler {
+	m := options.BaseRouter
+
+	if m == nil {
+		m = http.NewServeMux()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	m.HandleFunc("GET "+options.BaseURL+"/admin/ledger", wrapper.GetAdminLedger)
+	m.HandleFunc("DELETE "+options.BaseURL+"/admin/principals/{principal}/receipts", wrapper.DeleteAdminPrincipalsPrincipalReceipts)
+	m.HandleFunc("GET "+options.BaseURL+"/admin/purges", wrapper.GetAdminPurges)
+	m.HandleFunc("GET "+options.BaseURL+"/admin/receipts/export", wrapper.GetAdminReceiptsExport)
+	m.HandleFunc("POST "+options.BaseURL+"/admin/receipts/{id}/approve", wrapper.PostAdminReceiptsIdApprove)
+	m.HandleFunc("POST "+options.BaseURL+"/admin/receipts/{id}/reject", wrapper.PostAdminReceiptsIdReject)
+	m.HandleFunc("GET "+options.BaseURL+"/admin/review-queue", wrapper.GetAdminReviewQueue)
+	m.HandleFunc("GET "+options.BaseURL+"/receipts", wrapper.GetReceipts)
+	m.HandleFunc("POST "+options.BaseURL+"/receipts/import", wrapper.PostReceiptsImport)
+	m.HandleFunc("POST "+options.BaseURL+"/receipts/process", wrapper.PostReceiptsProcess)
+	m.HandleFunc("POST "+options.BaseURL+"/receipts/process/email", wrapper.PostReceiptsProcessEmail)
+	m.HandleFunc("POST "+options.BaseURL+"/receipts/process/text", wrapper.PostReceiptsProcessText)
+	m.HandleFunc("GET "+options.BaseURL+"/receipts/{id}", wrapper.GetReceiptsId)
+	m.HandleFunc("PATCH "+options.BaseURL+"/receipts/{id}", wrapper.PatchReceiptsId)
+	m.HandleFunc("PUT "+options.BaseURL+"/receipts/{id}", wrapper.PutReceiptsId)
+	m.HandleFunc("GET "+options.BaseURL+"/receipts/{id}/breakdown", wrapper.GetReceiptsIdBreakdown)
+	m.HandleFunc("GET "+options.BaseURL+"/receipts/{id}/image", wrapper.GetReceiptsIdImage)
+	m.HandleFunc("PUT "+options.BaseURL+"/receipts/{id}/image", wrapper.PutReceiptsIdImage)
+	m.HandleFunc("GET "+options.BaseURL+"/receipts/{id}/points", wrapper.GetReceiptsIdPoints)
+	m.HandleFunc("GET "+options.BaseURL+"/receipts/{id}/versions", wrapper.GetReceiptsIdVersions)
+
+	return m
+}
+
+type CorrectedResponseHeaders struct {
+	ETag string
+}
+type CorrectedJSONResponse struct {
+	Body ReceiptRecord
+
+	Headers CorrectedResponseHeaders
+}
+
+type DecidedJSONResponse ReceiptRecord
+
+type InvalidCorrectionApplicationProblemPlusJSONResponse Problem
+
+type InvalidDecisionApplicationProblemPlusJSONResponse Problem
+
+type InvalidIdApplicationProblemPlusJSONResponse Problem
+
+type NotCorrectableApplicationProblemPlusJSONResponse Problem
+
+type NotFoundApplicationProblemPlusJSONResponse Problem
+
+type NotPendingApplicationProblemPlusJSONResponse Problem
+
+type PreconditionFailedApplicationProblemPlusJSONResponse Problem
+
+type PreconditionRequiredApplicationProblemPlusJSONResponse Problem
+
+type ProblemApplicationProblemPlusJSONResponse Problem
+
+type UnauthorizedApplicationProblemPlusJSONResponse Problem
+
+type GetAdminLedgerRequestObject struct {
+}
+
+type GetAdminLedgerResponseObject interface {
+	VisitGetAdminLedgerResponse(w http.ResponseWriter) error
+}
+
+type GetAdminLedger200JSONResponse Ledger
+
+func (response GetAdminLedger200JSONResponse) VisitGetAdminLedgerResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminLedger401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response GetAdminLedger401ApplicationProblemPlusJSONResponse) VisitGetAdminLedgerResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminLedgerdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetAdminLedgerdefaultApplicationProblemPlusJSONResponse) VisitGetAdminLedgerResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DeleteAdminPrincipalsPrincipalReceiptsRequestObject struct {
+	Principal string `json:"principal"`
+}
+
+type DeleteAdminPrincipalsPrincipalReceiptsResponseObject interface {
+	VisitDeleteAdminPrincipalsPrincipalReceiptsResponse(w http.ResponseWriter) error
+}
+
+type DeleteAdminPrincipalsPrincipalReceipts200JSONResponse Purge
+
+func (response DeleteAdminPrincipalsPrincipalReceipts200JSONResponse) VisitDeleteAdminPrincipalsPrincipalReceiptsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAdminPrincipalsPrincipalReceipts401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteAdminPrincipalsPrincipalReceipts401ApplicationProblemPlusJSONResponse) VisitDeleteAdminPrincipalsPrincipalReceiptsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAdminPrincipalsPrincipalReceiptsdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response DeleteAdminPrincipalsPrincipalReceiptsdefaultApplicationProblemPlusJSONResponse) VisitDeleteAdminPrincipalsPrincipalReceiptsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetAdminPurgesRequestObject struct {
+}
+
+type GetAdminPurgesResponseObject interface {
+	VisitGetAdminPurgesResponse(w http.ResponseWriter) error
+}
+
+type GetAdminPurges200JSONResponse []Purge
+
+func (response GetAdminPurges200JSONResponse) VisitGetAdminPurgesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminPurges401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response GetAdminPurges401ApplicationProblemPlusJSONResponse) VisitGetAdminPurgesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminPurgesdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetAdminPurgesdefaultApplicationProblemPlusJSONResponse) VisitGetAdminPurgesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetAdminReceiptsExportRequestObject struct {
+	Params GetAdminReceiptsExportParams
+}
+
+type GetAdminReceiptsExportResponseObject interface {
+	VisitGetAdminReceiptsExportResponse(w http.ResponseWriter) error
+}
+
+type GetAdminReceiptsExport200ApplicationxNdjsonResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response GetAdminReceiptsExport200ApplicationxNdjsonResponse) VisitGetAdminReceiptsExportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type GetAdminReceiptsExport200TextcsvResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response GetAdminReceiptsExport200TextcsvResponse) VisitGetAdminReceiptsExportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/csv")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type GetAdminReceiptsExport400ApplicationProblemPlusJSONResponse Problem
+
+func (response GetAdminReceiptsExport400ApplicationProblemPlusJSONResponse) VisitGetAdminReceiptsExportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminReceiptsExport401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response GetAdminReceiptsExport401ApplicationProblemPlusJSONResponse) VisitGetAdminReceiptsExportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminReceiptsExportdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetAdminReceiptsExportdefaultApplicationProblemPlusJSONResponse) VisitGetAdminReceiptsExportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostAdminReceiptsIdApproveRequestObject struct {
+	Id   ReceiptId `json:"id"`
+	Body *PostAdminReceiptsIdApproveJSONRequestBody
+}
+
+type PostAdminReceiptsIdApproveResponseObject interface {
+	VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error
+}
+
+type PostAdminReceiptsIdApprove200JSONResponse struct{ DecidedJSONResponse }
+
+func (response PostAdminReceiptsIdApprove200JSONResponse) VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdApprove400ApplicationProblemPlusJSONResponse struct {
+	InvalidDecisionApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdApprove400ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdApprove401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdApprove401ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdApprove404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdApprove404ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdApprove409ApplicationProblemPlusJSONResponse struct {
+	NotPendingApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdApprove409ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdApprovedefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PostAdminReceiptsIdApprovedefaultApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdApproveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostAdminReceiptsIdRejectRequestObject struct {
+	Id   ReceiptId `json:"id"`
+	Body *PostAdminReceiptsIdRejectJSONRequestBody
+}
+
+type PostAdminReceiptsIdRejectResponseObject interface {
+	VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error
+}
+
+type PostAdminReceiptsIdReject200JSONResponse struct{ DecidedJSONResponse }
+
+func (response PostAdminReceiptsIdReject200JSONResponse) VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdReject400ApplicationProblemPlusJSONResponse struct {
+	InvalidDecisionApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdReject400ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdReject401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdReject401ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdReject404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdReject404ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdReject409ApplicationProblemPlusJSONResponse struct {
+	NotPendingApplicationProblemPlusJSONResponse
+}
+
+func (response PostAdminReceiptsIdReject409ApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostAdminReceiptsIdRejectdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PostAdminReceiptsIdRejectdefaultApplicationProblemPlusJSONResponse) VisitPostAdminReceiptsIdRejectResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetAdminReviewQueueRequestObject struct {
+}
+
+type GetAdminReviewQueueResponseObject interface {
+	VisitGetAdminReviewQueueResponse(w http.ResponseWriter) error
+}
+
+type GetAdminReviewQueue200JSONResponse []ReceiptRecord
+
+func (response GetAdminReviewQueue200JSONResponse) VisitGetAdminReviewQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminReviewQueue401ApplicationProblemPlusJSONResponse Problem
+
+func (response GetAdminReviewQueue401ApplicationProblemPlusJSONResponse) VisitGetAdminReviewQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetAdminReviewQueuedefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetAdminReviewQueuedefaultApplicationProblemPlusJSONResponse) VisitGetAdminReviewQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsRequestObject struct {
+	Params GetReceiptsParams
+}
+
+type GetReceiptsResponseObject interface {
+	VisitGetReceiptsResponse(w http.ResponseWriter) error
+}
+
+type GetReceipts200JSONResponse ReceiptPage
+
+func (response GetReceipts200JSONResponse) VisitGetReceiptsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceipts400ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceipts400ApplicationProblemPlusJSONResponse) VisitGetReceiptsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetReceiptsdefaultApplicationProblemPlusJSONResponse) VisitGetReceiptsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostReceiptsImportRequestObject struct {
+	Params PostReceiptsImportParams
+	Body   *multipart.Reader
+}
+
+type PostReceiptsImportResponseObject interface {
+	VisitPostReceiptsImportResponse(w http.ResponseWriter) error
+}
+
+type PostReceiptsImport200JSONResponse ImportReport
+
+func (response PostReceiptsImport200JSONResponse) VisitPostReceiptsImportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsImport400ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsImport400ApplicationProblemPlusJSONResponse) VisitPostReceiptsImportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsImportdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PostReceiptsImportdefaultApplicationProblemPlusJSONResponse) VisitPostReceiptsImportResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostReceiptsProcessRequestObject struct {
+	Params PostReceiptsProcessParams
+	Body   *PostReceiptsProcessJSONRequestBody
+}
+
+type PostReceiptsProcessResponseObject interface {
+	VisitPostReceiptsProcessResponse(w http.ResponseWriter) error
+}
+
+type PostReceiptsProcess200JSONResponse ProcessedReceipt
+
+func (response PostReceiptsProcess200JSONResponse) VisitPostReceiptsProcessResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcess400ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcess400ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcess409ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcess409ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcess422ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcess422ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PostReceiptsProcessdefaultApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostReceiptsProcessEmailRequestObject struct {
+	Params PostReceiptsProcessEmailParams
+	Body   io.Reader
+}
+
+type PostReceiptsProcessEmailResponseObject interface {
+	VisitPostReceiptsProcessEmailResponse(w http.ResponseWriter) error
+}
+
+type PostReceiptsProcessEmail200JSONResponse EmailReceipt
+
+func (response PostReceiptsProcessEmail200JSONResponse) VisitPostReceiptsProcessEmailResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessEmail400ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcessEmail400ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessEmailResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessEmail409ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcessEmail409ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessEmailResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessEmail422ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcessEmail422ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessEmailResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessEmaildefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PostReceiptsProcessEmaildefaultApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessEmailResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PostReceiptsProcessTextRequestObject struct {
+	Params PostReceiptsProcessTextParams
+	Body   *PostReceiptsProcessTextTextRequestBody
+}
+
+type PostReceiptsProcessTextResponseObject interface {
+	VisitPostReceiptsProcessTextResponse(w http.ResponseWriter) error
+}
+
+type PostReceiptsProcessText200JSONResponse TextReceipt
+
+func (response PostReceiptsProcessText200JSONResponse) VisitPostReceiptsProcessTextResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessText400ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcessText400ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessTextResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessText409ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcessText409ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessTextResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessText422ApplicationProblemPlusJSONResponse Problem
+
+func (response PostReceiptsProcessText422ApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessTextResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(422)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PostReceiptsProcessTextdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PostReceiptsProcessTextdefaultApplicationProblemPlusJSONResponse) VisitPostReceiptsProcessTextResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsIdRequestObject struct {
+	Id ReceiptId `json:"id"`
+}
+
+type GetReceiptsIdResponseObject interface {
+	VisitGetReceiptsIdResponse(w http.ResponseWriter) error
+}
+
+type GetReceiptsId200ResponseHeaders struct {
+	ETag string
+}
+
+type GetReceiptsId200JSONResponse struct {
+	Body    ReceiptRecord
+	Headers GetReceiptsId200ResponseHeaders
+}
+
+func (response GetReceiptsId200JSONResponse) VisitGetReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsId400ApplicationProblemPlusJSONResponse struct {
+	InvalidIdApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsId400ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsId404ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceiptsId404ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIddefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetReceiptsIddefaultApplicationProblemPlusJSONResponse) VisitGetReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PatchReceiptsIdRequestObject struct {
+	Id     ReceiptId `json:"id"`
+	Params PatchReceiptsIdParams
+	Body   *PatchReceiptsIdApplicationMergePatchPlusJSONRequestBody
+}
+
+type PatchReceiptsIdResponseObject interface {
+	VisitPatchReceiptsIdResponse(w http.ResponseWriter) error
+}
+
+type PatchReceiptsId200JSONResponse struct{ CorrectedJSONResponse }
+
+func (response PatchReceiptsId200JSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PatchReceiptsId400ApplicationProblemPlusJSONResponse struct {
+	InvalidCorrectionApplicationProblemPlusJSONResponse
+}
+
+func (response PatchReceiptsId400ApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchReceiptsId401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PatchReceiptsId401ApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchReceiptsId404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PatchReceiptsId404ApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchReceiptsId409ApplicationProblemPlusJSONResponse struct {
+	NotCorrectableApplicationProblemPlusJSONResponse
+}
+
+func (response PatchReceiptsId409ApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchReceiptsId412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response PatchReceiptsId412ApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchReceiptsId428ApplicationProblemPlusJSONResponse struct {
+	PreconditionRequiredApplicationProblemPlusJSONResponse
+}
+
+func (response PatchReceiptsId428ApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(428)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchReceiptsIddefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PatchReceiptsIddefaultApplicationProblemPlusJSONResponse) VisitPatchReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PutReceiptsIdRequestObject struct {
+	Id     ReceiptId `json:"id"`
+	Params PutReceiptsIdParams
+	Body   *PutReceiptsIdJSONRequestBody
+}
+
+type PutReceiptsIdResponseObject interface {
+	VisitPutReceiptsIdResponse(w http.ResponseWriter) error
+}
+
+type PutReceiptsId200JSONResponse struct{ CorrectedJSONResponse }
+
+func (response PutReceiptsId200JSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PutReceiptsId400ApplicationProblemPlusJSONResponse struct {
+	InvalidCorrectionApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsId400ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsId401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsId401ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsId404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsId404ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsId409ApplicationProblemPlusJSONResponse struct {
+	NotCorrectableApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsId409ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsId412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsId412ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsId428ApplicationProblemPlusJSONResponse struct {
+	PreconditionRequiredApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsId428ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(428)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIddefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PutReceiptsIddefaultApplicationProblemPlusJSONResponse) VisitPutReceiptsIdResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsIdBreakdownRequestObject struct {
+	Id ReceiptId `json:"id"`
+}
+
+type GetReceiptsIdBreakdownResponseObject interface {
+	VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error
+}
+
+type GetReceiptsIdBreakdown200JSONResponse PointsBreakdown
+
+func (response GetReceiptsIdBreakdown200JSONResponse) VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdBreakdown202JSONResponse ReviewStatus
+
+func (response GetReceiptsIdBreakdown202JSONResponse) VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdBreakdown400ApplicationProblemPlusJSONResponse struct {
+	InvalidIdApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsIdBreakdown400ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdBreakdown404ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceiptsIdBreakdown404ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdBreakdown409ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceiptsIdBreakdown409ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdBreakdowndefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetReceiptsIdBreakdowndefaultApplicationProblemPlusJSONResponse) VisitGetReceiptsIdBreakdownResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsIdImageRequestObject struct {
+	Id ReceiptId `json:"id"`
+}
+
+type GetReceiptsIdImageResponseObject interface {
+	VisitGetReceiptsIdImageResponse(w http.ResponseWriter) error
+}
+
+type GetReceiptsIdImage200ImageResponse struct {
+	Body          io.Reader
+	ContentType   string
+	ContentLength int64
+}
+
+func (response GetReceiptsIdImage200ImageResponse) VisitGetReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", response.ContentType)
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type GetReceiptsIdImage400ApplicationProblemPlusJSONResponse struct {
+	InvalidIdApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsIdImage400ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdImage401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsIdImage401ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdImage404ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceiptsIdImage404ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdImagedefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetReceiptsIdImagedefaultApplicationProblemPlusJSONResponse) VisitGetReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type PutReceiptsIdImageRequestObject struct {
+	Id          ReceiptId `json:"id"`
+	ContentType string
+	Body        io.Reader
+}
+
+type PutReceiptsIdImageResponseObject interface {
+	VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error
+}
+
+type PutReceiptsIdImage200JSONResponse ReceiptImage
+
+func (response PutReceiptsIdImage200JSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIdImage400ApplicationProblemPlusJSONResponse struct {
+	InvalidIdApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsIdImage400ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIdImage401ApplicationProblemPlusJSONResponse struct {
+	UnauthorizedApplicationProblemPlusJSONResponse
+}
+
+func (response PutReceiptsIdImage401ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIdImage404ApplicationProblemPlusJSONResponse Problem
+
+func (response PutReceiptsIdImage404ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIdImage413ApplicationProblemPlusJSONResponse Problem
+
+func (response PutReceiptsIdImage413ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(413)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIdImage415ApplicationProblemPlusJSONResponse Problem
+
+func (response PutReceiptsIdImage415ApplicationProblemPlusJSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(415)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PutReceiptsIdImagedefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response PutReceiptsIdImagedefaultApplicationProblemPlusJSONResponse) VisitPutReceiptsIdImageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsIdPointsRequestObject struct {
+	Id ReceiptId `json:"id"`
+}
+
+type GetReceiptsIdPointsResponseObject interface {
+	VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error
+}
+
+type GetReceiptsIdPoints200JSONResponse Points
+
+func (response GetReceiptsIdPoints200JSONResponse) VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdPoints202JSONResponse ReviewStatus
+
+func (response GetReceiptsIdPoints202JSONResponse) VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdPoints400ApplicationProblemPlusJSONResponse struct {
+	InvalidIdApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsIdPoints400ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdPoints404ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceiptsIdPoints404ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdPoints409ApplicationProblemPlusJSONResponse Problem
+
+func (response GetReceiptsIdPoints409ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdPointsdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetReceiptsIdPointsdefaultApplicationProblemPlusJSONResponse) VisitGetReceiptsIdPointsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetReceiptsIdVersionsRequestObject struct {
+	Id ReceiptId `json:"id"`
+}
+
+type GetReceiptsIdVersionsResponseObject interface {
+	VisitGetReceiptsIdVersionsResponse(w http.ResponseWriter) error
+}
+
+type GetReceiptsIdVersions200JSONResponse []ReceiptVersion
+
+func (response GetReceiptsIdVersions200JSONResponse) VisitGetReceiptsIdVersionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdVersions400ApplicationProblemPlusJSONResponse struct {
+	InvalidIdApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsIdVersions400ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdVersionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdVersions404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response GetReceiptsIdVersions404ApplicationProblemPlusJSONResponse) VisitGetReceiptsIdVersionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetReceiptsIdVersionsdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetReceiptsIdVersionsdefaultApplicationProblemPlusJSONResponse) VisitGetReceiptsIdVersionsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+// StrictServerInterface represents all server handlers.
+type StrictServerInterface interface {
+	// Returns the points ledger totals
+	// (GET /admin/ledger)
+	GetAdminLedger(ctx context.Context, request GetAdminLedgerRequestObject) (GetAdminLedgerResponseObject, error)
+	// Erases the receipts of a principal
+	// (DELETE /admin/principals/{principal}/receipts)
+	DeleteAdminPrincipalsPrincipalReceipts(ctx context.Context, request DeleteAdminPrincipalsPrincipalReceiptsRequestObject) (DeleteAdminPrincipalsPrincipalReceiptsResponseObject, error)
+	// Lists the purge audit log
+	// (GET /admin/purges)
+	GetAdminPurges(ctx context.Context, request GetAdminPurgesRequestObject) (GetAdminPurgesResponseObject, error)
+	// Exports the stored receipts with their points
+	// (GET /admin/receipts/export)
+	GetAdminReceiptsExport(ctx context.Context, request GetAdminReceiptsExportRequestObject) (GetAdminReceiptsExportResponseObject, error)
+	// Approves a receipt awaiting review
+	// (POST /admin/receipts/{id}/approve)
+	PostAdminReceiptsIdApprove(ctx context.Context, request PostAdminReceiptsIdApproveRequestObject) (PostAdminReceiptsIdApproveResponseObject, error)
+	// Rejects a receipt awaiting review
+	// (POST /admin/receipts/{id}/reject)
+	PostAdminReceiptsIdReject(ctx context.Context, request PostAdminReceiptsIdRejectRequestObject) (PostAdminReceiptsIdRejectResponseObject, error)
+	// Lists the receipts awaiting review
+	// (GET /admin/review-queue)
+	GetAdminReviewQueue(ctx context.Context, request GetAdminReviewQueueRequestObject) (GetAdminReviewQueueResponseObject, error)
+	// Lists the caller's stored receipt records
+	// (GET /receipts)
+	GetReceipts(ctx context.Context, request GetReceiptsRequestObject) (GetReceiptsResponseObject, error)
+	// Imports receipts from a CSV file
+	// (POST /receipts/import)
+	PostReceiptsImport(ctx context.Context, request PostReceiptsImportRequestObject) (PostReceiptsImportResponseObject, error)
+	// Submits a receipt for processing
+	// (POST /receipts/process)
+	PostReceiptsProcess(ctx context.Context, request PostReceiptsProcessRequestObject) (PostReceiptsProcessResponseObject, error)
+	// Submits a receipt sent by email
+	// (POST /receipts/process/email)
+	PostReceiptsProcessEmail(ctx context.Context, request PostReceiptsProcessEmailRequestObject) (PostReceiptsProcessEmailResponseObject, error)
+	// Submits a receipt as printed text
+	// (POST /receipts/process/text)
+	PostReceiptsProcessText(ctx context.Context, request PostReceiptsProcessTextRequestObject) (PostReceiptsProcessTextResponseObject, error)
+	// Returns the stored receipt record
+	// (GET /receipts/{id})
+	GetReceiptsId(ctx context.Context, request GetReceiptsIdRequestObject) (GetReceiptsIdResponseObject, error)
+	// Corrects fields of a receipt
+	// (PATCH /receipts/{id})
+	PatchReceiptsId(ctx context.Context, request PatchReceiptsIdRequestObject) (PatchReceiptsIdResponseObject, error)
+	// Corrects a receipt
+	// (PUT /receipts/{id})
+	PutReceiptsId(ctx context.Context, request PutReceiptsIdRequestObject) (PutReceiptsIdResponseObject, error)
+	// Explains the points awarded for the receipt
+	// (GET /receipts/{id}/breakdown)
+	GetReceiptsIdBreakdown(ctx context.Context, request GetReceiptsIdBreakdownRequestObject) (GetReceiptsIdBreakdownResponseObject, error)
+	// Returns the photo of the receipt
+	// (GET /receipts/{id}/image)
+	GetReceiptsIdImage(ctx context.Context, request GetReceiptsIdImageRequestObject) (GetReceiptsIdImageResponseObject, error)
+	// Attaches a photo to the receipt
+	// (PUT /receipts/{id}/image)
+	PutReceiptsIdImage(ctx context.Context, request PutReceiptsIdImageRequestObject) (PutReceiptsIdImageResponseObject, error)
+	// Returns the points awarded for the receipt
+	// (GET /receipts/{id}/points)
+	GetReceiptsIdPoints(ctx context.Context, request GetReceiptsIdPointsRequestObject) (GetReceiptsIdPointsResponseObject, error)
+	// Returns the versions of a receipt
+	// (GET /receipts/{id}/versions)
+	GetReceiptsIdVersions(ctx context.Context, request GetReceiptsIdVersionsRequestObject) (GetReceiptsIdVersionsResponseObject, error)
+}
+
+type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
+type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+
+type StrictHTTPServerOptions struct {
+	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
+	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		},
+	}}
+}
+
+func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
+}
+
+type strictHandler struct {
+	ssi         StrictServerInterface
+	middlewares []StrictMiddlewareFunc
+	options     StrictHTTPServerOptions
+}
+
+// GetAdminLedger operation middleware
+func (sh *strictHandler) GetAdminLedger(w http.ResponseWriter, r *http.Request) {
+	var request GetAdminLedgerRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetAdminLedger(ctx, request.(GetAdminLedgerRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetAdminLedger")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetAdminLedgerResponseObject); ok {
+		if err := validResponse.VisitGetAdminLedgerResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteAdminPrincipalsPrincipalReceipts operation middleware
+func (sh *strictHandler) DeleteAdminPrincipalsPrincipalReceipts(w http.ResponseWriter, r *http.Request, principal string) {
+	var request DeleteAdminPrincipalsPrincipalReceiptsRequestObject
+
+	request.Principal = principal
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteAdminPrincipalsPrincipalReceipts(ctx, request.(DeleteAdminPrincipalsPrincipalReceiptsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteAdminPrincipalsPrincipalReceipts")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteAdminPrincipalsPrincipalReceiptsResponseObject); ok {
+		if err := validResponse.VisitDeleteAdminPrincipalsPrincipalReceiptsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetAdminPurges operation middleware
+func (sh *strictHandler) GetAdminPurges(w http.ResponseWriter, r *http.Request) {
+	var request GetAdminPurgesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetAdminPurges(ctx, request.(GetAdminPurgesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetAdminPurges")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetAdminPurgesResponseObject); ok {
+		if err := validResponse.VisitGetAdminPurgesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetAdminReceiptsExport operation middleware
+func (sh *strictHandler) GetAdminReceiptsExport(w http.ResponseWriter, r *http.Request, params GetAdminReceiptsExportParams) {
+	var request GetAdminReceiptsExportRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetAdminReceiptsExport(ctx, request.(GetAdminReceiptsExportRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetAdminReceiptsExport")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetAdminReceiptsExportResponseObject); ok {
+		if err := validResponse.VisitGetAdminReceiptsExportResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostAdminReceiptsIdApprove operation middleware
+func (sh *strictHandler) PostAdminReceiptsIdApprove(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request PostAdminReceiptsIdApproveRequestObject
+
+	request.Id = id
+
+	var body PostAdminReceiptsIdApproveJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostAdminReceiptsIdApprove(ctx, request.(PostAdminReceiptsIdApproveRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostAdminReceiptsIdApprove")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostAdminReceiptsIdApproveResponseObject); ok {
+		if err := validResponse.VisitPostAdminReceiptsIdApproveResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostAdminReceiptsIdReject operation middleware
+func (sh *strictHandler) PostAdminReceiptsIdReject(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request PostAdminReceiptsIdRejectRequestObject
+
+	request.Id = id
+
+	var body PostAdminReceiptsIdRejectJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostAdminReceiptsIdReject(ctx, request.(PostAdminReceiptsIdRejectRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostAdminReceiptsIdReject")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostAdminReceiptsIdRejectResponseObject); ok {
+		if err := validResponse.VisitPostAdminReceiptsIdRejectResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetAdminReviewQueue operation middleware
+func (sh *strictHandler) GetAdminReviewQueue(w http.ResponseWriter, r *http.Request) {
+	var request GetAdminReviewQueueRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetAdminReviewQueue(ctx, request.(GetAdminReviewQueueRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetAdminReviewQueue")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetAdminReviewQueueResponseObject); ok {
+		if err := validResponse.VisitGetAdminReviewQueueResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetReceipts operation middleware
+func (sh *strictHandler) GetReceipts(w http.ResponseWriter, r *http.Request, params GetReceiptsParams) {
+	var request GetReceiptsRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetReceipts(ctx, request.(GetReceiptsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetReceipts")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetReceiptsResponseObject); ok {
+		if err := validResponse.VisitGetReceiptsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostReceiptsImport operation middleware
+func (sh *strictHandler) PostReceiptsImport(w http.ResponseWriter, r *http.Request, params PostReceiptsImportParams) {
+	var request PostReceiptsImportRequestObject
+
+	request.Params = params
+
+	if reader, err := r.MultipartReader(); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode multipart body: %w", err))
+		return
+	} else {
+		request.Body = reader
+	}
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostReceiptsImport(ctx, request.(PostReceiptsImportRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostReceiptsImport")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostReceiptsImportResponseObject); ok {
+		if err := validResponse.VisitPostReceiptsImportResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostReceiptsProcess operation middleware
+func (sh *strictHandler) PostReceiptsProcess(w http.ResponseWriter, r *http.Request, params PostReceiptsProcessParams) {
+	var request PostReceiptsProcessRequestObject
+
+	request.Params = params
+
+	var body PostReceiptsProcessJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostReceiptsProcess(ctx, request.(PostReceiptsProcessRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostReceiptsProcess")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostReceiptsProcessResponseObject); ok {
+		if err := validResponse.VisitPostReceiptsProcessResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostReceiptsProcessEmail operation middleware
+func (sh *strictHandler) PostReceiptsProcessEmail(w http.ResponseWriter, r *http.Request, params PostReceiptsProcessEmailParams) {
+	var request PostReceiptsProcessEmailRequestObject
+
+	request.Params = params
+
+	request.Body = r.Body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostReceiptsProcessEmail(ctx, request.(PostReceiptsProcessEmailRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostReceiptsProcessEmail")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostReceiptsProcessEmailResponseObject); ok {
+		if err := validResponse.VisitPostReceiptsProcessEmailResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PostReceiptsProcessText operation middleware
+func (sh *strictHandler) PostReceiptsProcessText(w http.ResponseWriter, r *http.Request, params PostReceiptsProcessTextParams) {
+	var request PostReceiptsProcessTextRequestObject
+
+	request.Params = params
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't read body: %w", err))
+		return
+	}
+	body := PostReceiptsProcessTextTextRequestBody(data)
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PostReceiptsProcessText(ctx, request.(PostReceiptsProcessTextRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PostReceiptsProcessText")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PostReceiptsProcessTextResponseObject); ok {
+		if err := validResponse.VisitPostReceiptsProcessTextResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetReceiptsId operation middleware
+func (sh *strictHandler) GetReceiptsId(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request GetReceiptsIdRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetReceiptsId(ctx, request.(GetReceiptsIdRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetReceiptsId")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetReceiptsIdResponseObject); ok {
+		if err := validResponse.VisitGetReceiptsIdResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PatchReceiptsId operation middleware
+func (sh *strictHandler) PatchReceiptsId(w http.ResponseWriter, r *http.Request, id ReceiptId, params PatchReceiptsIdParams) {
+	var request PatchReceiptsIdRequestObject
+
+	request.Id = id
+	request.Params = params
+
+	var body PatchReceiptsIdApplicationMergePatchPlusJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PatchReceiptsId(ctx, request.(PatchReceiptsIdRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PatchReceiptsId")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PatchReceiptsIdResponseObject); ok {
+		if err := validResponse.VisitPatchReceiptsIdResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PutReceiptsId operation middleware
+func (sh *strictHandler) PutReceiptsId(w http.ResponseWriter, r *http.Request, id ReceiptId, params PutReceiptsIdParams) {
+	var request PutReceiptsIdRequestObject
+
+	request.Id = id
+	request.Params = params
+
+	var body PutReceiptsIdJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PutReceiptsId(ctx, request.(PutReceiptsIdRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PutReceiptsId")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PutReceiptsIdResponseObject); ok {
+		if err := validResponse.VisitPutReceiptsIdResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetReceiptsIdBreakdown operation middleware
+func (sh *strictHandler) GetReceiptsIdBreakdown(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request GetReceiptsIdBreakdownRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetReceiptsIdBreakdown(ctx, request.(GetReceiptsIdBreakdownRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetReceiptsIdBreakdown")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetReceiptsIdBreakdownResponseObject); ok {
+		if err := validResponse.VisitGetReceiptsIdBreakdownResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetReceiptsIdImage operation middleware
+func (sh *strictHandler) GetReceiptsIdImage(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request GetReceiptsIdImageRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetReceiptsIdImage(ctx, request.(GetReceiptsIdImageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetReceiptsIdImage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetReceiptsIdImageResponseObject); ok {
+		if err := validResponse.VisitGetReceiptsIdImageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// PutReceiptsIdImage operation middleware
+func (sh *strictHandler) PutReceiptsIdImage(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request PutReceiptsIdImageRequestObject
+
+	request.Id = id
+	request.ContentType = r.Header.Get("Content-Type")
+
+	request.Body = r.Body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PutReceiptsIdImage(ctx, request.(PutReceiptsIdImageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PutReceiptsIdImage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PutReceiptsIdImageResponseObject); ok {
+		if err := validResponse.VisitPutReceiptsIdImageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetReceiptsIdPoints operation middleware
+func (sh *strictHandler) GetReceiptsIdPoints(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request GetReceiptsIdPointsRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetReceiptsIdPoints(ctx, request.(GetReceiptsIdPointsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetReceiptsIdPoints")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetReceiptsIdPointsResponseObject); ok {
+		if err := validResponse.VisitGetReceiptsIdPointsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetReceiptsIdVersions operation middleware
+func (sh *strictHandler) GetReceiptsIdVersions(w http.ResponseWriter, r *http.Request, id ReceiptId) {
+	var request GetReceiptsIdVersionsRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetReceiptsIdVersions(ctx, request.(GetReceiptsIdVersionsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetReceiptsIdVersions")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetReceiptsIdVersionsResponseObject); ok {
+		if err := validResponse.VisitGetReceiptsIdVersionsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+x9a3cbN7LgX8HpmT2Z2TQp6mEn1jlz7mr8SDQTO7qSktk7oVeCukESoybQAdCmGa/+",
+	"+54qPBr9Iik/ZO/cfIgjdqOBQqFQqDfeJZlcllIwYXRy/C5ZMJozhX8+v6Rz+H/OdKZ4abgUyXFymjNh",
+	"+IwzTcyCkaxSiglD3jCluRREzvCxYhnjpUmJkUQzkRMuyOls9JKabEFWCyZIJpVimeFiTrgZJ2miswVb",
+	"UhjQrEuWHCfaKC7myd3dXZqUVNElMw6y05wtS2mYyNZ/Z+sujCckKzgTZpQtpGaC3LI1MQtqyJLeMk0U",
+	"M2oNAwOkurpZco2wazpjSZpw6MLiIUkTQZcsOY6HHMGYMbxL+vYHJuZmkRwfPHqUJksu/O/9tDObNDmd",
+	"IR66cF8uGAGseyx6pN4wgNZhjOVjcuJ/wNsVNwtZGcIN4TC5WaVZjk/J0cG35EyxTIqcY9tz9mvFFcvH",
+	"g/N0i9Sc4JYJnSkuMl7S4jTfSDCrhbT4NoblTTrREn5z5Z9oklGYOGGKwnSkIIr9WjFthiD/36MAxug0",
+	"/6AFOrcwnOb9S3T6rEPmlPz0EzxW8NcPp888kCU1ixpEDmAptwTJsVEVi8EsqTFMwWf/Zzq9+PqPSdq3",
+	"FRTTpRSa4U546mkCfmRSGCYM/EnLsuAZBaD3/qUB8nfRQH9UbJYcJ3/Yq/f+nn2r99zcz1kmVW5H7GLA",
+	"zRv+L5WjNeQGHhzfIkn7OErf+K7ZHrbBYZ+xjOcPPbOqzGkEv5thArtWvKEFz5+GjbcBsFLJm4Itv74f",
+	"gGf2qyHQTp/B/l7SYibVEraE6sc5tOIW2AhuwKb+cqDOHTgRsCmZSUXYW7osC0YoUexfLQ4HzygAV0/r",
+	"NP88ExISwFmxohi5iXnsnz4D8F5J4yiF3hTsoWH0sKyodmj0LPQNZytYA2404YYtSc4M5YUmK6YYKSs1",
+	"Z3k6TFp5ZSFnmlAhzYIpQoVl5eEVKWXBs7UbGIZxCHkhK/Ggy/VKBsBnMDYSGAoCYZHOmMiBtX6mBfKU",
+	"tKIcRSG7QAmeqfWx/YLygj0o5oKslktmQVziT9OSUNw8vtJtQbA9hfNw6j3wbvUTsecL8iIQ98TcQmg/",
+	"fkCgTsSa2I3juk6JrrIFoZpQL+KQN1wW1FgRlWuiS5ahbCEIF4YpQQvClJIK5vCToJVZSMV/e1jsvrRo",
+	"RGZieTGh+ZILYuQtEyhHuV5gkPj4aSPEfveVJrQslXxDC+iz5v5yRqgnNJBbSyVLpgy3ElBupYQTnDCw",
+	"YmqS4wQO8ZHhS9aVodLEHSIdTSNN3o7mcgQPR/qWlyOJMNJiVErEuxXZYGKGmkpvQ96FbWWFNk/+v/iP",
+	"0wj01wFKeQPTBiifLykvnMwCIzWnnUkx4zkTGdsGxQvOivxp3fwuTfiwYEu15nPBcmJkvMHH/XgMwO0g",
+	"d8EXhi3LghrWP7zCo4gp0BM0I74xMZ0TjVqZc0xObjQTxmqUPHpb8FtGKDHsrdkwhdbCOPHcC64RinvX",
+	"520plXnhKA7mM6NVYZLjJNNvkvZueXrxMx6rgq0KLtgoZwVfcsNy8reLH1/hbGoq93J1yRSB1gA6E9US",
+	"gLSdixz38eueVbFwncuVbkJVT6wJ2T8WzJ7j5OnFz4Th12Rh2ZFcIQweLKmih9ywZQxY3T+86QWtTYod",
+	"KvherohHu3HrTnPkMjPCaLYgM+iCcJ2SmZJLMsEjnZJ5xbQGmt3HBxlThnIBirKRhhZEV0uQ0fAb6BZA",
+	"1OSGZbTSlsCQVBY0J0IKRnQmFdOkkKsUOHMu3RcoPOTSHtp5TqrSb5RScYFaLYzXZVP4ecRyRLW8Yci/",
+	"y0plC6rZM7cvBhtc8mV/A79vel8iPD1vWrQf+mgB1Bo+dRPx/fZtDFzl53g8ddgWLl933X+QVqbEpXGH",
+	"yRtaVOyYULtBHAcOZ+U02UNI9iZ7peIZmyZOuGPkRubrIMIGwxEBHbyXjS2Z1nTeQ43/gMXmmqyUFPNa",
+	"yUXAtnMTO9W6+z5UnS5xrzL4t4ssPOJ1P6+074IYJleaFGxmiKxMSriAJ0SqnKlxWLNtbFqunnuZwgFK",
+	"laJr+M0RTitf7NTZqfsg5v2NPtus1w+Q+lkPo6vutYOxDQdb+xDRRiqWk0o4HG063Ybld7As6gWFnm7W",
+	"YSn6+wscudOZXNUrGQRqxHMKR5uyHKqWYGFx9xsL60aDPTJnaiu6I16dJw60XoQbtuxiOec6k5UYQI1/",
+	"Swy9ZSC9zQLHhdlwp0XgriW0AAa/JjTDbzRsYpiYswIkx8lkPNlP0qZtLP/6T9PpeDrN3x2kh3d//o8/",
+	"9uEbB+gH0Z4JFoKSrplXDLmuT7UAwOPx0ZP3AuDXigrDzbofBsuHYdkrwY0OOELivJHVfGEIF7VycOhO",
+	"OpAHwqk4TQ7J/yL744NH02RMToTrwZlL4CDjcI6DnF4wHKgxt4MUTKN8CQf4kydPnqBl1P7cT3toSi+k",
+	"Ms/iqfTN7AJakTMl8yozJGoeOHQXyS9h9SkX5Blbkf2Ds783Mf7LdFq+++EO/n2J/77Cfy/w36ezu+lU",
+	"T6ejaTWZHDz+arz3P9LXX/cuCWDgbJguLEXIGWIO2vpdaYn3hs2kYoSKdSDy5iwOxweP3oNU2vpBG8ue",
+	"lvt26A8sn7Oeg5bm/6q0WXq3ziZebbs4iT64SxMqpFgvvU65/fNL2FL4JZ7UA5zOvgNzh8q9jiF14HrI",
+	"OIMOx4V5fJT00aFvvm1n+Xap5/ZSORPXTgP1M02dhDk20JQ2cD68VifNlenCX7t2tBcuHfj1fLzRTTBD",
+	"sgUVcwaHvkOvWbA1WdKcdcXQqO+GvjyM63o574uveKzQzzBeHAX10w1VYS8i89ZOR/QYaZovATtakhtW",
+	"gOBmQFyHLd1Fxz0m16S6D6edPkS8gl4L/hv1zHUn4RkQ1JCRHaKweZNB7bWE+Q6DlIrPuaBF/ygo+cJh",
+	"FJx4ze4Pjg8PydnLvo7x0wF6h03EM1q4/i2xN3vePzo+PNxZ5A6z8OP2IfuMrmETvmRmIfN+VbQtM5aU",
+	"5w1dnOpFkiaZYjm3ivUN/n/OZ+YqowrFf3nDCwatFiy7BeDMgqle9fgsUOMQlW5idk3e2kDf/mRyf3a3",
+	"gU4toH9VjN7mciU+DsReOOTa+eWXQSauCqa/cs2bAsy3u50X0MFOJ9LN2poZQP9He3xVMNSnAA7Upyx3",
+	"pYoRtK5aTO+mYFUFc0u8TT4P54uFvHcNatN1x556/uIp+ebbyTfexBycPPZzYj+4gQmuFk5tcZbnGboa",
+	"+g6OnO1oLH4KTdFcDIN2AXz+tiwoF9oK3DKzngMreFnNALtpMoBzvw1BCwc7ZX7z+ObR48lowthsdHRw",
+	"k42e5PuPR/ns6NvZ4YR9++TmAK006Pjp40jBX/XjbEhpDFoZo6rgLApOCMYATZfAacWcKbQApdYWSSN3",
+	"WOyE61PaapDaaveQAeA5kOiKNlfO+gycEeXk7BQMaUbRzKQt86iFBEgdDS142OxMxJFxpwdgLrShYlC8",
+	"pmZR67kWajSooRUuH178PY/3vV2WfS/sng6Ca7t9F7rvLy/PiG1AgNprSG28RQOko8lRH6Mx3BQ9k7+o",
+	"lksKDhq7OLdc5Mj/3FzJqQlcD8lJKsLeMLWONkf/bthI4PbBlvitFiyps625n3oP7WpFIVe1hQOQ0xYr",
+	"fHO3UFdCmqsBwFqsDt96zKW1cwRG2cT4nsr83rMbE/hKEwHY9XLzklHBxXzsDY9XjjSPG3SayarIEd83",
+	"Vv2O3XZgcbSU7Py5II6No+11Zdnq8dCGdT6+GgbDl+w3KdhxyygEzwm8CAZOVPIN4WLBFAfVgGtSiVsh",
+	"V2LswiWuPL6PG1EVYOMX0rWJp2+XkOeNwX3cA+POW4ABTyJEPI1JFTki7aeRPzByvEYewzHpEMyxhcmO",
+	"CSAaZMVjErXwHM7NABcCmMuYLFGaw96opdpjZwY2kS9bVyV6OeCN/WJc82uPgONNUQ3N8LV6Gv7tcY/T",
+	"qh2GYadU2gCEK/us+V1/dMCY8DoY8eqWra8UA/5pv20FKuLY3soGrZwZKeezGUOHfWcOlYDWELbSWAu7",
+	"Axz1156UKJ4PPChSEQaOyzHhSzpnV61VixdWSNvGNzVSXhVUzR3R40NAAT7DuA0rhmmmYPvSLGNgMiCV",
+	"cOvJ8iv8yH5flYWkecAh+dvZ8+9Scvbqu5R8d/oCAP0HuzkLIMDhd2UVyOMeS2x/nIyWsPcyKmAyoGwy",
+	"BRiKIjXLKAYicIFN0RXdiAqIt2h1FG/oKBLUYdV3Pw7RAlcoSRzH6LOwkEqwtyXCWqzH4ay7cvvTMgz7",
+	"zPOrvOZXkcguRbEmqOIwDPbN2RtWyBI0rFhjavFYq6A1mSSGTja5YOJ99mHiUaOaXyVpEvOg2pnbOI7i",
+	"v7vsIokEw6vaTN7e366f5uYFoHp3ZtRBvbmgdXOLhCdhJ+CMWtTtJTVHrCipd2is/bSBt5gmkjRpL3q/",
+	"bqpkxrR+P9/LhqCCWojYRbRL0h2CZGFprB2FDYCEdoLIigEE68PZrDoZuA1XkX0Cz11L8sgGgllupaCf",
+	"3aXopqFnq3Ms7xeEYP37J0irnIcoAmfPRnJBWVPTNdjPqDNqwPMcbWagDHrnwS1jpY+Rd01yamhXM6Sm",
+	"z3PKRP0lWdCyZKJldd0YorODETkCLKhl4L+/YUy0bQrQtkArI85rVztz6QPKBxwd35+MDh49Bra7qJVX",
+	"90mvgdIGs3fdg7tHHtWxS22MW+Ec1ihaVnuwXbnD67j2MgWLQGUK/iaIM4YJ6NAJO2MIB7HnjH0N9LT9",
+	"G0V1pdhxCx9U3wZHWyPQ30hyE6MmnBUR5MgpSsfB3AC9fEr50H29ValvEc99VPPWBqUmnFBJA4SN1t7h",
+	"2C6r8w04Dk8vfiRHB/vfNJRU/wn+oEvrS6WKEQiCeY66pH1qI3sgIBu8WCzjSzCmU93sBRotOQj36JxM",
+	"o7ALcIdOE+CYP108S8k02T+YTOyDv539F5EKHo0PHrlnf/3+2Zicx2YT75m00EEvzXPgp4tnLeffyeif",
+	"r98d3vWyevbWqnHnvVFlYMTFmeI0hoJWs3XH4ZezspBoH/5KkxuqI9xY32xqhWkjbaiQ3VToptPeXNj4",
+	"bExe8KKwR83NuiGIeUm6MaaihmkUMGmcoiRVLO9lVNlEJufpdbk/bQf6k4Nhp+R++tg5JRWj+Y+iWPu8",
+	"lA6qw+bYLQLEsCV8teTi1Lbf79qLyrYNfqN5sdG4J3Kqu1fgjIm2O7YOkVpSDAsjB5ODg9FkfzTZj0+K",
+	"3EZEdU+rVoRWFxDU23cEhBwcjRayUvYjL563/CGHx+2wiD/9Mtl//R/Taf5/D36ZjA5f//n4l8no0evp",
+	"NP/jhvCWVzYcbJOlPjggBwCuuQPsMaOo0NTSp+2gRY6To4PRN998C9BHeVmPj7akZTXD3HqApcvIZGdb",
+	"wnaxkLe0athzrQAEGzzwkjyVSjBFXlJ1y8wOUQhx4MFA1AGCsCOiA+hz/sYZseoZBMpZIQfPkb036eLg",
+	"8OjeiNXVTYgS7ALnzg05awROYiSEoW+tJ5qX7ZiZyeS9YmYMfTuwgehbMNipeYf82tzuPaN1gq7ZJ8y2",
+	"8e8FWjSXUkFOT16d1LY5S4v1kXmyZIpndO8VW139l1S308QlCl4+hfAozUzUeDR5dAwH6picWpNeHLYa",
+	"E3bzyCAu0Nfa+mWfd7YNRj8WyiEGVsKgc0VNxc2a0Dzfos9N3psGhmkRX3mKBG9shxVxgVwoHLsfI5Tr",
+	"04XK+kTTZW/8KcRzwRtCjaHZwuKbdtkuJeVCGhmrIFHOVp8vDzNELnv9AzYCBRsQgJfkzLCsQYXWZNbL",
+	"6Rb04NHj3bUl7CklC/aWMAHybN7fLf9tAFJ40+jMyleG7ariWSuhzx8Z0GJtz2hJdSuxqybbCYmpMe+m",
+	"FZDWgGUDsZw5WmmuqWBvB+Ixs0ppYBeSlFSjqH9NZ4ap6xCOB9+SEtbU51O4XVVQ7V40d/b+yfk/D189",
+	"e/73y4ufj87PX7z4z8dPvnv04uTnDYKG3hxI63RL3MCYSH2v0OVmZu9uwa96M5J7E/RPbKDNkqk5+hSy",
+	"hc1OOveim3Nz4mb1U1tWOjbwurbEAj9OhoFw8+msdR7lUm1CS8i5uoe3e3dP9z305d3NG1H/W5175WKt",
+	"0SxX09CcqrxgGmdityf4TbAKhWVjkT+n1qr647U/UpIU97x9BwK254BXtdC+t5kxtY0v3qbRNvNYwnRP",
+	"Qk+IRmH9OdPk/Pmzk6eXz59Nkzq80EfHoPwjNavjZJwA6M0o97DsuTOyd2KXsZBlgwlMSjK5vOEiMkba",
+	"9zBMaqUuhNeLX7vDcv8ENmvoP0dTjx62wsWOvwUrrNnLfvtJts398hFR4rcS4hbqqmfRiPjbDbs+E7g/",
+	"lHCgXsx+I7wQF1YKRpZAbYBEjM6qTSDjZFvM+m9MybMBa7JPfYsny6gS6EVztO9zxHgr+T1wSkdxbuQb",
+	"KQtGxc5rtzkFMV6nJnOMIiU8njccZz/XSzEUJj4gPITYYtMTvr2gJq4TgCYpxXzicr3IPhZ5B3kMv99C",
+	"l77bBl22ahb0DvuhLgc/cS84OUCOG5WQpGA6tGhlxXvOGrPSZnBWWdAsGiEisnsGx9+DqUUbddNeapGq",
+	"/6qRMhtWb4vlGzhhnBLepMptTg4bV2Kzxe3K90dl+KIDFse2DYT6vLf/5W5wMheBAzen8jEyxXtR6LP1",
+	"7hMljq8IhSjPqjAp4Zb5oiMYlL6MAe9rMrR7e6iidMr3z6mr8xqHcukGU+mcNW1L5twW4oYBYsLelMQZ",
+	"xfX2iOobkqVCIOzKM1JVFY4xaM9zOglxPwrHj6I4QVqUCyoqNPCgmYxmBiYsmlYjLEP1/t7WGjpbg6yW",
+	"pWuPSiQxZlRkDH0eN2tCiWBzavgbRpgwqmmYeTwUtL1VB4BGY/K00kYu8YcdGiYKMm2GL46nSZ0wU5uJ",
+	"uSK25yZ+Pbau+rHVJpQKIznyZrrWMOu72BCBWkrNTVcoqpcRGA1ZSXU7K+QqdpV24lA8b0Qitqyx1116",
+	"yd6af6saEx9Q0gHkYpZVipv1BXTrJaQlF5dY0mTAJHmL1QzFjM8r5QvvnTx7efrq6vLHvz9/5SvRoXTI",
+	"qGKqnufCmNLWVuFiJvvsDZoDWQZSKG0YjlRTMRV/+APBM0BPhXXxWkYeArY4yqm/+GyA13+C4fTx3t5q",
+	"tRqrWTZiOTdSjaWa76lZBv9Buz8PpQ2gwzInVE/F9VBpmWuIMNHkOpM5uyZLho4NjA8LdjqNJbHIdRTI",
+	"e21Tf1xi2zVg55pwGKcOLX4HXd5d+5AOxXKusL7Ugik2JtcW2GvCPFeFsaLsAuh5SddepF1JlaNtQEtX",
+	"LVJPhV7YIEdFBZh2hJuHlSSYNrq3Mg+EXNllv+5Es13juAXXxqouvroBHsVTwQW5xlXT1+OpCHHQdYT3",
+	"mV/wSNA/TvbHk/EE87VKJmjJMfd0Mj60xu0FEu4eUu5eERJE56zv4LXZdNE21B3tP87WZMuUcJEVVW5x",
+	"IMEb5SNdMDokCpBMEECF+IBCbcl3zJzk6I1CoFrFDA8mk49W7M+NMFAVyiLFpRICHo8m+0M9BhD3GpWW",
+	"sF9XW2Xbh3UVpZrFJMe/NJnLL6/vXoPGB6kCa6QAUymh45VogX2X+kUOUTZ67134+26vaXotWJ+b/Jwt",
+	"JXgarSChvHEyqFUuWCH06mycUaYpLrgnEm3o2h9YdXJsE/LavuQieWzsApCPDZCoI8dsIFsh57ZQA6H9",
+	"wVbjDqU9w+kisYWyoDr8dR6lY0aVZX8ZSArvj+iiIaAL3YBoOOeCdOqQ9hQCDX1urAfaljxef8L9YoMJ",
+	"B7ZLO5wwWrovdPM8h3VpsTW01NeYj7cPTF4P8sgfuDZ+g2DTKLccA+5SIoscs+e40jbfCu1KN+t+K60E",
+	"hT5gcIhJnlmoPnDRd3KguNXvOE56qSHemY7pf6FUYBeuh5vEix9yy2zpq0EquDCK0aWng2ZGvnXveLPX",
+	"jBeGqaYvC1kEFNiSCv7qLwM2JpjZ5zvNqFLB2WEZrrMTtcJbqWJkQipheOGqQHv533LrBstqpW/APKCb",
+	"gjWy3WzdI4xqbuW/BuUJPEYrb5OzyAP2r5yRuJ+oPee1Vcp24b8uINuzHfwulK/+tWJqXfNV27ZR+3kT",
+	"2TdKuN3dpe8+Ymm0lChWRrbQsKiyqJZCj8mrZ7DkrlfI0VlBWPaCvmG+ykvUf2rlS1+1VQ8hACv53G/6",
+	"WCmuZ/LPHWCY2dFgpMGFQ2xEIrqTrTycU8MGF0fJZQO2LXF1HwJS8E9tgcnITwVRS4Liuimt9AETSwUf",
+	"Swp4OxJ591DozCuB5K09qC24sV3veWApOIqktqfB5KFLveZoiULljok8BKlxx5W+1EPKk1BPnZfgfOfK",
+	"HQC9B9c7nt/tOY5vqy/onvPrxDbQUZnJVmKhP07sLQnAa+yg5A3X3GYsNZn6mdRNrn6au1G6jL0Pc3WT",
+	"vbrkviVwVLf/KvP1Ryz/3nA63HWL6budtHmNfVX6msY3t2+XX39fIjyaHG3/KBTZxg+e7PSBr4D9yQl9",
+	"OwEOU7c1Yg4T9zm+34W2GRUN2kZzMeaDO6FqFyq3w/1/QeRtBfN3on9Qot9KmE2ahyejXytWsUE9pFZq",
+	"arG+TetOHY0SR1Az3SCVw3f/ieM+hL65LWCvX++0a9YOGYyI60spff5weu0QCViqiu1/W2ippc863EZS",
+	"rLW/GazXVRRMfaVblq6Wtotxo4QaQjEya0x+cgY8+DXCViyHD7RR1LC5E5JdHZSo1JLNtI1SddFkb4uS",
+	"wISvIXz12vFwcE1rGxBQMhrvuzFBStdejUM1Xgr2la6RiFrxdb9d4LpXp93VkNi+NQtRmPZZFMFVwPKd",
+	"Lzkath7e89KjXdhMredBQY4zqm0DtwIu0DjYZtkbLisd4of7NB7scaO2k/aZBFzR0p5Kj8RIxODQgGht",
+	"aQwYdurBJKqGihXTNgbDfEpjbBzp3XeFhN1b9bSbrPDBFS+38DvcivN+bLGH7wUe1M+3muxvz1aXHpYe",
+	"bV3pFrVz4ew+M14wX6Df7kcw9Pj4EvTqgeHHelsxxta7N3xh5/rwx1rR0e9btm4Z/bj2dYNc+GPpKy/4",
+	"Sw2ikDfBWsm1CII7kgAo5CiuLjj2pphX1uUKLBNuJt78hzUQLCOqyzB2xeEgCS/7rXhb5OD4vrjNkvCy",
+	"KgwvqTJ7QE8jMLY3ybYd+1QMJIr4hWwE0d1wQZE7dGvC07J01wK1jn5aal8/woUvWJrQtWHUmfjQ5ItN",
+	"/2KbkJJypYlmgApnGcrkckmjBOtrH37ylwvAf4oes7+cYMbTtbXmutGtLddZB/1XKYlTklISZySl1v1m",
+	"Q6ZtDSmfJpWSdtFhR3o8Y9Zbh2Tutm+YuZ2oi7axpwM3u1TGL3rjL3bVVT4Kj22U3t98S5Mmvjx9c2f7",
+	"jfWZ2C7yJcd08XoRjsEQSGkFzW5B+AE6ZrlbqFAzzFF366K4D2TOnok2pamahbZ4smNrw0z5AtlcrDlB",
+	"vJv7DAu2nft7PJs5Ku0KWCpypDeijxCHShvcEJhuwCj6N4H1te32dI4XipzF2Qc6JB7gcYHME3e0rXW+",
+	"pM3SCZPDvYPJHqSW2wzQqS+YiykXgeU6d0uj8gz0FiJJbJPrusrN9Zi8csF/Dizv5+nx3Pj6c19p8tRS",
+	"6+gHKuYVZuJ5YHO6driZIaBMjL776zQJFGRrF3ylPUvYfEy4IJZ7nxOte17v0q1f7H6yfLB09tAMq1OB",
+	"qYctnA+ReiPQ7jPxq/7rKZ3F5nPdjuhL4zVTTna4z/Do4ODB77J7z9J+H4G5b2PG/cx9D0sBbrLa0rzR",
+	"J54YwlYQJC7mOspzFoSNfFtKbHCvLdnl/eGxwAwrNSAzn0GEIBYsTMn3ly9/SMmvlTQsH2FyDYYnQk83",
+	"VLPHR1DekzummrNMhoHCuB7UiPH6gCAbAsIV8TXJ4XHO1Ji0tkS41MzdRuXuPmtXtMCcG+yZG+TGPTed",
+	"2YNoxbWT3airg+IqivgynIhtGy5gr2rBB8Gj7+Zks2yY2I3F46V1n1IfsEBBqOq3dgP2OG+HxPqH5deN",
+	"+/uG75Id4NFpU/hw9OF5Ux3HDCd8fTHaZ+LsWP82qP+BcJCYcY85qaGukFEXqf39DLjPdbZOs99cktUt",
+	"gGUx7qIwjEA2kfbokoDGn+Rw8KzP8v/+s8G4igX3OBpgkHDPHhKWPxp+fHoOmlhZmZ3PgMaNk54BBxxa",
+	"4bfgguk0KnwT3uMb4jwSaCpyynK4oqRue/nj5ckP+EUd+YocOaQtc9FSGzx4PhgLI66ZJov7X5AIlyLu",
+	"xLshF+QTsm6MMMHQ/K0xJg/HpOP8l39vHv07730Q3gtU/hkZL20yyBbrhVCKQV9grDv67oyc2wjIIJgu",
+	"maFYYzTE68f5y2EVN/nK0G/1YYETn9bv4t3Sm6nXIiBJnY8OgYFS3UODuGZ72OburuYCO4VSnMbxEF/K",
+	"/fkfSL4xxfX6cWyJyP5KPDBvjCXqVOT5Eya8HT55/OcOvw5W9ndTW5VrmhyTafJkDBXXILcMbw4zePev",
+	"/VBXBe4qSgRbhcoDKFGc/XSJBdzTlv1RMTjLbAkCI21kgB6Tl5gLp4lmsK+IqIrCqYuQgONUSgyA0DWc",
+	"KHVcu3auNsBqIa0fo3Wiw+Q/zh7bbmc7nWGF+XvY2HCFRrhCX7+nNxQH/EgRTU99if77xjQ9DfUlvryo",
+	"JgcbVpeHz/YPdtmadZn4F7Z2PJ6u397vU19q4dMHv7hJBi9c7F+1BYZ6DzfcPM3Tzfl0w20NdZWOuPi5",
+	"L8HhXrrsNXdBOHeOAa9jAJOoo66sILCxhkqc+YZsxmXK1YVZxvV1EVhJDIv+brgqwhUNbtVB8cMvGRVW",
+	"5fDX4Mg3TPn69QQKADuOhddauF7imzS5yFgn9Mfp/pVZMGFQarOCXEYFvMDAT2BcLSWsy8Qq8+WysE/t",
+	"JvidY/2bc6yIT3Xk8r2b+DrFLdF6jol0riqsE8Mk7NL2jTv2aoS6NMQGEb2+3PELldXbd1AOBY323+8I",
+	"yIKFP5gcfOTY61DKZ5sPrBO0q2UnLl0an3tB1swk/w46w2e2N3Sv4XLhLY2qbx9BuXkel6EYqB+2gRuE",
+	"spFbdfVuieBelWdzkWCMVfL1Z7tigI0lgIF0akUdewtqIQXrlRPGm7nLqbvI6BNyFoR273++h4uoS0Cu",
+	"TLGrfxK0vf4yybE9xK/K++7c9z+qv4TtHvw+fbe+PXABjZj2tykLJ3bVbDwn7q72prK6ODBvuFwk7D2b",
+	"ceik/pg4uB4qow0snxUzoqirREkFMarSjZZOPE1dOcxi3b7MDp7Xt9nZw8Pej9e3my28H7ifz6qPvp+H",
+	"ZPD338oP50hoVvAdKKPh6SRsgP9+PMFK+YcPLQLseJmkhe7R5/Wfb7qo8gGyNAPzc0zTyG2ySl0+cKuw",
+	"slkQsloLv7eycuYvfP6CNZWhtR+6Dv937eR37eTTaSe778m+De9NoTtVTBqq9B2kGykatwjLdjXlyL8S",
+	"2z2iOjjBZ9wxnrbrhYea0Bu4yc9+dp+Rn9wni9bBu2sabW3HjotWfdDu3d0u+BHJ1s+j5QC4u7v7fwMA",
+	"0VD13dSkAAA=",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
 }
@@ -0,0 +1,383 @@
+// Package textparse reads receipts from plain text, such as OCR output or the body of an email, as printed by a
+// till: the retailer on the first lines, then item lines ending in their price, a TOTAL line, and the date and time
+// of the purchase somewhere on the receipt. Each field comes with a confidence score, since the text is never as
+// reliable as a structured submission.
+package textparse
+
+import (
+	"fetch-app/normalize"
+	"fetch-app/server"
+	"fmt"
+	"github.com/oapi-codegen/runtime/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Confidence scores how likely each field was read correctly, from 0 (guessed or missing) to 1 (certain).
+type Confidence struct {
+	Retailer     float64 `json:"retailer"`
+	PurchaseDate float64 `json:"purchaseDate"`
+	PurchaseTime float64 `json:"purchaseTime"`
+	Items        float64 `json:"items"`
+	Total        float64 `json:"total"`
+}
+
+// Result is a receipt read from text.
+type Result struct {
+	Receipt    server.Receipt `json:"receipt"`
+	Confidence Confidence     `json:"confidence"`
+}
+
+// MissingError reports the fields that could not be found in the text at all.
+type MissingError struct {
+	// Fields are the names of the missing Receipt fields, such as "purchaseDate".
+	Fields []string
+}
+
+// Error lists the missing fields.
+func (e *MissingError) Error() string {
+	return "the text has no " + strings.Join(e.Fields, ", ")
+}
+
+var (
+	// priceLine matches a line ending in a price, optionally followed by a tax flag such as "T" or "N".
+	priceLine = regexp.MustCompile(`^(.*?)[\s.:]*(-)?\$?\s*(\d{1,6}[.,]\d{2})(-)?(?:\s+[A-Z]{1,2})?$`)
+	// totalLabel matches the label of the line with the amount paid.
+	totalLabel = regexp.MustCompile(`(?i)^(grand\s+)?total\b|^(amount|balance|total)\s+due\b`)
+	// subtotalLabel matches the label of the line with the sum of the items.
+	subtotalLabel = regexp.MustCompile(`(?i)^sub[\s-]?total\b`)
+	// taxLabel and tipLabel match the labels of the lines with the tax and the tip.
+	taxLabel = regexp.MustCompile(`(?i)^(sales\s+)?(tax|vat|gst|hst)\b`)
+	tipLabel = regexp.MustCompile(`(?i)^(tip|gratuity)\b`)
+	// otherLabel matches the labels of the other priced lines that are not items.
+	otherLabel = regexp.MustCompile(`(?i)\b(tax|vat|gst|change|cash|credit|debit|visa|mastercard|amex|discover|tend(er)?|tip|gratuity|payment|paid|savings|you saved|balance|rounding)\b`)
+	// paymentLabels match the labels of the lines with the amount tendered, by payment method, in the order they are
+	// tried.
+	paymentLabels = []struct {
+		method server.PaymentMethod
+		label  *regexp.Regexp
+	}{
+		{server.GiftCard, regexp.MustCompile(`(?i)\bgift\s*card\b`)},
+		{server.Mobile, regexp.MustCompile(`(?i)\b(apple|google|samsung)\s*pay\b`)},
+		{server.Debit, regexp.MustCompile(`(?i)\bdebit\b`)},
+		{server.Credit, regexp.MustCompile(`(?i)\b(credit|visa|mastercard|amex|discover)\b`)},
+		{server.Cash, regexp.MustCompile(`(?i)^cash\b`)},
+		{server.Check, regexp.MustCompile(`(?i)^(check|cheque)\b`)},
+	}
+	// storeNumber matches the number of the store, such as "Store #1234".
+	storeNumber = regexp.MustCompile(`(?i)\bstore\s*(?:#|no\.?|number)?\s*(\d{1,10})\b`)
+
+	isoDate   = regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`)
+	slashDate = regexp.MustCompile(`\b(\d{1,2})[/.-](\d{1,2})[/.-](\d{4}|\d{2})\b`)
+	nameDate  = regexp.MustCompile(`(?i)\b(?:(\d{1,2})\s+)?(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+(?:(\d{1,2}),?\s+)?(\d{4})\b`)
+	clock     = regexp.MustCompile(`(?i)\b(\d{1,2}):(\d{2})(?::\d{2})?\s*([ap])\.?m?\.?\b|\b(\d{1,2}):(\d{2})(?::\d{2})?\b`)
+
+	// retailerChars and descriptionChars are the characters the Receipt schema allows in the fields.
+	retailerChars    = regexp.MustCompile(`[^\p{L}\p{M}\p{N}\s\-&'.]+`)
+	descriptionChars = regexp.MustCompile(`[^\p{L}\p{M}\p{N}\p{S}\p{Cf}\s\-&'./%,]+`)
+	spaces           = regexp.MustCompile(`\s+`)
+)
+
+// Parse reads a receipt from its printed text, reading numeric dates that could be either way round in the order.
+//
+// Returns:
+//
+//	The receipt and the confidence in each field. A field that had to be inferred, such as a total summed from
+//	the items, has a low confidence.
+//	A *MissingError if the text has no item lines, or no total and no items to sum, in which case there is no
+//	receipt. A missing date or time is reported the same way.
+func Parse(text string, order normalize.Order) (Result, error) {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(spaces.ReplaceAllString(line, " ")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var result Result
+	var missing []string
+	result.Receipt.Retailer, result.Confidence.Retailer = retailer(lines)
+	if result.Receipt.Retailer == "" {
+		missing = append(missing, "retailer")
+	}
+
+	date, dateConfidence, found := purchaseDate(lines, order)
+	if found {
+		result.Receipt.PurchaseDate = types.Date{Time: date}
+		result.Confidence.PurchaseDate = dateConfidence
+	} else {
+		missing = append(missing, "purchaseDate")
+	}
+	result.Receipt.PurchaseTime, result.Confidence.PurchaseTime = purchaseTime(lines)
+	if result.Receipt.PurchaseTime == "" {
+		missing = append(missing, "purchaseTime")
+	}
+
+	prices := scanPrices(lines)
+	items, total, subtotal, discounts := prices.items, prices.total, prices.subtotal, prices.discounts
+	result.Receipt.Items = items
+	if len(items) == 0 {
+		missing = append(missing, "items")
+	}
+	sum := int64(0)
+	for _, item := range items {
+		cents, _ := parseCents(item.Price)
+		sum += cents
+	}
+
+	// The items are most likely complete when they, less any discounts, add up to the subtotal or the total printed
+	// below them
+	switch {
+	case total >= 0:
+		result.Receipt.Total = formatCents(total)
+		result.Confidence.Total = 0.95
+	case len(items) > 0:
+		result.Receipt.Total = formatCents(sum)
+		result.Confidence.Total = 0.3
+	default:
+		missing = append(missing, "total")
+	}
+	switch {
+	case len(items) == 0:
+	case sum-discounts == total || sum-discounts == subtotal:
+		result.Confidence.Items = 0.95
+	case total < 0 && subtotal < 0:
+		result.Confidence.Items = 0.5
+	default:
+		result.Confidence.Items = 0.4
+	}
+
+	// The optional fields are only filled in when printed, and have no confidence of their own
+	if subtotal >= 0 {
+		result.Receipt.Subtotal = optional(formatCents(subtotal))
+	}
+	if prices.tax >= 0 {
+		result.Receipt.Tax = optional(formatCents(prices.tax))
+	}
+	if prices.tip >= 0 {
+		result.Receipt.Tip = optional(formatCents(prices.tip))
+	}
+	if prices.payment != "" {
+		result.Receipt.PaymentMethod = &prices.payment
+	}
+	for _, line := range lines {
+		if m := storeNumber.FindStringSubmatch(line); m != nil {
+			result.Receipt.StoreNumber = optional(m[1])
+			break
+		}
+	}
+
+	if len(missing) > 0 {
+		return result, &MissingError{Fields: missing}
+	}
+	return result, nil
+}
+
+// retailer takes the first line with letters above the items that is not a date or time as the retailer, cleaned of
+// the characters a retailer name cannot have. Lines skipped on the way lower the confidence.
+func retailer(lines []string) (string, float64) {
+	confidence := 0.9
+	for _, line := range lines {
+		if clock.MatchString(line) || isoDate.MatchString(line) || slashDate.MatchString(line) || nameDate.MatchString(line) {
+			confidence -= 0.2
+			continue
+		}
+		if priceLine.MatchString(line) {
+			return "", 0
+		}
+		name := strings.TrimSpace(spaces.ReplaceAllString(retailerChars.ReplaceAllString(line, " "), " "))
+		if strings.IndexFunc(name, unicode.IsLetter) < 0 {
+			confidence -= 0.2
+			continue
+		}
+		if name != line {
+			confidence -= 0.1
+		}
+		return name, max(confidence, 0.3)
+	}
+	return "", 0
+}
+
+// purchaseDate finds the first date on the receipt. Numeric dates are read in the order unless a number cannot be a
+// month; a date that could be read either way has a lower confidence.
+func purchaseDate(lines []string, order normalize.Order) (time.Time, float64, bool) {
+	for _, line := range lines {
+		if m := isoDate.FindStringSubmatch(line); m != nil {
+			if date, ok := makeDate(m[1], m[2], m[3]); ok {
+				return date, 0.95, true
+			}
+		}
+		if m := nameDate.FindStringSubmatch(line); m != nil {
+			day := m[1]
+			if day == "" {
+				day = m[3]
+			}
+			month := strings.Index("janfebmaraprmayjunjulaugsepoctnovdec", strings.ToLower(m[2]))/3 + 1
+			if date, ok := makeDate(m[4], strconv.Itoa(month), day); ok {
+				return date, 0.9, true
+			}
+		}
+		if m := slashDate.FindStringSubmatch(line); m != nil {
+			year := m[3]
+			if len(year) == 2 {
+				year = "20" + year
+			}
+			first, _ := strconv.Atoi(m[1])
+			second, _ := strconv.Atoi(m[2])
+			switch {
+			case first > 12:
+				if date, ok := makeDate(year, m[2], m[1]); ok {
+					return date, 0.85, true
+				}
+			case second > 12 || first == second:
+				if date, ok := makeDate(year, m[1], m[2]); ok {
+					return date, 0.85, true
+				}
+			default:
+				month, day := m[1], m[2]
+				if order == normalize.DayFirst {
+					month, day = day, month
+				}
+				if date, ok := makeDate(year, month, day); ok {
+					return date, 0.6, true
+				}
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// makeDate builds a date, rejecting impossible ones such as February 30.
+func makeDate(year, month, day string) (time.Time, bool) {
+	date, err := time.Parse("2006-1-2", fmt.Sprintf("%s-%s-%s", year, strings.TrimLeft(month, "0"), strings.TrimLeft(day, "0")))
+	return date, err == nil
+}
+
+// purchaseTime finds the first valid time on the receipt and converts it to 24-hour time.
+func purchaseTime(lines []string) (string, float64) {
+	for _, line := range lines {
+		for _, m := range clock.FindAllStringSubmatch(line, -1) {
+			if m[1] != "" {
+				hour, _ := strconv.Atoi(m[1])
+				minute, _ := strconv.Atoi(m[2])
+				if hour < 1 || hour > 12 || minute > 59 {
+					continue
+				}
+				hour %= 12
+				if strings.EqualFold(m[3], "p") {
+					hour += 12
+				}
+				return fmt.Sprintf("%02d:%02d", hour, minute), 0.95
+			}
+			hour, _ := strconv.Atoi(m[4])
+			minute, _ := strconv.Atoi(m[5])
+			if hour > 23 || minute > 59 {
+				continue
+			}
+			return fmt.Sprintf("%02d:%02d", hour, minute), 0.9
+		}
+	}
+	return "", 0
+}
+
+// prices are the priced lines of a receipt. The amounts are in cents, and -1 when the receipt has none.
+type prices struct {
+	// items are the item lines above the subtotal or total.
+	items []server.Item
+	// total, subtotal, tax and tip are the amounts of their lines. Several tax lines are added up.
+	total, subtotal, tax, tip int64
+	// discounts is the sum of the discounts on the items, as a positive amount.
+	discounts int64
+	// payment is how the receipt was paid, from the first line with an amount tendered, or empty.
+	payment server.PaymentMethod
+}
+
+// scanPrices reads the priced lines: the items and the discounts on them, up to the first subtotal or total, the
+// amounts of the subtotal, tax, tip and total, and how the receipt was paid.
+func scanPrices(lines []string) prices {
+	p := prices{items: make([]server.Item, 0), total: -1, subtotal: -1, tax: -1, tip: -1}
+	for _, line := range lines {
+		m := priceLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		label := strings.TrimSpace(m[1])
+		cents, ok := parseCents(m[3])
+		if !ok {
+			continue
+		}
+		switch {
+		case subtotalLabel.MatchString(label):
+			if p.subtotal < 0 {
+				p.subtotal = cents
+			}
+		case totalLabel.MatchString(label):
+			if p.total < 0 {
+				p.total = cents
+			}
+		case taxLabel.MatchString(label):
+			if p.total < 0 {
+				p.tax = max(p.tax, 0) + cents
+			}
+		case tipLabel.MatchString(label):
+			if p.tip < 0 {
+				p.tip = cents
+			}
+		case p.total >= 0 || p.subtotal >= 0 || otherLabel.MatchString(label):
+			// Nothing after the totals is an item, and neither are payments
+			if p.payment == "" {
+				p.payment = paymentMethod(label)
+			}
+		case m[2] != "" || m[4] != "":
+			// Discounts are printed as negative prices
+			p.discounts += cents
+		default:
+			description := strings.TrimSpace(spaces.ReplaceAllString(descriptionChars.ReplaceAllString(label, " "), " "))
+			if strings.IndexFunc(description, unicode.IsLetter) < 0 || clock.MatchString(line) {
+				continue
+			}
+			p.items = append(p.items, server.Item{ShortDescription: description, Price: formatCents(cents)})
+		}
+	}
+	return p
+}
+
+// paymentMethod returns the payment method named by the label of a priced line, or empty if it names none.
+func paymentMethod(label string) server.PaymentMethod {
+	for _, payment := range paymentLabels {
+		if payment.label.MatchString(label) {
+			return payment.method
+		}
+	}
+	return ""
+}
+
+// parseCents converts a price with a decimal point or comma into cents.
+func parseCents(price string) (int64, bool) {
+	whole, fraction, ok := strings.Cut(strings.Replace(price, ",", ".", 1), ".")
+	if !ok || len(fraction) != 2 {
+		return 0, false
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	f, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return w*100 + f, true
+}
+
+// formatCents formats cents as a price with two decimals, as the Receipt schema expects.
+func formatCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// optional returns a pointer to the value, for the optional fields of a receipt.
+func optional(value string) *string {
+	return &value
+}
@@ -0,0 +1,92 @@
+package textparse
+
+import (
+	"encoding/json"
+	"fetch-app/normalize"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected results of the sample receipts")
+
+// TestCorpus parses each sample receipt in testdata and compares the result with the .json file next to it.
+// Run with -update to rewrite the expected results after changing the parser, then review the diff.
+func TestCorpus(t *testing.T) {
+	samples, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, samples)
+	for _, sample := range samples {
+		t.Run(filepath.Base(sample), func(t *testing.T) {
+			text, err := os.ReadFile(sample)
+			assert.NoError(t, err)
+			result, err := Parse(string(text), normalize.MonthFirst)
+			assert.NoError(t, err)
+			actual, err := json.MarshalIndent(result, "", "  ")
+			assert.NoError(t, err)
+
+			golden := strings.TrimSuffix(sample, ".txt") + ".json"
+			if *update {
+				assert.NoError(t, os.WriteFile(golden, append(actual, '\n'), 0o644))
+			}
+			expected, err := os.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+// TestParseMissing tests that text without a receipt reports what is missing.
+func TestParseMissing(t *testing.T) {
+	_, err := Parse("Thanks for shopping with us!\nSee you soon", normalize.MonthFirst)
+	var missing *MissingError
+	if assert.ErrorAs(t, err, &missing) {
+		assert.Equal(t, []string{"purchaseDate", "purchaseTime", "items", "total"}, missing.Fields)
+	}
+}
+
+// TestParseDates tests the date formats and how ambiguous numeric dates are read.
+func TestParseDates(t *testing.T) {
+	tests := []struct {
+		line       string
+		date       string
+		confidence float64
+	}{
+		{"2022-01-02", "2022-01-02", 0.95},
+		{"01/02/2022", "2022-01-02", 0.6},
+		{"13/02/22", "2022-02-13", 0.85},
+		{"02-13-2022", "2022-02-13", 0.85},
+		{"2 January 2022", "2022-01-02", 0.9},
+		{"Feb. 30, 2022 and 2022-03-01", "2022-03-01", 0.95},
+	}
+	for _, tt := range tests {
+		date, confidence, found := purchaseDate([]string{tt.line}, normalize.MonthFirst)
+		if assert.True(t, found, tt.line) {
+			assert.Equal(t, tt.date, date.Format("2006-01-02"), tt.line)
+			assert.Equal(t, tt.confidence, confidence, tt.line)
+		}
+	}
+
+	// Only dates that could be read either way follow the configured order
+	date, _, _ := purchaseDate([]string{"01/02/2022"}, normalize.DayFirst)
+	assert.Equal(t, "2022-02-01", date.Format("2006-01-02"))
+	date, _, _ = purchaseDate([]string{"02-13-2022"}, normalize.DayFirst)
+	assert.Equal(t, "2022-02-13", date.Format("2006-01-02"))
+}
+
+// TestParseTimes tests that times are converted to 24-hour time.
+func TestParseTimes(t *testing.T) {
+	for line, expected := range map[string]string{
+		"12:05 AM":    "00:05",
+		"12:05pm":     "12:05",
+		"1:01 p.m.":   "13:01",
+		"23:59:10":    "23:59",
+		"25:00 14:30": "14:30",
+	} {
+		actual, _ := purchaseTime([]string{line})
+		assert.Equal(t, expected, actual, line)
+	}
+}
@@ -0,0 +1,213 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Validator checks HTTP requests and responses against an OpenAPI document.
+// It only depends on net/http, so it can sit in front of any router.
+type Validator struct {
+	doc    *openapi3.T
+	router routers.Router
+}
+
+// Route is a request matched to an operation of the document, kept to validate the response to it.
+type Route struct {
+	input *openapi3filter.RequestValidationInput
+}
+
+// FieldError describes one way a value violates the document.
+type FieldError struct {
+	// Field locates the value: a JSON pointer such as "/items/0/price" for bodies, or the parameter name.
+	Field string `json:"field"`
+	// Message explains what is wrong with the value.
+	Message string `json:"message"`
+}
+
+// Error reports every way a request or response violates the document.
+type Error struct {
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"errors,omitempty"`
+}
+
+// Error returns the message followed by each field error.
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.Field+": "+field.Message)
+	}
+	if len(parts) == 0 {
+		return e.Message
+	}
+	return e.Message + ": " + strings.Join(parts, "; ")
+}
+
+// Streamed exports are documented as plain strings, which kin-openapi only knows how to decode for some content
+// types, so newline-delimited JSON is read the same way as text.
+func init() {
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", func(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encFn openapi3filter.EncodingFn) (any, error) {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	})
+}
+
+// New creates a Validator for the document. Its servers are ignored, so routes match on the path alone
+// wherever the API is hosted.
+func New(swagger *openapi3.T) (*Validator, error) {
+	swagger.Servers = nil
+	if err := swagger.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	router, err := gorillamux.NewRouter(swagger)
+	if err != nil {
+		return nil, fmt.Errorf("building OpenAPI router: %w", err)
+	}
+	return &Validator{doc: swagger, router: router}, nil
+}
+
+// options validates every field rather than stopping at the first error, and leaves authentication to the
+// server, which knows the configured credentials.
+func options() *openapi3filter.Options {
+	return &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+}
+
+// ValidateRequest checks the parameters and body of a request against its operation.
+// The request body is restored, so handlers can still read it.
+//
+// Returns:
+//
+//	The matched route, or nil if the document has no operation for the request, which leaves the server to
+//	respond as it would without validation.
+//	An *Error listing every violation if the request does not match its operation.
+func (v *Validator) ValidateRequest(r *http.Request) (*Route, error) {
+	route, pathParams, err := v.router.FindRoute(r)
+	if err != nil {
+		return nil, nil
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    options(),
+	}
+	if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+		return nil, newError("The request does not match the API contract", err)
+	}
+	return &Route{input: input}, nil
+}
+
+// ValidateResponse checks a response against the operation of the route it answers.
+// Statuses the operation does not document are violations too.
+//
+// Returns:
+//
+//	An *Error listing every violation if the response does not match its operation.
+func (v *Validator) ValidateResponse(route *Route, status int, header http.Header, body []byte) error {
+	opts := options()
+	opts.IncludeResponseStatus = true
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: route.input,
+		Status:                 status,
+		Header:                 header,
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		Options:                opts,
+	}
+	if err := openapi3filter.ValidateResponse(route.input.Request.Context(), input); err != nil {
+		return newError("The response does not match the API contract", err)
+	}
+	return nil
+}
+
+// ValidateSchema checks a value against a schema of the document, for callers that do not go through HTTP.
+// The value is checked in its JSON representation.
+//
+// Parameters:
+//
+//	name  - The name of the schema under components/schemas, such as "Receipt".
+//	value - The value to check.
+//
+// Returns:
+//
+//	An *Error listing every violation if the value does not match the schema.
+func (v *Validator) ValidateSchema(name string, value interface{}) error {
+	schema, ok := v.doc.Components.Schemas[name]
+	if !ok || schema.Value == nil {
+		return fmt.Errorf("unknown schema %q", name)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if err := schema.Value.VisitJSON(decoded, openapi3.MultiErrors()); err != nil {
+		return newError("The value does not match the API contract", err)
+	}
+	return nil
+}
+
+// newError flattens the errors reported by kin-openapi into field errors.
+func newError(message string, err error) *Error {
+	return &Error{Message: message, Fields: fieldErrors(err, "")}
+}
+
+// fieldErrors walks an error tree from kin-openapi, collecting a FieldError for each leaf.
+func fieldErrors(err error, field string) []FieldError {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		var fields []FieldError
+		for _, e := range multi {
+			fields = append(fields, fieldErrors(e, field)...)
+		}
+		return fields
+	}
+
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) {
+		if requestErr.Parameter != nil {
+			field = requestErr.Parameter.Name
+		}
+		if requestErr.Err != nil {
+			return fieldErrors(requestErr.Err, field)
+		}
+		return []FieldError{{Field: field, Message: requestErr.Reason}}
+	}
+
+	var responseErr *openapi3filter.ResponseError
+	if errors.As(err, &responseErr) {
+		if responseErr.Err != nil {
+			return fieldErrors(responseErr.Err, field)
+		}
+		return []FieldError{{Field: field, Message: responseErr.Reason}}
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			field += "/" + strings.Join(pointer, "/")
+		}
+		return []FieldError{{Field: field, Message: schemaErr.Reason}}
+	}
+
+	return []FieldError{{Field: field, Message: err.Error()}}
+}
@@ -0,0 +1,165 @@
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fetch-app/server"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// validReceipt is a receipt that satisfies the contract.
+const validReceipt = `{
+	"retailer": "M&M Corner Market",
+	"purchaseDate": "2022-03-20",
+	"purchaseTime": "14:33",
+	"items": [{"shortDescription": "Gatorade", "price": "2.25"}],
+	"total": "2.25"
+}`
+
+// newTestValidator creates a validator for the embedded spec.
+func newTestValidator(t *testing.T) *Validator {
+	swagger, err := server.GetSwagger()
+	if err != nil {
+		t.Fatalf("Error loading spec: %v", err)
+	}
+	validator, err := New(swagger)
+	if err != nil {
+		t.Fatalf("Error creating validator: %v", err)
+	}
+	return validator
+}
+
+// newRequest creates a JSON request.
+func newRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestValidateRequest(t *testing.T) {
+	validator := newTestValidator(t)
+
+	req := newRequest(http.MethodPost, "/receipts/process", validReceipt)
+	route, err := validator.ValidateRequest(req)
+	assert.NoError(t, err)
+	assert.NotNil(t, route)
+
+	// The body can still be read by the handler
+	body, _ := io.ReadAll(req.Body)
+	assert.JSONEq(t, validReceipt, string(body))
+}
+
+func TestValidateRequestViolations(t *testing.T) {
+	validator := newTestValidator(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []FieldError
+	}{
+		{
+			"pattern",
+			`{"retailer": "Target", "purchaseDate": "2022-03-20", "purchaseTime": "14:33",
+			  "items": [{"shortDescription": "Gatorade", "price": "2.5"}], "total": "2.50"}`,
+			[]FieldError{{Field: "/items/0/price", Message: `string doesn't match the regular expression "^\d+(\.\d{2,3})?$"`}},
+		},
+		{
+			"required",
+			`{"retailer": "Target", "purchaseDate": "2022-03-20", "purchaseTime": "14:33", "items": [{"price": "2.50"}]}`,
+			[]FieldError{
+				{Field: "/items/0/shortDescription", Message: `property "shortDescription" is missing`},
+				{Field: "/total", Message: `property "total" is missing`},
+			},
+		},
+		{
+			"format",
+			`{"retailer": "Target", "purchaseDate": "03/20/2022", "purchaseTime": "14:33",
+			  "items": [{"shortDescription": "Gatorade", "price": "2.50"}], "total": "2.50"}`,
+			[]FieldError{{Field: "/purchaseDate", Message: `string doesn't match the format "date" (string doesn't match pattern "^[0-9]{4}-(0[1-9]|10|11|12)-(0[1-9]|[12][0-9]|3[01])$")`}},
+		},
+		{
+			"optional fields",
+			`{"retailer": "Target", "purchaseDate": "2022-03-20", "purchaseTime": "14:33", "paymentMethod": "barter",
+			  "items": [{"shortDescription": "Gatorade", "price": "2.50", "quantity": 0}], "total": "2.50", "tax": "0.2"}`,
+			[]FieldError{
+				{Field: "/paymentMethod", Message: `value is not one of the allowed values ["cash","credit","debit","gift_card","mobile","check","other"]`},
+				{Field: "/items/0/quantity", Message: `number must be at least 1`},
+				{Field: "/tax", Message: `string doesn't match the regular expression "^\d+(\.\d{2,3})?$"`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := validator.ValidateRequest(newRequest(http.MethodPost, "/receipts/process", test.body))
+			var validationErr *Error
+			assert.True(t, errors.As(err, &validationErr))
+			assert.ElementsMatch(t, test.expected, validationErr.Fields)
+		})
+	}
+}
+
+func TestValidateRequestUnicode(t *testing.T) {
+	validator := newTestValidator(t)
+
+	body := `{"retailer": "Café Ñandú", "purchaseDate": "2022-03-20", "purchaseTime": "9:05",
+	          "items": [{"shortDescription": "東京ラーメン 🍜", "price": "12.00"}], "total": "12.00"}`
+	_, err := validator.ValidateRequest(newRequest(http.MethodPost, "/receipts/process", body))
+	assert.NoError(t, err)
+}
+
+func TestValidateRequestOptionalFields(t *testing.T) {
+	validator := newTestValidator(t)
+
+	body := `{"retailer": "Target", "purchaseDate": "2022-03-20", "purchaseTime": "14:33",
+	          "items": [{"shortDescription": "Gatorade", "price": "4.49", "quantity": 2, "unitPrice": "2.25", "discount": "0.01"}],
+	          "subtotal": "4.49", "tax": "0.36", "tip": "0.00", "total": "4.85",
+	          "paymentMethod": "debit", "storeNumber": "1234", "receiptNumber": "0042-7781"}`
+	_, err := validator.ValidateRequest(newRequest(http.MethodPost, "/receipts/process", body))
+	assert.NoError(t, err)
+}
+
+func TestValidateRequestUnknownRoute(t *testing.T) {
+	validator := newTestValidator(t)
+
+	route, err := validator.ValidateRequest(httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.NoError(t, err)
+	assert.Nil(t, route)
+}
+
+func TestValidateResponse(t *testing.T) {
+	validator := newTestValidator(t)
+	route, err := validator.ValidateRequest(httptest.NewRequest(http.MethodGet, "/receipts/abc/points", nil))
+	assert.NoError(t, err)
+
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	assert.NoError(t, validator.ValidateResponse(route, http.StatusOK, header, []byte(`{"points": 10}`)))
+	assert.NoError(t, validator.ValidateResponse(route, http.StatusAccepted, header, []byte(`{"status": "pending_review"}`)))
+
+	// Wrong types, missing fields and undocumented statuses are all drift
+	err = validator.ValidateResponse(route, http.StatusOK, header, []byte(`{"points": "10"}`))
+	assert.Error(t, err)
+	err = validator.ValidateResponse(route, http.StatusAccepted, header, []byte(`{}`))
+	assert.Error(t, err)
+	err = validator.ValidateResponse(route, http.StatusTeapot, header, []byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestValidateSchema(t *testing.T) {
+	validator := newTestValidator(t)
+
+	item := server.Item{ShortDescription: "Gatorade", Price: "2.25"}
+	assert.NoError(t, validator.ValidateSchema("Item", item))
+
+	item.Price = "2.5"
+	err := validator.ValidateSchema("Item", item)
+	var validationErr *Error
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []FieldError{{Field: "/price", Message: `string doesn't match the regular expression "^\d+(\.\d{2,3})?$"`}}, validationErr.Fields)
+
+	assert.Error(t, validator.ValidateSchema("Unknown", item))
+}